@@ -3,11 +3,23 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	TrialScopePlan   = "plan"
+	TrialScopeGlobal = "global"
+)
+
+const (
+	GrandfatheringRenovacion = "renovacion"
+	GrandfatheringPermanente = "permanente"
+)
+
 type (
 	Config struct {
 		AppName       string
@@ -17,6 +29,76 @@ type (
 		DatabaseName  string
 		JWTSecret     string
 		JWTExpiration time.Duration
+		Scheduler     SchedulerConfig
+		Suscripciones SuscripcionesConfig
+		Pagos         PagosConfig
+	}
+
+	SchedulerConfig struct {
+		Enabled          bool
+		LeaseDuration    time.Duration
+		ExpiryInterval   time.Duration
+		ExpiryBatchSize  int
+		RenewalInterval  time.Duration
+		RenewalLeadTime  time.Duration
+		RenewalBatchSize int
+		TrialInterval    time.Duration
+		PauseInterval    time.Duration
+		// PlanChangeInterval es cada cuánto se aplican los cambios de plan
+		// programados que ya llegaron a su fecha.
+		PlanChangeInterval time.Duration
+		// ReminderInterval es cada cuánto se buscan suscripciones por vencer
+		// para avisar a sus usuarios.
+		ReminderInterval time.Duration
+		// PendingPaymentInterval es cada cuánto se cancelan las altas cuyo
+		// pago no llegó a tiempo.
+		PendingPaymentInterval time.Duration
+		// PendingEventsInterval es cada cuánto se copian al historial los
+		// eventos que quedaron guardados en las suscripciones.
+		PendingEventsInterval time.Duration
+	}
+
+	SuscripcionesConfig struct {
+		// FallbackPlanID es el plan al que se mueve una suscripción al renovarse
+		// cuando su plan fue desactivado. Vacío significa dejarla vencer.
+		FallbackPlanID string
+		// TrialScope define si la prueba gratuita se permite una vez por plan
+		// ("plan") o una sola vez por usuario en cualquier plan ("global").
+		TrialScope string
+		// Grandfathering define hasta cuándo una suscripción conserva la versión
+		// del plan con la que se suscribió: hasta la próxima renovación
+		// ("renovacion") o mientras no se la migre ("permanente").
+		Grandfathering string
+		// MonedaDefault es la moneda ISO 4217 que se usa cuando el cliente no
+		// elige una al suscribirse o al validar un cupón.
+		MonedaDefault string
+		// EntitlementsCacheTTL es cuánto se guardan en memoria los derechos
+		// resueltos. Acota lo que tarda otra réplica en ver un cambio.
+		EntitlementsCacheTTL time.Duration
+		// RegaloVigencia es cuánto tiempo se puede canjear el código de un
+		// regalo desde que se compra.
+		RegaloVigencia time.Duration
+		// DiasRecordatorio son las ventanas, en días antes de fecha_fin, en las
+		// que se avisa que una suscripción que no se renueva está por vencer.
+		DiasRecordatorio []int
+		// PagoPendienteTTL es cuánto espera un alta su primer pago antes de
+		// cancelarse.
+		PagoPendienteTTL time.Duration
+		// UsoReserva es cuánto tiene un proceso para registrar un evento de
+		// uso antes de que un reintento con la misma clave pueda tomarlo.
+		UsoReserva time.Duration
+	}
+
+	PagosConfig struct {
+		// WebhookSecret firma los webhooks de la pasarela. Vacío rechaza todos.
+		WebhookSecret string
+		// FakeAutoConfirmar hace que la pasarela de desarrollo apruebe los
+		// cobros al crearlos, sin esperar un webhook. Está apagado salvo que se
+		// pida: si no, todo cobro se daría por pagado.
+		FakeAutoConfirmar bool
+		// WebhookReserva es cuánto tiene un proceso para terminar un webhook
+		// antes de que un reintento del mismo evento pueda tomarlo.
+		WebhookReserva time.Duration
 	}
 )
 
@@ -34,7 +116,88 @@ func NewConfig() (*Config, error) {
 		DatabaseName:  os.Getenv("DATABASE_NAME"),
 		JWTSecret:     os.Getenv("JWT_SECRET"),
 		JWTExpiration: 24 * time.Hour,
+		Scheduler: SchedulerConfig{
+			Enabled:                getEnvBool("SCHEDULER_ENABLED", true),
+			LeaseDuration:          getEnvDuration("SCHEDULER_LEASE_DURATION", 5*time.Minute),
+			ExpiryInterval:         getEnvDuration("SCHEDULER_EXPIRY_INTERVAL", 5*time.Minute),
+			ExpiryBatchSize:        getEnvInt("SCHEDULER_EXPIRY_BATCH_SIZE", 500),
+			RenewalInterval:        getEnvDuration("SCHEDULER_RENEWAL_INTERVAL", 15*time.Minute),
+			RenewalLeadTime:        getEnvDuration("SCHEDULER_RENEWAL_LEAD_TIME", 24*time.Hour),
+			RenewalBatchSize:       getEnvInt("SCHEDULER_RENEWAL_BATCH_SIZE", 100),
+			TrialInterval:          getEnvDuration("SCHEDULER_TRIAL_INTERVAL", 15*time.Minute),
+			PauseInterval:          getEnvDuration("SCHEDULER_PAUSE_INTERVAL", 15*time.Minute),
+			PlanChangeInterval:     getEnvDuration("SCHEDULER_PLAN_CHANGE_INTERVAL", 15*time.Minute),
+			ReminderInterval:       getEnvDuration("SCHEDULER_REMINDER_INTERVAL", time.Hour),
+			PendingPaymentInterval: getEnvDuration("SCHEDULER_PENDING_PAYMENT_INTERVAL", 15*time.Minute),
+			PendingEventsInterval:  getEnvDuration("SCHEDULER_PENDING_EVENTS_INTERVAL", 5*time.Minute),
+		},
+		Suscripciones: SuscripcionesConfig{
+			FallbackPlanID:       os.Getenv("FALLBACK_PLAN_ID"),
+			TrialScope:           getEnvString("TRIAL_SCOPE", TrialScopePlan),
+			Grandfathering:       getEnvString("GRANDFATHERING", GrandfatheringRenovacion),
+			MonedaDefault:        getEnvString("MONEDA_DEFAULT", "BOB"),
+			EntitlementsCacheTTL: getEnvDuration("ENTITLEMENTS_CACHE_TTL", time.Minute),
+			RegaloVigencia:       getEnvDuration("REGALO_VIGENCIA", 365*24*time.Hour),
+			DiasRecordatorio:     getEnvIntList("DIAS_RECORDATORIO_VENCIMIENTO", []int{7, 3, 1}),
+			PagoPendienteTTL:     getEnvDuration("PENDING_PAYMENT_TTL", 24*time.Hour),
+			UsoReserva:           getEnvDuration("USAGE_EVENT_LEASE", time.Minute),
+		},
+		Pagos: PagosConfig{
+			WebhookSecret:     os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+			FakeAutoConfirmar: getEnvBool("PAYMENT_FAKE_AUTO_CONFIRM", false),
+			WebhookReserva:    getEnvDuration("PAYMENT_WEBHOOK_LEASE", 5*time.Minute),
+		},
 	}
 
 	return cfg, nil
 }
+
+func getEnvString(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvIntList lee una lista de enteros positivos separados por coma; si
+// alguno no es válido usa defaultValue.
+func getEnvIntList(key string, defaultValue []int) []int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+
+	var values []int
+	for _, part := range strings.Split(raw, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value <= 0 {
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
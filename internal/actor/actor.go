@@ -0,0 +1,23 @@
+package actor
+
+import (
+	"context"
+	"sw2p2go/internal/entity"
+)
+
+type actorKey struct{}
+
+// With guarda en el contexto quién origina una operación, para dejarlo
+// registrado en los cambios de estado.
+func With(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// FromContext devuelve el actor de la operación o "sistema" cuando la
+// originan las tareas programadas.
+func FromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return entity.ActorSistema
+}
@@ -10,6 +10,9 @@ import (
 	"sw2p2go/config"
 	v1 "sw2p2go/internal/controller/http/v1"
 	"sw2p2go/internal/middleware"
+	"sw2p2go/internal/notification"
+	"sw2p2go/internal/payment"
+	"sw2p2go/internal/scheduler"
 	"sw2p2go/internal/usecase/repositories"
 	"sw2p2go/internal/usecase/services"
 	"syscall"
@@ -20,9 +23,10 @@ import (
 )
 
 type App struct {
-	config   *config.Config
-	router   *v1.Router
-	database *mongo.Database
+	config    *config.Config
+	router    *v1.Router
+	database  *mongo.Database
+	scheduler *scheduler.Scheduler
 }
 
 func NewApp(cfg *config.Config) *App {
@@ -62,6 +66,10 @@ func (a *App) initDatabase() error {
 
 	a.database = client.Database(a.config.DatabaseName)
 
+	if err := repositories.CrearIndices(ctx, a.database); err != nil {
+		return fmt.Errorf("error creando índices: %w", err)
+	}
+
 	log.Printf("Conectado exitosamente a MongoDB: %s", a.config.DatabaseName)
 	return nil
 }
@@ -70,22 +78,89 @@ func (a *App) initDependencies() {
 
 	usuarioRepo := repositories.NewUsuarioRepository(a.database)
 	planRepo := repositories.NewPlanRepository(a.database)
+	versionRepo := repositories.NewVersionPlanRepository(a.database)
 	suscripcionRepo := repositories.NewSuscripcionRepository(a.database)
-
-	usuarioService := services.NewUsuarioService(usuarioRepo, a.config)
-	planService := services.NewPlanService(planRepo, suscripcionRepo)
-	suscripcionService := services.NewSuscripcionService(suscripcionRepo, usuarioRepo, planRepo)
+	renovacionRepo := repositories.NewRenovacionRepository(a.database)
+	cambioPlanRepo := repositories.NewCambioPlanRepository(a.database)
+	cambioAsientosRepo := repositories.NewCambioAsientosRepository(a.database)
+	asientoRepo := repositories.NewAsientoRepository(a.database)
+	complementoRepo := repositories.NewComplementoRepository(a.database)
+	regaloRepo := repositories.NewRegaloRepository(a.database)
+	cambioProgramadoRepo := repositories.NewCambioProgramadoRepository(a.database)
+	creditoRepo := repositories.NewCreditoRepository(a.database)
+	reporteRepo := repositories.NewReporteRepository(a.database)
+	recordatorioRepo := repositories.NewRecordatorioRepository(a.database)
+	eventoRepo := repositories.NewEventoSuscripcionRepository(a.database)
+	cuponRepo := repositories.NewCuponRepository(a.database)
+	canjeRepo := repositories.NewCanjeCuponRepository(a.database)
+	facturaRepo := repositories.NewFacturaRepository(a.database)
+	contadorRepo := repositories.NewContadorRepository(a.database)
+	eventoPagoRepo := repositories.NewEventoPagoRepository(a.database)
+	tasaRepo := repositories.NewTasaImpuestoRepository(a.database)
+	eventoUsoRepo := repositories.NewEventoUsoRepository(a.database)
+	usoPeriodoRepo := repositories.NewUsoPeriodoRepository(a.database)
+
+	gateway := payment.NewFakeGateway(a.config.Pagos.FakeAutoConfirmar)
+	canal := notification.NewLogCanal()
+	tareaRepo := repositories.NewTareaRepository(a.database)
+
+	entitlementsCache := services.NewEntitlementsCache(a.config.Suscripciones.EntitlementsCacheTTL)
+
+	usuarioService := services.NewUsuarioService(usuarioRepo, suscripcionRepo, eventoRepo, a.config)
+	planService := services.NewPlanService(planRepo, versionRepo, suscripcionRepo, entitlementsCache)
+	suscripcionService := services.NewSuscripcionService(suscripcionRepo, usuarioRepo, planRepo, versionRepo, renovacionRepo, cambioPlanRepo, cambioAsientosRepo, asientoRepo, complementoRepo, regaloRepo, cambioProgramadoRepo, creditoRepo, eventoRepo, cuponRepo, canjeRepo, facturaRepo, contadorRepo, eventoPagoRepo, tasaRepo, gateway, entitlementsCache, a.config)
+	cuponService := services.NewCuponService(cuponRepo, canjeRepo, planRepo, a.config)
+	complementoService := services.NewComplementoService(complementoRepo, planRepo)
+	creditoService := services.NewCreditoService(creditoRepo, usuarioRepo, facturaRepo, gateway, a.config)
+	reporteService := services.NewReporteService(reporteRepo, a.config)
+	recordatorioService := services.NewRecordatorioService(suscripcionRepo, usuarioRepo, planRepo, recordatorioRepo, canal, a.config)
+	facturaService := services.NewFacturaService(facturaRepo, contadorRepo, creditoRepo)
+	impuestoService := services.NewImpuestoService(tasaRepo, usuarioRepo, planRepo, a.config)
+	entitlementService := services.NewEntitlementService(suscripcionRepo, planRepo, versionRepo, entitlementsCache)
+	usoService := services.NewUsoService(suscripcionRepo, planRepo, versionRepo, eventoUsoRepo, usoPeriodoRepo, a.config)
+
+	a.scheduler = scheduler.NewScheduler(tareaRepo, a.config.Scheduler.LeaseDuration)
+	a.scheduler.Register(scheduler.TareaVencerSuscripciones, a.config.Scheduler.ExpiryInterval, suscripcionService.ExpireSuscripciones)
+	a.scheduler.Register(scheduler.TareaRenovarSuscripciones, a.config.Scheduler.RenewalInterval, suscripcionService.RenewSuscripciones)
+	a.scheduler.Register(scheduler.TareaProcesarPruebas, a.config.Scheduler.TrialInterval, suscripcionService.ProcessTrials)
+	a.scheduler.Register(scheduler.TareaReanudarPausas, a.config.Scheduler.PauseInterval, suscripcionService.ResumePausedSuscripciones)
+	a.scheduler.Register(scheduler.TareaAplicarCambiosPlan, a.config.Scheduler.PlanChangeInterval, suscripcionService.AplicarCambiosProgramados)
+	a.scheduler.Register(scheduler.TareaCancelarPagosPendientes, a.config.Scheduler.PendingPaymentInterval, suscripcionService.CancelarPagosPendientes)
+	a.scheduler.Register(scheduler.TareaPublicarEventos, a.config.Scheduler.PendingEventsInterval, suscripcionService.PublicarEventosPendientes)
+	a.scheduler.Register(scheduler.TareaRecordarVencimientos, a.config.Scheduler.ReminderInterval, recordatorioService.EnviarRecordatoriosVencimiento)
 
 	authMiddleware := middleware.NewAuthMiddleware(a.config.JWTSecret)
 
 	usuarioHandler := v1.NewUsuarioHandler(usuarioService)
 	planHandler := v1.NewPlanHandler(planService)
 	suscripcionHandler := v1.NewSuscripcionHandler(suscripcionService)
+	tareaHandler := v1.NewTareaHandler(a.scheduler)
+	cuponHandler := v1.NewCuponHandler(cuponService)
+	facturaHandler := v1.NewFacturaHandler(facturaService)
+	pagoHandler := v1.NewPagoHandler(suscripcionService)
+	impuestoHandler := v1.NewImpuestoHandler(impuestoService)
+	entitlementHandler := v1.NewEntitlementHandler(entitlementService)
+	usoHandler := v1.NewUsoHandler(usoService)
+	complementoHandler := v1.NewComplementoHandler(complementoService)
+	regaloHandler := v1.NewRegaloHandler(suscripcionService)
+	creditoHandler := v1.NewCreditoHandler(creditoService)
+	reporteHandler := v1.NewReporteHandler(reporteService)
 
 	a.router = v1.NewRouter(
 		usuarioHandler,
 		planHandler,
 		suscripcionHandler,
+		tareaHandler,
+		cuponHandler,
+		facturaHandler,
+		pagoHandler,
+		impuestoHandler,
+		entitlementHandler,
+		usoHandler,
+		complementoHandler,
+		regaloHandler,
+		creditoHandler,
+		reporteHandler,
 		authMiddleware,
 	)
 }
@@ -98,7 +173,15 @@ func (a *App) GetDatabase() *mongo.Database {
 	return a.database
 }
 
+func (a *App) GetScheduler() *scheduler.Scheduler {
+	return a.scheduler
+}
+
 func (a *App) Close() error {
+	if a.scheduler != nil {
+		a.scheduler.Stop()
+	}
+
 	if a.database != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -123,6 +206,10 @@ func Run(cfg *config.Config) {
 
 	router := app.GetRouter().SetupRoutes()
 
+	if cfg.Scheduler.Enabled {
+		app.GetScheduler().Start()
+	}
+
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%s", cfg.HTTPPort),
 		Handler: router,
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type entrada[V any] struct {
+	valor V
+	vence time.Time
+}
+
+// TTL es un caché en memoria con vencimiento por entrada. Es local a cada
+// réplica: una invalidación solo limpia la réplica que la hace, las demás ven
+// el cambio cuando vence la entrada.
+type TTL[K comparable, V any] struct {
+	mu         sync.RWMutex
+	ttl        time.Duration
+	entradas   map[K]entrada[V]
+	generacion uint64
+	purgadoEn  time.Time
+}
+
+func NewTTL[K comparable, V any](ttl time.Duration) *TTL[K, V] {
+	return &TTL[K, V]{
+		ttl:      ttl,
+		entradas: make(map[K]entrada[V]),
+	}
+}
+
+func (c *TTL[K, V]) Get(clave K) (V, bool) {
+	c.mu.RLock()
+	e, ok := c.entradas[clave]
+	c.mu.RUnlock()
+
+	if !ok || !time.Now().Before(e.vence) {
+		var cero V
+		return cero, false
+	}
+	return e.valor, true
+}
+
+// Generacion cambia con cada invalidación. Quien va a cargar un valor la lee
+// antes de consultar la base y se la pasa a Set.
+func (c *TTL[K, V]) Generacion() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generacion
+}
+
+// Set guarda el valor hasta que pase el TTL o hasta limite, lo que ocurra
+// primero (un limite cero no acota). Si hubo una invalidación desde que se
+// leyó generacion el valor puede estar desactualizado y no se guarda.
+func (c *TTL[K, V]) Set(clave K, valor V, limite time.Time, generacion uint64) {
+	vence := time.Now().Add(c.ttl)
+	if !limite.IsZero() && limite.Before(vence) {
+		vence = limite
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generacion != c.generacion {
+		return
+	}
+
+	c.purgarVencidas()
+	c.entradas[clave] = entrada[V]{valor: valor, vence: vence}
+}
+
+func (c *TTL[K, V]) Delete(clave K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generacion++
+	delete(c.entradas, clave)
+}
+
+func (c *TTL[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generacion++
+	c.entradas = make(map[K]entrada[V])
+}
+
+// purgarVencidas evita que el mapa crezca con entradas que nadie vuelve a
+// leer. Recorre el mapa a lo sumo una vez por TTL; se llama con el lock tomado.
+func (c *TTL[K, V]) purgarVencidas() {
+	now := time.Now()
+	if now.Sub(c.purgadoEn) < c.ttl {
+		return
+	}
+	c.purgadoEn = now
+
+	for clave, e := range c.entradas {
+		if !now.Before(e.vence) {
+			delete(c.entradas, clave)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ComplementoHandler struct {
+	complementoService services.ComplementoService
+}
+
+func NewComplementoHandler(complementoService services.ComplementoService) *ComplementoHandler {
+	return &ComplementoHandler{
+		complementoService: complementoService,
+	}
+}
+
+func (h *ComplementoHandler) CreateComplemento(c *gin.Context) {
+	var req dto.CreateComplementoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	complemento, err := h.complementoService.CreateComplemento(c.Request.Context(), &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "plan no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if isComplementoRequestError(err) {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error creando complemento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse("Complemento creado exitosamente", complemento))
+}
+
+func (h *ComplementoHandler) GetAllComplementos(c *gin.Context) {
+	showInactive, _ := strconv.ParseBool(c.DefaultQuery("show_inactive", "false"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	complementos, total, err := h.complementoService.GetAllComplementos(c.Request.Context(), c.Query("plan_id"), showInactive, limit, offset)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "ID de plan inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error obteniendo complementos", err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta := dto.MetaData{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+
+	response := &dto.PaginatedResponse{
+		Success: true,
+		Message: "Complementos obtenidos exitosamente",
+		Data:    complementos,
+		Meta:    meta,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ComplementoHandler) GetComplementoByID(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	complemento, err := h.complementoService.GetComplementoByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("Complemento no encontrado", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Complemento obtenido exitosamente", complemento))
+}
+
+func (h *ComplementoHandler) UpdateComplemento(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	var req dto.UpdateComplementoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	if err := h.complementoService.UpdateComplemento(c.Request.Context(), id, &req); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "complemento no encontrado" || err.Error() == "plan no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if isComplementoRequestError(err) || err.Error() == "ID de complemento inválido" ||
+			err.Error() == "no hay campos para actualizar" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error actualizando complemento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Complemento actualizado exitosamente", nil))
+}
+
+func (h *ComplementoHandler) DeleteComplemento(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	if err := h.complementoService.DeleteComplemento(c.Request.Context(), id); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "complemento no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "ID de complemento inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error eliminando complemento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Complemento eliminado exitosamente", nil))
+}
+
+func isComplementoRequestError(err error) bool {
+	return errors.Is(err, services.ErrMonedaNoSoportada) ||
+		errors.Is(err, services.ErrMonedaDuplicada) ||
+		errors.Is(err, services.ErrClaveDerechoInvalida) ||
+		errors.Is(err, services.ErrDerechoDuplicado) ||
+		err.Error() == "ID de plan inválido"
+}
@@ -0,0 +1,171 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/payment"
+	"sw2p2go/internal/usecase/repositories"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreditoHandler struct {
+	creditoService services.CreditoService
+}
+
+func NewCreditoHandler(creditoService services.CreditoService) *CreditoHandler {
+	return &CreditoHandler{
+		creditoService: creditoService,
+	}
+}
+
+func (h *CreditoHandler) GetMiSaldo(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	h.getSaldo(c, userID)
+}
+
+func (h *CreditoHandler) GetMisMovimientos(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	h.getMovimientos(c, userID)
+}
+
+func (h *CreditoHandler) GetSaldo(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	h.getSaldo(c, id)
+}
+
+func (h *CreditoHandler) GetMovimientos(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	h.getMovimientos(c, id)
+}
+
+func (h *CreditoHandler) OtorgarCredito(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	var req dto.OtorgarCreditoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	movimiento, err := h.creditoService.OtorgarCredito(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(creditoErrorStatus(err), dto.NewErrorResponse("Error otorgando crédito", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse("Crédito otorgado exitosamente", movimiento))
+}
+
+func (h *CreditoHandler) Reembolsar(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	var req dto.ReembolsarCreditoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	movimiento, err := h.creditoService.Reembolsar(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(creditoErrorStatus(err), dto.NewErrorResponse("Error reembolsando crédito", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse("Reembolso registrado exitosamente", movimiento))
+}
+
+func (h *CreditoHandler) getSaldo(c *gin.Context, userID string) {
+	saldo, err := h.creditoService.GetSaldo(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(creditoErrorStatus(err), dto.NewErrorResponse("Error obteniendo saldo de crédito", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Saldo de crédito obtenido exitosamente", saldo))
+}
+
+func (h *CreditoHandler) getMovimientos(c *gin.Context, userID string) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	movimientos, total, err := h.creditoService.GetMovimientos(c.Request.Context(), userID, c.Query("moneda"), limit, offset)
+	if err != nil {
+		c.JSON(creditoErrorStatus(err), dto.NewErrorResponse("Error obteniendo movimientos de crédito", err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta := dto.MetaData{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+
+	response := &dto.PaginatedResponse{
+		Success: true,
+		Message: "Movimientos de crédito obtenidos exitosamente",
+		Data:    movimientos,
+		Meta:    meta,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func creditoErrorStatus(err error) int {
+	switch {
+	case err.Error() == "usuario no encontrado" || err.Error() == "factura no encontrada":
+		return http.StatusNotFound
+	case err.Error() == "ID de usuario inválido" || err.Error() == "ID de suscripción inválido" ||
+		err.Error() == "ID de factura inválido" || errors.Is(err, services.ErrMonedaNoSoportada):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrSaldoInsuficiente) || errors.Is(err, repositories.ErrCreditoConcurrente) ||
+		errors.Is(err, services.ErrFacturaNoReembolsable) || errors.Is(err, payment.ErrReembolsoInvalido) ||
+		errors.Is(err, payment.ErrCobroNoEncontrado):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,210 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/repositories"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CuponHandler struct {
+	cuponService services.CuponService
+}
+
+func NewCuponHandler(cuponService services.CuponService) *CuponHandler {
+	return &CuponHandler{
+		cuponService: cuponService,
+	}
+}
+
+func (h *CuponHandler) CreateCupon(c *gin.Context) {
+	var req dto.CreateCuponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	cupon, err := h.cuponService.CreateCupon(c.Request.Context(), &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrCodigoCuponExistente) {
+			statusCode = http.StatusConflict
+		} else if err.Error() == "plan no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if isCuponRequestError(err) {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error creando cupón", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse("Cupón creado exitosamente", cupon))
+}
+
+func (h *CuponHandler) GetAllCupones(c *gin.Context) {
+	showInactive, _ := strconv.ParseBool(c.DefaultQuery("show_inactive", "false"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	cupones, total, err := h.cuponService.GetAllCupones(c.Request.Context(), showInactive, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Error obteniendo cupones", err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta := dto.MetaData{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+
+	response := &dto.PaginatedResponse{
+		Success: true,
+		Message: "Cupones obtenidos exitosamente",
+		Data:    cupones,
+		Meta:    meta,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CuponHandler) GetCuponByID(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	cupon, err := h.cuponService.GetCuponByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("Cupón no encontrado", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Cupón obtenido exitosamente", cupon))
+}
+
+func (h *CuponHandler) UpdateCupon(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	var req dto.UpdateCuponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	if err := h.cuponService.UpdateCupon(c.Request.Context(), id, &req); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "cupón no encontrado" || err.Error() == "plan no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if isCuponRequestError(err) || err.Error() == "ID de cupón inválido" ||
+			err.Error() == "no hay campos para actualizar" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error actualizando cupón", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Cupón actualizado exitosamente", nil))
+}
+
+func (h *CuponHandler) DeleteCupon(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	if err := h.cuponService.DeleteCupon(c.Request.Context(), id); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "cupón no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "ID de cupón inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error eliminando cupón", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Cupón eliminado exitosamente", nil))
+}
+
+func (h *CuponHandler) ValidarCupon(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.ValidarCuponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	validacion, err := h.cuponService.ValidarCupon(c.Request.Context(), userID, &req)
+	if err != nil {
+		statusCode := cuponErrorStatus(err)
+		if statusCode == 0 {
+			statusCode = http.StatusInternalServerError
+			if err.Error() == "plan no encontrado" {
+				statusCode = http.StatusNotFound
+			} else if err.Error() == "ID de plan inválido" || err.Error() == "ID de usuario inválido" ||
+				errors.Is(err, services.ErrMonedaNoSoportada) || errors.Is(err, services.ErrMonedaNoDisponible) {
+				statusCode = http.StatusBadRequest
+			}
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Cupón no válido", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Cupón válido", validacion))
+}
+
+// cuponErrorStatus traduce los motivos por los que un cupón no se puede usar;
+// devuelve 0 si el error no viene del cupón.
+func cuponErrorStatus(err error) int {
+	switch {
+	case err.Error() == "cupón no encontrado":
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCuponInactivo),
+		errors.Is(err, services.ErrCuponNoVigente),
+		errors.Is(err, services.ErrCuponNoAplica),
+		errors.Is(err, services.ErrCuponMonedaDistinta),
+		errors.Is(err, services.ErrCuponLimiteUsuario),
+		errors.Is(err, repositories.ErrCuponAgotado):
+		return http.StatusConflict
+	}
+	return 0
+}
+
+func isCuponRequestError(err error) bool {
+	return errors.Is(err, services.ErrFormatoFecha) ||
+		errors.Is(err, services.ErrRangoFechas) ||
+		errors.Is(err, services.ErrMonedaNoSoportada) ||
+		err.Error() == "ID de plan inválido" ||
+		err.Error() == "moneda es requerida para cupones de monto fijo" ||
+		err.Error() == "el porcentaje de descuento no puede superar 100" ||
+		err.Error() == "duracion_periodos es requerido para cupones repetidos"
+}
@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"net/http"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EntitlementHandler struct {
+	entitlementService services.EntitlementService
+}
+
+func NewEntitlementHandler(entitlementService services.EntitlementService) *EntitlementHandler {
+	return &EntitlementHandler{
+		entitlementService: entitlementService,
+	}
+}
+
+func (h *EntitlementHandler) GetMisEntitlements(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	h.responder(c, userID)
+}
+
+// GetEntitlements es para otros servicios y administradores; un usuario común
+// solo puede consultar los suyos.
+func (h *EntitlementHandler) GetEntitlements(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID de usuario requerido", "missing_user_id"))
+		return
+	}
+
+	if !c.GetBool("es_admin") && c.GetString("user_id") != userID {
+		c.JSON(http.StatusForbidden, dto.NewErrorResponse("No autorizado", "forbidden"))
+		return
+	}
+
+	h.responder(c, userID)
+}
+
+func (h *EntitlementHandler) responder(c *gin.Context, userID string) {
+	entitlements, err := h.entitlementService.GetEntitlements(c.Request.Context(), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "ID de usuario inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error obteniendo entitlements", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Entitlements obtenidos exitosamente", entitlements))
+}
@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/repositories"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FacturaHandler struct {
+	facturaService services.FacturaService
+}
+
+func NewFacturaHandler(facturaService services.FacturaService) *FacturaHandler {
+	return &FacturaHandler{
+		facturaService: facturaService,
+	}
+}
+
+func (h *FacturaHandler) GetMisFacturas(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	page, limit, offset := facturaPagination(c)
+
+	facturas, total, err := h.facturaService.GetMisFacturas(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Error obteniendo facturas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, facturasResponse(facturas, total, page, limit))
+}
+
+func (h *FacturaHandler) GetAllFacturas(c *gin.Context) {
+	page, limit, offset := facturaPagination(c)
+
+	facturas, total, err := h.facturaService.GetAllFacturas(c.Request.Context(), c.Query("estado"), c.Query("usuario_id"), limit, offset)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "ID de usuario inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error obteniendo facturas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, facturasResponse(facturas, total, page, limit))
+}
+
+func (h *FacturaHandler) GetFacturaByID(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	factura, err := h.facturaService.GetFacturaByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("Factura no encontrada", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Factura obtenida exitosamente", factura))
+}
+
+func (h *FacturaHandler) EmitirFactura(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	factura, err := h.facturaService.EmitirFactura(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(facturaErrorStatus(err), dto.NewErrorResponse("Error emitiendo factura", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Factura emitida exitosamente", factura))
+}
+
+func (h *FacturaHandler) AnularFactura(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	if err := h.facturaService.AnularFactura(c.Request.Context(), id); err != nil {
+		c.JSON(facturaErrorStatus(err), dto.NewErrorResponse("Error anulando factura", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Factura anulada exitosamente", nil))
+}
+
+func facturaErrorStatus(err error) int {
+	switch {
+	case err.Error() == "factura no encontrada":
+		return http.StatusNotFound
+	case err.Error() == "ID de factura inválido":
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrFacturaModificada),
+		errors.Is(err, services.ErrNumeracionOcupada),
+		err.Error() == "solo se pueden emitir facturas en borrador",
+		err.Error() == "la factura no se puede anular en su estado actual":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func facturaPagination(c *gin.Context) (int, int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	return page, limit, (page - 1) * limit
+}
+
+func facturasResponse(facturas []*dto.FacturaDTO, total int64, page, limit int) *dto.PaginatedResponse {
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	return &dto.PaginatedResponse{
+		Success: true,
+		Message: "Facturas obtenidas exitosamente",
+		Data:    facturas,
+		Meta: dto.MetaData{
+			Page:        page,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     page < totalPages,
+			HasPrevious: page > 1,
+		},
+	}
+}
@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImpuestoHandler struct {
+	impuestoService services.ImpuestoService
+}
+
+func NewImpuestoHandler(impuestoService services.ImpuestoService) *ImpuestoHandler {
+	return &ImpuestoHandler{
+		impuestoService: impuestoService,
+	}
+}
+
+func (h *ImpuestoHandler) CreateTasa(c *gin.Context) {
+	var req dto.CreateTasaImpuestoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	tasa, err := h.impuestoService.CreateTasa(c.Request.Context(), &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrTasaImpuestoExistente) {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error creando tasa de impuesto", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse("Tasa de impuesto creada exitosamente", tasa))
+}
+
+func (h *ImpuestoHandler) GetAllTasas(c *gin.Context) {
+	showInactive, _ := strconv.ParseBool(c.DefaultQuery("show_inactive", "false"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	tasas, total, err := h.impuestoService.GetAllTasas(c.Request.Context(), c.Query("pais"), showInactive, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Error obteniendo tasas de impuesto", err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta := dto.MetaData{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+
+	response := &dto.PaginatedResponse{
+		Success: true,
+		Message: "Tasas de impuesto obtenidas exitosamente",
+		Data:    tasas,
+		Meta:    meta,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ImpuestoHandler) GetTasaByID(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	tasa, err := h.impuestoService.GetTasaByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("Tasa de impuesto no encontrada", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Tasa de impuesto obtenida exitosamente", tasa))
+}
+
+func (h *ImpuestoHandler) UpdateTasa(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	var req dto.UpdateTasaImpuestoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	if err := h.impuestoService.UpdateTasa(c.Request.Context(), id, &req); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "tasa de impuesto no encontrada" {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, services.ErrTasaImpuestoExistente) {
+			statusCode = http.StatusConflict
+		} else if err.Error() == "ID de tasa inválido" || err.Error() == "no hay campos para actualizar" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error actualizando tasa de impuesto", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Tasa de impuesto actualizada exitosamente", nil))
+}
+
+func (h *ImpuestoHandler) DeleteTasa(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	if err := h.impuestoService.DeleteTasa(c.Request.Context(), id); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "tasa de impuesto no encontrada" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "ID de tasa inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error eliminando tasa de impuesto", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Tasa de impuesto eliminada exitosamente", nil))
+}
+
+func (h *ImpuestoHandler) CalcularImpuesto(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.CalcularImpuestoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	calculo, err := h.impuestoService.CalcularImpuesto(c.Request.Context(), userID, &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "plan no encontrado" || err.Error() == "usuario no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "ID de plan inválido" || err.Error() == "ID de usuario inválido" ||
+			errors.Is(err, services.ErrMonedaNoSoportada) || errors.Is(err, services.ErrMonedaNoDisponible) {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error calculando impuesto", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Impuesto calculado exitosamente", calculo))
+}
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sw2p2go/internal/actor"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBytes limita el cuerpo que se lee antes de verificar la firma.
+const maxWebhookBytes = 64 << 10
+
+type PagoHandler struct {
+	suscripcionService services.SuscripcionService
+}
+
+func NewPagoHandler(suscripcionService services.SuscripcionService) *PagoHandler {
+	return &PagoHandler{
+		suscripcionService: suscripcionService,
+	}
+}
+
+// WebhookPagos recibe los eventos de la pasarela. La firma se calcula sobre el
+// cuerpo tal cual llega, por eso se lee crudo en lugar de usar ShouldBindJSON.
+func (h *PagoHandler) WebhookPagos(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	ctx := actor.With(c.Request.Context(), entity.ActorPasarela)
+
+	if err := h.suscripcionService.ProcesarWebhookPago(ctx, payload, c.GetHeader("X-Firma")); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrFirmaInvalida) {
+			statusCode = http.StatusUnauthorized
+		} else if errors.Is(err, services.ErrEventoPagoInvalido) {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error procesando webhook", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Evento procesado", nil))
+}
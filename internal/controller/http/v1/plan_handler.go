@@ -1,9 +1,11 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/repositories"
 	"sw2p2go/internal/usecase/services"
 
 	"github.com/gin-gonic/gin"
@@ -28,7 +30,7 @@ func (h *PlanHandler) CreatePlan(c *gin.Context) {
 
 	plan, err := h.planService.CreatePlan(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Error creando plan", err.Error()))
+		c.JSON(planErrorStatus(err), dto.NewErrorResponse("Error creando plan", err.Error()))
 		return
 	}
 
@@ -105,7 +107,7 @@ func (h *PlanHandler) UpdatePlan(c *gin.Context) {
 	}
 
 	if err := h.planService.UpdatePlan(c.Request.Context(), id, &req); err != nil {
-		statusCode := http.StatusInternalServerError
+		statusCode := planErrorStatus(err)
 		if err.Error() == "plan no encontrado" {
 			statusCode = http.StatusNotFound
 		}
@@ -158,3 +160,37 @@ func (h *PlanHandler) GetActivePlanes(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dto.NewSuccessResponse("Planes activos obtenidos exitosamente", planes))
 }
+
+func (h *PlanHandler) GetVersiones(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	versiones, err := h.planService.GetVersiones(c.Request.Context(), id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "plan no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "ID de plan inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error obteniendo versiones", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Versiones obtenidas exitosamente", versiones))
+}
+
+func planErrorStatus(err error) int {
+	if errors.Is(err, services.ErrMonedaNoSoportada) || errors.Is(err, services.ErrMonedaDuplicada) ||
+		errors.Is(err, services.ErrClaveDerechoInvalida) || errors.Is(err, services.ErrDerechoDuplicado) ||
+		errors.Is(err, services.ErrLimitesAsientos) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, repositories.ErrVersionPlanExistente) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,187 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/repositories"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RegaloHandler struct {
+	suscripcionService services.SuscripcionService
+}
+
+func NewRegaloHandler(suscripcionService services.SuscripcionService) *RegaloHandler {
+	return &RegaloHandler{
+		suscripcionService: suscripcionService,
+	}
+}
+
+func (h *RegaloHandler) ComprarRegalo(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.ComprarRegaloRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	regalo, err := h.suscripcionService.ComprarRegalo(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(regaloErrorStatus(err), dto.NewErrorResponse("Error comprando regalo", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse("Regalo comprado exitosamente", regalo))
+}
+
+func (h *RegaloHandler) CanjearRegalo(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.CanjearRegaloRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	canje, err := h.suscripcionService.CanjearRegalo(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(regaloErrorStatus(err), dto.NewErrorResponse("Error canjeando regalo", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Regalo canjeado exitosamente", canje))
+}
+
+func (h *RegaloHandler) GetMisRegalos(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	page, limit, offset := regaloPaginacion(c)
+
+	regalos, total, err := h.suscripcionService.GetMisRegalos(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Error obteniendo tus regalos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, regalosPaginados("Tus regalos obtenidos exitosamente", regalos, total, page, limit))
+}
+
+func (h *RegaloHandler) GetAllRegalos(c *gin.Context) {
+	page, limit, offset := regaloPaginacion(c)
+
+	regalos, total, err := h.suscripcionService.GetAllRegalos(c.Request.Context(), c.Query("estado"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Error obteniendo regalos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, regalosPaginados("Regalos obtenidos exitosamente", regalos, total, page, limit))
+}
+
+func (h *RegaloHandler) RevocarRegalo(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	if err := h.suscripcionService.RevocarRegalo(c.Request.Context(), id); err != nil {
+		c.JSON(regaloErrorStatus(err), dto.NewErrorResponse("Error revocando regalo", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Regalo revocado exitosamente", nil))
+}
+
+func (h *RegaloHandler) ExpirarRegalo(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	// Sin cuerpo expira el regalo de inmediato
+	var req dto.ExpirarRegaloRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	if err := h.suscripcionService.ExpirarRegalo(c.Request.Context(), id, &req); err != nil {
+		c.JSON(regaloErrorStatus(err), dto.NewErrorResponse("Error expirando regalo", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Regalo expirado exitosamente", nil))
+}
+
+func regaloPaginacion(c *gin.Context) (page, limit, offset int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	return page, limit, (page - 1) * limit
+}
+
+func regalosPaginados(message string, regalos []*dto.RegaloDTO, total int64, page, limit int) *dto.PaginatedResponse {
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return &dto.PaginatedResponse{
+		Success: true,
+		Message: message,
+		Data:    regalos,
+		Meta: dto.MetaData{
+			Page:        page,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     page < totalPages,
+			HasPrevious: page > 1,
+		},
+	}
+}
+
+func regaloErrorStatus(err error) int {
+	switch {
+	case err.Error() == "regalo no encontrado" || err.Error() == "plan no encontrado" ||
+		err.Error() == "usuario no encontrado":
+		return http.StatusNotFound
+	case err.Error() == "ID de usuario inválido" || err.Error() == "ID de plan inválido" ||
+		err.Error() == "ID de regalo inválido" ||
+		errors.Is(err, services.ErrFormatoFecha) || errors.Is(err, services.ErrMonedaNoSoportada) ||
+		errors.Is(err, services.ErrMonedaNoDisponible):
+		return http.StatusBadRequest
+	case err.Error() == "usuario inactivo" || err.Error() == "plan inactivo" ||
+		errors.Is(err, services.ErrPlanNoRegalable) || errors.Is(err, services.ErrRegaloNoDisponible) ||
+		errors.Is(err, services.ErrRegaloExpirado) || errors.Is(err, services.ErrRegaloCanjeado) ||
+		errors.Is(err, services.ErrRegaloRevocado) || errors.Is(err, services.ErrRegaloOtroPlan) ||
+		errors.Is(err, services.ErrRegaloNoAplicable) || errors.Is(err, services.ErrRegaloYaCanjeado) ||
+		errors.Is(err, repositories.ErrRegaloModificado) ||
+		err.Error() == "la suscripción fue modificada por otro proceso":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
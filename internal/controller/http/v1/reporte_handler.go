@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReporteHandler struct {
+	reporteService services.ReporteService
+}
+
+func NewReporteHandler(reporteService services.ReporteService) *ReporteHandler {
+	return &ReporteHandler{
+		reporteService: reporteService,
+	}
+}
+
+func (h *ReporteHandler) GetMRR(c *gin.Context) {
+	reporte, err := h.reporteService.GetMRR(c.Request.Context(), c.Query("desde"), c.Query("hasta"), c.Query("moneda"))
+	if err != nil {
+		c.JSON(reporteErrorStatus(err), dto.NewErrorResponse("Error generando reporte de MRR", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Reporte de MRR generado exitosamente", reporte))
+}
+
+// GetCohortes devuelve la matriz en JSON, o como CSV con formato=csv.
+func (h *ReporteHandler) GetCohortes(c *gin.Context) {
+	reporte, err := h.reporteService.GetCohortes(c.Request.Context(), c.Query("agrupacion"), c.Query("desde"), c.Query("hasta"))
+	if err != nil {
+		c.JSON(reporteErrorStatus(err), dto.NewErrorResponse("Error generando reporte de cohortes", err.Error()))
+		return
+	}
+
+	if c.Query("formato") == "csv" {
+		data, err := cohortesCSV(reporte)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Error exportando reporte de cohortes", err.Error()))
+			return
+		}
+
+		filename := fmt.Sprintf("cohortes_%s_%s_%s.csv", reporte.Agrupacion, reporte.Desde, reporte.Hasta)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Reporte de cohortes generado exitosamente", reporte))
+}
+
+// cohortesCSV escribe una fila por cohorte con el porcentaje retenido de cada
+// mes; las celdas de meses que todavía no llegaron quedan vacías.
+func cohortesCSV(reporte *dto.ReporteCohortesDTO) ([]byte, error) {
+	meses := 0
+	for _, cohorte := range reporte.Cohortes {
+		meses = max(meses, len(cohorte.Retencion))
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"cohorte", "usuarios"}
+	for i := 0; i < meses; i++ {
+		header = append(header, fmt.Sprintf("mes_%d", i))
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, cohorte := range reporte.Cohortes {
+		row := make([]string, len(header))
+		row[0] = cohorte.Mes
+		row[1] = strconv.Itoa(cohorte.Usuarios)
+		for i, retencion := range cohorte.Retencion {
+			row[2+i] = strconv.FormatFloat(retencion, 'f', 2, 64)
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func reporteErrorStatus(err error) int {
+	if errors.Is(err, services.ErrFormatoFecha) || errors.Is(err, services.ErrRangoFechas) ||
+		errors.Is(err, services.ErrMonedaNoSoportada) || errors.Is(err, services.ErrFormatoMes) ||
+		errors.Is(err, services.ErrAgrupacionInvalida) || errors.Is(err, services.ErrRangoCohortesAmplio) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
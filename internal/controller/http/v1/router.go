@@ -14,6 +14,17 @@ type Router struct {
 	usuarioHandler     *UsuarioHandler
 	planHandler        *PlanHandler
 	suscripcionHandler *SuscripcionHandler
+	tareaHandler       *TareaHandler
+	cuponHandler       *CuponHandler
+	facturaHandler     *FacturaHandler
+	pagoHandler        *PagoHandler
+	impuestoHandler    *ImpuestoHandler
+	entitlementHandler *EntitlementHandler
+	usoHandler         *UsoHandler
+	complementoHandler *ComplementoHandler
+	regaloHandler      *RegaloHandler
+	creditoHandler     *CreditoHandler
+	reporteHandler     *ReporteHandler
 	authMiddleware     *middleware.AuthMiddleware
 }
 
@@ -21,12 +32,34 @@ func NewRouter(
 	usuarioHandler *UsuarioHandler,
 	planHandler *PlanHandler,
 	suscripcionHandler *SuscripcionHandler,
+	tareaHandler *TareaHandler,
+	cuponHandler *CuponHandler,
+	facturaHandler *FacturaHandler,
+	pagoHandler *PagoHandler,
+	impuestoHandler *ImpuestoHandler,
+	entitlementHandler *EntitlementHandler,
+	usoHandler *UsoHandler,
+	complementoHandler *ComplementoHandler,
+	regaloHandler *RegaloHandler,
+	creditoHandler *CreditoHandler,
+	reporteHandler *ReporteHandler,
 	authMiddleware *middleware.AuthMiddleware,
 ) *Router {
 	return &Router{
 		usuarioHandler:     usuarioHandler,
 		planHandler:        planHandler,
 		suscripcionHandler: suscripcionHandler,
+		tareaHandler:       tareaHandler,
+		cuponHandler:       cuponHandler,
+		facturaHandler:     facturaHandler,
+		pagoHandler:        pagoHandler,
+		impuestoHandler:    impuestoHandler,
+		entitlementHandler: entitlementHandler,
+		usoHandler:         usoHandler,
+		complementoHandler: complementoHandler,
+		regaloHandler:      regaloHandler,
+		creditoHandler:     creditoHandler,
+		reporteHandler:     reporteHandler,
 		authMiddleware:     authMiddleware,
 	}
 }
@@ -75,6 +108,18 @@ func (r *Router) SetupRoutes() *gin.Engine {
 		planPublic.GET("/activos", r.planHandler.GetActivePlanes)
 	}
 
+	complementosPublic := v1.Group("/complementos")
+	{
+		complementosPublic.GET("", r.complementoHandler.GetAllComplementos)
+		complementosPublic.GET("/:id", r.complementoHandler.GetComplementoByID)
+	}
+
+	// Los webhooks se autentican con la firma HMAC, no con JWT
+	webhooks := v1.Group("/webhooks")
+	{
+		webhooks.POST("/pagos", r.pagoHandler.WebhookPagos)
+	}
+
 	protected := v1.Group("")
 	protected.Use(r.authMiddleware.JWT())
 	{
@@ -87,6 +132,7 @@ func (r *Router) SetupRoutes() *gin.Engine {
 		profile := protected.Group("/perfil")
 		{
 			profile.GET("", r.usuarioHandler.GetProfile)
+			profile.GET("/exportar", r.usuarioHandler.ExportarDatos)
 		}
 
 		protectedPlans := protected.Group("/planes")
@@ -101,16 +147,116 @@ func (r *Router) SetupRoutes() *gin.Engine {
 			suscripciones.POST("", r.suscripcionHandler.CreateSuscripcion)
 			suscripciones.GET("", r.suscripcionHandler.GetAllSuscripciones)
 			suscripciones.GET("/detalles", r.suscripcionHandler.GetSuscripcionesWithDetails)
+			suscripciones.POST("/canjear", r.regaloHandler.CanjearRegalo)
 			suscripciones.GET("/:id", r.suscripcionHandler.GetSuscripcionByID)
-			suscripciones.PUT("/:id", r.suscripcionHandler.UpdateSuscripcion)
 			suscripciones.DELETE("/:id", r.suscripcionHandler.CancelSuscripcion)
+			suscripciones.POST("/:id/auto-renovacion", r.suscripcionHandler.EnableAutoRenovar)
+			suscripciones.DELETE("/:id/auto-renovacion", r.suscripcionHandler.DisableAutoRenovar)
+			suscripciones.GET("/:id/renovaciones", r.suscripcionHandler.GetRenovaciones)
+			suscripciones.GET("/:id/historial", r.suscripcionHandler.GetHistorial)
+			suscripciones.GET("/:id/uso", r.usoHandler.GetUsoSuscripcion)
+			suscripciones.POST("/:id/cambiar-plan", r.suscripcionHandler.CambiarPlan)
+			suscripciones.POST("/:id/cambiar-plan/previsualizar", r.suscripcionHandler.PreviewCambioPlan)
+			suscripciones.GET("/:id/asientos", r.suscripcionHandler.GetAsientos)
+			suscripciones.POST("/:id/asientos/agregar", r.suscripcionHandler.AgregarAsientos)
+			suscripciones.POST("/:id/asientos/quitar", r.suscripcionHandler.QuitarAsientos)
+			suscripciones.POST("/:id/asientos/asignar", r.suscripcionHandler.AsignarAsiento)
+			suscripciones.DELETE("/:id/asientos/:user_id", r.suscripcionHandler.LiberarAsiento)
+			suscripciones.POST("/:id/complementos", r.suscripcionHandler.AgregarComplemento)
+			suscripciones.DELETE("/:id/complementos/:complemento_id", r.suscripcionHandler.QuitarComplemento)
+			suscripciones.POST("/:id/pausar", r.suscripcionHandler.PausarSuscripcion)
+			suscripciones.POST("/:id/reanudar", r.suscripcionHandler.ReanudarSuscripcion)
+			suscripciones.POST("/:id/deshacer-cancelacion", r.suscripcionHandler.DeshacerCancelacion)
 			suscripciones.GET("/usuario/:user_id", r.suscripcionHandler.GetSuscripcionesByUser)
 		}
 
+		cupones := protected.Group("/cupones")
+		{
+			cupones.POST("/validar", r.cuponHandler.ValidarCupon)
+		}
+
+		entitlements := protected.Group("/entitlements")
+		{
+			entitlements.GET("/me", r.entitlementHandler.GetMisEntitlements)
+			entitlements.GET("/:user_id", r.entitlementHandler.GetEntitlements)
+		}
+
+		uso := protected.Group("/uso")
+		{
+			uso.POST("", r.usoHandler.RegistrarUso)
+			uso.POST("/verificar", r.usoHandler.VerificarUso)
+		}
+
+		impuestos := protected.Group("/impuestos")
+		{
+			impuestos.POST("/calcular", r.impuestoHandler.CalcularImpuesto)
+		}
+
+		regalos := protected.Group("/regalos")
+		{
+			regalos.POST("", r.regaloHandler.ComprarRegalo)
+		}
+
+		misRegalos := protected.Group("/mis-regalos")
+		{
+			misRegalos.GET("", r.regaloHandler.GetMisRegalos)
+		}
+
+		miCredito := protected.Group("/mi-credito")
+		{
+			miCredito.GET("", r.creditoHandler.GetMiSaldo)
+			miCredito.GET("/movimientos", r.creditoHandler.GetMisMovimientos)
+		}
+
 		misSuscripciones := protected.Group("/mis-suscripciones")
 		{
 			misSuscripciones.GET("", r.suscripcionHandler.GetMySuscripciones)
 		}
+
+		misFacturas := protected.Group("/mis-facturas")
+		{
+			misFacturas.GET("", r.facturaHandler.GetMisFacturas)
+		}
+
+		admin := protected.Group("/admin")
+		admin.Use(r.authMiddleware.AdminOnly())
+		{
+			admin.GET("/tareas", r.tareaHandler.GetAllTareas)
+			admin.GET("/planes/:id/versiones", r.planHandler.GetVersiones)
+			admin.POST("/planes/:id/migrar-version", r.suscripcionHandler.MigrarVersionPlan)
+			// Editar fecha_fin o el estado a mano es una corrección de soporte
+			admin.PUT("/suscripciones/:id", r.suscripcionHandler.UpdateSuscripcion)
+			admin.POST("/suscripciones/:id/cambios-programados", r.suscripcionHandler.ProgramarCambioPlan)
+			admin.GET("/suscripciones/:id/cambios-programados", r.suscripcionHandler.GetCambiosProgramados)
+			admin.DELETE("/suscripciones/:id/cambios-programados/:cambio_id", r.suscripcionHandler.CancelarCambioProgramado)
+			admin.POST("/tareas/:nombre/ejecutar", r.tareaHandler.EjecutarTarea)
+			admin.POST("/cupones", r.cuponHandler.CreateCupon)
+			admin.GET("/cupones", r.cuponHandler.GetAllCupones)
+			admin.GET("/cupones/:id", r.cuponHandler.GetCuponByID)
+			admin.PUT("/cupones/:id", r.cuponHandler.UpdateCupon)
+			admin.DELETE("/cupones/:id", r.cuponHandler.DeleteCupon)
+			admin.POST("/complementos", r.complementoHandler.CreateComplemento)
+			admin.PUT("/complementos/:id", r.complementoHandler.UpdateComplemento)
+			admin.DELETE("/complementos/:id", r.complementoHandler.DeleteComplemento)
+			admin.GET("/usuarios/:id/credito", r.creditoHandler.GetSaldo)
+			admin.GET("/usuarios/:id/credito/movimientos", r.creditoHandler.GetMovimientos)
+			admin.POST("/usuarios/:id/credito", r.creditoHandler.OtorgarCredito)
+			admin.POST("/usuarios/:id/reembolsos", r.creditoHandler.Reembolsar)
+			admin.GET("/regalos", r.regaloHandler.GetAllRegalos)
+			admin.POST("/regalos/:id/revocar", r.regaloHandler.RevocarRegalo)
+			admin.POST("/regalos/:id/expirar", r.regaloHandler.ExpirarRegalo)
+			admin.GET("/facturas", r.facturaHandler.GetAllFacturas)
+			admin.GET("/facturas/:id", r.facturaHandler.GetFacturaByID)
+			admin.POST("/facturas/:id/emitir", r.facturaHandler.EmitirFactura)
+			admin.POST("/facturas/:id/anular", r.facturaHandler.AnularFactura)
+			admin.POST("/impuestos", r.impuestoHandler.CreateTasa)
+			admin.GET("/impuestos", r.impuestoHandler.GetAllTasas)
+			admin.GET("/impuestos/:id", r.impuestoHandler.GetTasaByID)
+			admin.PUT("/impuestos/:id", r.impuestoHandler.UpdateTasa)
+			admin.DELETE("/impuestos/:id", r.impuestoHandler.DeleteTasa)
+			admin.GET("/reportes/mrr", r.reporteHandler.GetMRR)
+			admin.GET("/reportes/cohortes", r.reporteHandler.GetCohortes)
+		}
 	}
 
 	return router
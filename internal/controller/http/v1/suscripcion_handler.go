@@ -1,9 +1,12 @@
 package v1
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/repositories"
 	"sw2p2go/internal/usecase/services"
 
 	"github.com/gin-gonic/gin"
@@ -29,12 +32,20 @@ func (h *SuscripcionHandler) CreateSuscripcion(c *gin.Context) {
 	suscripcion, err := h.suscripcionService.CreateSuscripcion(c.Request.Context(), &req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "el usuario ya tiene una suscripción activa" ||
+		if cuponStatus := cuponErrorStatus(err); cuponStatus != 0 {
+			statusCode = cuponStatus
+		} else if err.Error() == "el usuario ya tiene una suscripción activa" ||
 			err.Error() == "usuario no encontrado" ||
 			err.Error() == "plan no encontrado" ||
 			err.Error() == "usuario inactivo" ||
 			err.Error() == "plan inactivo" {
 			statusCode = http.StatusConflict
+		} else if errors.Is(err, services.ErrFormatoFecha) || errors.Is(err, services.ErrRangoFechas) ||
+			errors.Is(err, services.ErrFechaFinConPrueba) ||
+			errors.Is(err, services.ErrMonedaNoSoportada) || errors.Is(err, services.ErrMonedaNoDisponible) ||
+			errors.Is(err, services.ErrCantidadAsientosInvalida) ||
+			err.Error() == "ID de usuario inválido" || err.Error() == "ID de plan inválido" {
+			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, dto.NewErrorResponse("Error creando suscripción", err.Error()))
 		return
@@ -168,12 +179,20 @@ func (h *SuscripcionHandler) UpdateSuscripcion(c *gin.Context) {
 	}
 
 	if err := h.suscripcionService.UpdateSuscripcion(c.Request.Context(), id, &req); err != nil {
+		var transicionErr *services.TransicionInvalidaError
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "suscripción no encontrada" {
 			statusCode = http.StatusNotFound
-		} else if err.Error() == "formato de fecha inválido (use YYYY-MM-DD)" ||
-			err.Error() == "estado inválido" {
+		} else if errors.Is(err, services.ErrFormatoFecha) ||
+			errors.Is(err, services.ErrEstadoInvalido) ||
+			errors.Is(err, services.ErrRangoFechas) ||
+			errors.Is(err, services.ErrFechaFinPasada) ||
+			err.Error() == "no hay campos para actualizar" {
 			statusCode = http.StatusBadRequest
+		} else if errors.As(err, &transicionErr) ||
+			errors.Is(err, services.ErrSuscripcionActivaExistente) ||
+			errors.Is(err, repositories.ErrSuscripcionModificada) {
+			statusCode = http.StatusConflict
 		}
 		c.JSON(statusCode, dto.NewErrorResponse("Error actualizando suscripción", err.Error()))
 		return
@@ -189,18 +208,79 @@ func (h *SuscripcionHandler) CancelSuscripcion(c *gin.Context) {
 		return
 	}
 
-	if err := h.suscripcionService.CancelSuscripcion(c.Request.Context(), id); err != nil {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	// El cuerpo es opcional para mantener compatible el DELETE sin datos
+	var req dto.CancelarSuscripcionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	if err := h.suscripcionService.CancelSuscripcion(c.Request.Context(), id, userID, c.GetBool("es_admin"), &req); err != nil {
+		var transicionErr *services.TransicionInvalidaError
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "suscripción no encontrada" {
 			statusCode = http.StatusNotFound
+		} else if err.Error() == "no autorizado para modificar esta suscripción" {
+			statusCode = http.StatusForbidden
+		} else if err.Error() == "ID de suscripción inválido" {
+			statusCode = http.StatusBadRequest
+		} else if errors.As(err, &transicionErr) ||
+			err.Error() == "la suscripción no está activa" ||
+			err.Error() == "la suscripción ya tiene una cancelación programada" ||
+			err.Error() == "la suscripción fue modificada por otro proceso" {
+			statusCode = http.StatusConflict
 		}
 		c.JSON(statusCode, dto.NewErrorResponse("Error cancelando suscripción", err.Error()))
 		return
 	}
 
+	if req.Modo == "fin_periodo" {
+		c.JSON(http.StatusOK, dto.NewSuccessResponse("Cancelación programada para el fin del periodo", nil))
+		return
+	}
+
 	c.JSON(http.StatusOK, dto.NewSuccessResponse("Suscripción cancelada exitosamente", nil))
 }
 
+func (h *SuscripcionHandler) DeshacerCancelacion(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	if err := h.suscripcionService.DeshacerCancelacion(c.Request.Context(), id, userID); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "suscripción no encontrada" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "no autorizado para modificar esta suscripción" {
+			statusCode = http.StatusForbidden
+		} else if err.Error() == "ID de suscripción inválido" {
+			statusCode = http.StatusBadRequest
+		} else if err.Error() == "la suscripción no tiene una cancelación programada" ||
+			err.Error() == "la cancelación programada no guardó la renovación previa" ||
+			err.Error() == "la suscripción fue modificada por otro proceso" {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error deshaciendo cancelación", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Cancelación deshecha exitosamente", nil))
+}
+
 func (h *SuscripcionHandler) GetSuscripcionesWithDetails(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
@@ -239,3 +319,632 @@ func (h *SuscripcionHandler) GetSuscripcionesWithDetails(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+func (h *SuscripcionHandler) EnableAutoRenovar(c *gin.Context) {
+	h.setAutoRenovar(c, true)
+}
+
+func (h *SuscripcionHandler) DisableAutoRenovar(c *gin.Context) {
+	h.setAutoRenovar(c, false)
+}
+
+func (h *SuscripcionHandler) setAutoRenovar(c *gin.Context, autoRenovar bool) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	if err := h.suscripcionService.SetAutoRenovar(c.Request.Context(), id, userID, autoRenovar); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "suscripción no encontrada" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "no autorizado para modificar esta suscripción" {
+			statusCode = http.StatusForbidden
+		} else if err.Error() == "la suscripción no está activa" ||
+			err.Error() == "la suscripción ya tiene una cancelación programada" {
+			statusCode = http.StatusConflict
+		} else if err.Error() == "ID de suscripción inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error actualizando renovación automática", err.Error()))
+		return
+	}
+
+	message := "Renovación automática desactivada exitosamente"
+	if autoRenovar {
+		message = "Renovación automática activada exitosamente"
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(message, nil))
+}
+
+func (h *SuscripcionHandler) GetRenovaciones(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	renovaciones, err := h.suscripcionService.GetRenovaciones(c.Request.Context(), id, userID, c.GetBool("es_admin"), limit, offset)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "suscripción no encontrada" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "no autorizado para modificar esta suscripción" {
+			statusCode = http.StatusForbidden
+		} else if err.Error() == "ID de suscripción inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error obteniendo renovaciones", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Renovaciones obtenidas exitosamente", renovaciones))
+}
+
+func (h *SuscripcionHandler) GetHistorial(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	eventos, total, err := h.suscripcionService.GetHistorial(c.Request.Context(), id, userID, c.GetBool("es_admin"), limit, offset)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "suscripción no encontrada" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "no autorizado para modificar esta suscripción" {
+			statusCode = http.StatusForbidden
+		} else if err.Error() == "ID de suscripción inválido" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error obteniendo historial", err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta := dto.MetaData{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+
+	response := &dto.PaginatedResponse{
+		Success: true,
+		Message: "Historial obtenido exitosamente",
+		Data:    eventos,
+		Meta:    meta,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *SuscripcionHandler) CambiarPlan(c *gin.Context) {
+	h.cambiarPlan(c, false)
+}
+
+func (h *SuscripcionHandler) PreviewCambioPlan(c *gin.Context) {
+	h.cambiarPlan(c, true)
+}
+
+func (h *SuscripcionHandler) cambiarPlan(c *gin.Context, preview bool) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.CambiarPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	var (
+		cambio *dto.CambioPlanDTO
+		err    error
+	)
+	if preview {
+		cambio, err = h.suscripcionService.PreviewCambioPlan(c.Request.Context(), id, userID, &req)
+	} else {
+		cambio, err = h.suscripcionService.CambiarPlan(c.Request.Context(), id, userID, &req)
+	}
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "suscripción no encontrada" || err.Error() == "plan no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "no autorizado para modificar esta suscripción" {
+			statusCode = http.StatusForbidden
+		} else if err.Error() == "ID de suscripción inválido" || err.Error() == "ID de plan inválido" {
+			statusCode = http.StatusBadRequest
+		} else if err.Error() == "la suscripción no está activa" ||
+			err.Error() == "la suscripción ya tiene ese plan" ||
+			err.Error() == "plan inactivo" ||
+			errors.Is(err, services.ErrMonedaNoDisponible) ||
+			errors.Is(err, services.ErrCantidadAsientosInvalida) ||
+			errors.Is(err, services.ErrComplementosIncompatibles) ||
+			errors.Is(err, services.ErrCargoPendiente) ||
+			err.Error() == "la renovación automática está desactivada" ||
+			err.Error() == "la suscripción fue modificada por otro proceso" {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error cambiando de plan", err.Error()))
+		return
+	}
+
+	if preview {
+		c.JSON(http.StatusOK, dto.NewSuccessResponse("Cambio de plan calculado exitosamente", cambio))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Plan cambiado exitosamente", cambio))
+}
+
+// ProgramarCambioPlan agenda un cambio de plan que la tarea
+// aplicar_cambios_programados aplica en la fecha indicada.
+func (h *SuscripcionHandler) ProgramarCambioPlan(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	var req dto.ProgramarCambioPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	cambio, err := h.suscripcionService.ProgramarCambioPlan(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(cambioProgramadoErrorStatus(err), dto.NewErrorResponse("Error programando cambio de plan", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse("Cambio de plan programado exitosamente", cambio))
+}
+
+func (h *SuscripcionHandler) GetCambiosProgramados(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	cambios, total, err := h.suscripcionService.GetCambiosProgramados(c.Request.Context(), id, c.Query("estado"), limit, offset)
+	if err != nil {
+		c.JSON(cambioProgramadoErrorStatus(err), dto.NewErrorResponse("Error obteniendo cambios programados", err.Error()))
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta := dto.MetaData{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+
+	response := &dto.PaginatedResponse{
+		Success: true,
+		Message: "Cambios programados obtenidos exitosamente",
+		Data:    cambios,
+		Meta:    meta,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *SuscripcionHandler) CancelarCambioProgramado(c *gin.Context) {
+	id := c.Param("id")
+	cambioID := c.Param("cambio_id")
+	if id == "" || cambioID == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	if err := h.suscripcionService.CancelarCambioProgramado(c.Request.Context(), id, cambioID); err != nil {
+		c.JSON(cambioProgramadoErrorStatus(err), dto.NewErrorResponse("Error cancelando cambio programado", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Cambio programado cancelado exitosamente", nil))
+}
+
+// MigrarVersionPlan mueve en bloque las suscripciones de un plan a otra
+// versión; con dry_run solo devuelve el reporte de lo que cambiaría.
+func (h *SuscripcionHandler) MigrarVersionPlan(c *gin.Context) {
+	planID := c.Param("id")
+	if planID == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	var req dto.MigrarVersionPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	reporte, err := h.suscripcionService.MigrarVersionPlan(c.Request.Context(), planID, &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "plan no encontrado" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "ID de plan inválido" || errors.Is(err, services.ErrVersionPlanInexistente) {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error migrando versión del plan", err.Error()))
+		return
+	}
+
+	mensaje := "Suscripciones migradas exitosamente"
+	if reporte.DryRun {
+		mensaje = "Simulación de migración completada"
+	}
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(mensaje, reporte))
+}
+
+func (h *SuscripcionHandler) PausarSuscripcion(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.PausarSuscripcionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	if err := h.suscripcionService.PausarSuscripcion(c.Request.Context(), id, userID, &req); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "suscripción no encontrada" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "no autorizado para modificar esta suscripción" {
+			statusCode = http.StatusForbidden
+		} else if err.Error() == "ID de suscripción inválido" || errors.Is(err, services.ErrPausaExcedida) {
+			statusCode = http.StatusBadRequest
+		} else if err.Error() == "solo se pueden pausar suscripciones activas" ||
+			err.Error() == "la suscripción ya tiene una cancelación programada" ||
+			err.Error() == "el plan no permite pausar la suscripción" ||
+			err.Error() == "la suscripción fue modificada por otro proceso" {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error pausando suscripción", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Suscripción pausada exitosamente", nil))
+}
+
+func (h *SuscripcionHandler) ReanudarSuscripcion(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	if err := h.suscripcionService.ReanudarSuscripcion(c.Request.Context(), id, userID); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "suscripción no encontrada" {
+			statusCode = http.StatusNotFound
+		} else if err.Error() == "no autorizado para modificar esta suscripción" {
+			statusCode = http.StatusForbidden
+		} else if err.Error() == "ID de suscripción inválido" {
+			statusCode = http.StatusBadRequest
+		} else if err.Error() == "la suscripción no está pausada" ||
+			err.Error() == "la suscripción fue modificada por otro proceso" {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error reanudando suscripción", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Suscripción reanudada exitosamente", nil))
+}
+
+func (h *SuscripcionHandler) AgregarAsientos(c *gin.Context) {
+	h.cambiarAsientos(c, true)
+}
+
+func (h *SuscripcionHandler) QuitarAsientos(c *gin.Context) {
+	h.cambiarAsientos(c, false)
+}
+
+func (h *SuscripcionHandler) cambiarAsientos(c *gin.Context, agregar bool) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.CambiarAsientosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	var (
+		cambio *dto.CambioAsientosDTO
+		err    error
+	)
+	if agregar {
+		cambio, err = h.suscripcionService.AgregarAsientos(c.Request.Context(), id, userID, &req)
+	} else {
+		cambio, err = h.suscripcionService.QuitarAsientos(c.Request.Context(), id, userID, &req)
+	}
+	if err != nil {
+		c.JSON(asientosErrorStatus(err), dto.NewErrorResponse("Error cambiando asientos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Asientos actualizados exitosamente", cambio))
+}
+
+func (h *SuscripcionHandler) GetAsientos(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	asientos, err := h.suscripcionService.GetAsientos(c.Request.Context(), id, userID, c.GetBool("es_admin"))
+	if err != nil {
+		c.JSON(asientosErrorStatus(err), dto.NewErrorResponse("Error obteniendo asientos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Asientos obtenidos exitosamente", asientos))
+}
+
+func (h *SuscripcionHandler) AsignarAsiento(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.AsignarAsientoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	if err := h.suscripcionService.AsignarAsiento(c.Request.Context(), id, userID, &req); err != nil {
+		c.JSON(asientosErrorStatus(err), dto.NewErrorResponse("Error asignando asiento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Asiento asignado exitosamente", nil))
+}
+
+func (h *SuscripcionHandler) LiberarAsiento(c *gin.Context) {
+	id := c.Param("id")
+	asignadoID := c.Param("user_id")
+	if id == "" || asignadoID == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	if err := h.suscripcionService.LiberarAsiento(c.Request.Context(), id, userID, asignadoID); err != nil {
+		c.JSON(asientosErrorStatus(err), dto.NewErrorResponse("Error liberando asiento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Asiento liberado exitosamente", nil))
+}
+
+func asientosErrorStatus(err error) int {
+	switch {
+	case err.Error() == "suscripción no encontrada" || err.Error() == "plan no encontrado" ||
+		err.Error() == "usuario no encontrado" || errors.Is(err, services.ErrAsientoNoAsignado):
+		return http.StatusNotFound
+	case err.Error() == "no autorizado para modificar esta suscripción":
+		return http.StatusForbidden
+	case err.Error() == "ID de suscripción inválido" || err.Error() == "ID de usuario inválido" ||
+		errors.Is(err, services.ErrCantidadAsientosInvalida):
+		return http.StatusBadRequest
+	case err.Error() == "la suscripción no está activa" || err.Error() == "usuario inactivo" ||
+		errors.Is(err, services.ErrPlanSinAsientos) || errors.Is(err, services.ErrAsientosOcupados) ||
+		errors.Is(err, services.ErrSinAsientosLibres) || errors.Is(err, services.ErrUsuarioConAsiento) ||
+		errors.Is(err, services.ErrCargoPendiente) ||
+		err.Error() == "la suscripción fue modificada por otro proceso":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *SuscripcionHandler) AgregarComplemento(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.AgregarComplementoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	alta, err := h.suscripcionService.AgregarComplemento(c.Request.Context(), id, userID, &req)
+	if err != nil {
+		c.JSON(complementoErrorStatus(err), dto.NewErrorResponse("Error agregando complemento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Complemento agregado exitosamente", alta))
+}
+
+func (h *SuscripcionHandler) QuitarComplemento(c *gin.Context) {
+	id := c.Param("id")
+	complementoID := c.Param("complemento_id")
+	if id == "" || complementoID == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	if err := h.suscripcionService.QuitarComplemento(c.Request.Context(), id, userID, complementoID); err != nil {
+		c.JSON(complementoErrorStatus(err), dto.NewErrorResponse("Error quitando complemento", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Complemento quitado exitosamente", nil))
+}
+
+func complementoErrorStatus(err error) int {
+	switch {
+	case err.Error() == "suscripción no encontrada" || err.Error() == "complemento no encontrado" ||
+		errors.Is(err, services.ErrComplementoNoAgregado):
+		return http.StatusNotFound
+	case err.Error() == "no autorizado para modificar esta suscripción":
+		return http.StatusForbidden
+	case err.Error() == "ID de suscripción inválido" || err.Error() == "ID de complemento inválido":
+		return http.StatusBadRequest
+	case err.Error() == "la suscripción no está activa" ||
+		errors.Is(err, services.ErrComplementoInactivo) || errors.Is(err, services.ErrComplementoIncompatible) ||
+		errors.Is(err, services.ErrComplementoSinPrecio) || errors.Is(err, services.ErrComplementoYaAgregado) ||
+		err.Error() == "la suscripción fue modificada por otro proceso":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func cambioProgramadoErrorStatus(err error) int {
+	switch {
+	case err.Error() == "suscripción no encontrada" || err.Error() == "plan no encontrado" ||
+		err.Error() == "cambio programado no encontrado":
+		return http.StatusNotFound
+	case err.Error() == "ID de suscripción inválido" || err.Error() == "ID de plan inválido" ||
+		err.Error() == "ID de cambio programado inválido" ||
+		errors.Is(err, services.ErrFormatoFecha) || errors.Is(err, services.ErrFechaEfectivaPasada):
+		return http.StatusBadRequest
+	case err.Error() == "la suscripción no está activa" ||
+		err.Error() == "la suscripción ya tiene ese plan" ||
+		err.Error() == "plan inactivo" ||
+		errors.Is(err, services.ErrMonedaNoDisponible) ||
+		errors.Is(err, services.ErrCantidadAsientosInvalida) ||
+		errors.Is(err, services.ErrComplementosIncompatibles) ||
+		errors.Is(err, services.ErrCambioProgramadoExistente) ||
+		errors.Is(err, services.ErrCambioProgramadoNoPendiente):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/scheduler"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TareaHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewTareaHandler(scheduler *scheduler.Scheduler) *TareaHandler {
+	return &TareaHandler{
+		scheduler: scheduler,
+	}
+}
+
+func (h *TareaHandler) GetAllTareas(c *gin.Context) {
+	tareas, err := h.scheduler.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Error obteniendo tareas", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Tareas obtenidas exitosamente", tareas))
+}
+
+func (h *TareaHandler) EjecutarTarea(c *gin.Context) {
+	nombre := c.Param("nombre")
+	if nombre == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Nombre de tarea requerido", "missing_nombre"))
+		return
+	}
+
+	procesados, err := h.scheduler.Trigger(c.Request.Context(), nombre)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, scheduler.ErrTareaNoEncontrada) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, scheduler.ErrTareaEnEjecucion) {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error ejecutando tarea", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Tarea ejecutada exitosamente", gin.H{
+		"tarea":      nombre,
+		"procesados": procesados,
+	}))
+}
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UsoHandler struct {
+	usoService services.UsoService
+}
+
+func NewUsoHandler(usoService services.UsoService) *UsoHandler {
+	return &UsoHandler{
+		usoService: usoService,
+	}
+}
+
+func (h *UsoHandler) RegistrarUso(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.RegistrarUsoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+	if req.ClaveIdempotencia == "" {
+		req.ClaveIdempotencia = c.GetHeader("Idempotency-Key")
+	}
+
+	evento, err := h.usoService.RegistrarUso(c.Request.Context(), userID, c.GetBool("es_admin"), &req)
+	if err != nil {
+		c.JSON(usoErrorStatus(err), dto.NewErrorResponse("Error registrando uso", err.Error()))
+		return
+	}
+
+	if evento.Duplicado {
+		c.JSON(http.StatusOK, dto.NewSuccessResponse("Uso ya registrado", evento))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewSuccessResponse("Uso registrado exitosamente", evento))
+}
+
+func (h *UsoHandler) VerificarUso(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	var req dto.VerificarUsoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Datos inválidos", err.Error()))
+		return
+	}
+
+	verificacion, err := h.usoService.VerificarUso(c.Request.Context(), userID, c.GetBool("es_admin"), &req)
+	if err != nil {
+		c.JSON(usoErrorStatus(err), dto.NewErrorResponse("Error verificando uso", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Uso verificado exitosamente", verificacion))
+}
+
+func (h *UsoHandler) GetUsoSuscripcion(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("ID requerido", "missing_id"))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	uso, err := h.usoService.GetUsoSuscripcion(c.Request.Context(), id, userID, c.GetBool("es_admin"))
+	if err != nil {
+		c.JSON(usoErrorStatus(err), dto.NewErrorResponse("Error obteniendo uso", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Uso obtenido exitosamente", uso))
+}
+
+// usoErrorStatus usa errors.Is porque un fallo al consumir puede venir unido al
+// error de liberar la reserva del evento.
+func usoErrorStatus(err error) int {
+	switch {
+	case err.Error() == "suscripción no encontrada":
+		return http.StatusNotFound
+	case err.Error() == "no autorizado para consultar esta suscripción":
+		return http.StatusForbidden
+	case err.Error() == "ID de suscripción inválido",
+		errors.Is(err, services.ErrClaveIdempotenciaRequerida),
+		errors.Is(err, services.ErrMetricaNoDisponible),
+		errors.Is(err, services.ErrUsoFueraDePeriodo):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrCuotaExcedida):
+		return http.StatusTooManyRequests
+	case errors.Is(err, services.ErrSuscripcionNoVigente),
+		errors.Is(err, services.ErrClaveIdempotenciaReutilizada),
+		errors.Is(err, services.ErrEventoUsoEnProceso):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -107,6 +107,38 @@ func (h *UsuarioHandler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.NewSuccessResponse("Perfil obtenido exitosamente", usuario))
 }
 
+// ExportarDatos godoc
+// @Summary      Exportar datos del usuario
+// @Description  Devuelve el perfil, las suscripciones y el historial de eventos del usuario autenticado
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  dto.APIResponse
+// @Failure      401  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Failure      500  {object}  dto.ErrorResponse
+// @Router       /perfil/exportar [get]
+func (h *UsuarioHandler) ExportarDatos(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Usuario no autenticado", "unauthorized"))
+		return
+	}
+
+	exportacion, err := h.usuarioService.ExportarDatos(c.Request.Context(), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "usuario no encontrado" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, dto.NewErrorResponse("Error exportando datos", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse("Datos exportados exitosamente", exportacion))
+}
+
 // GetAllUsers godoc
 // @Summary      Listar todos los usuarios
 // @Description  Obtiene lista paginada de usuarios (público)
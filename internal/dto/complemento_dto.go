@@ -0,0 +1,58 @@
+package dto
+
+import "time"
+
+type ComplementoDTO struct {
+	ID          string       `json:"id"`
+	Nombre      string       `json:"nombre"`
+	Descripcion string       `json:"descripcion"`
+	Precios     []PrecioDTO  `json:"precios"`
+	PlanesIDs   []string     `json:"planes_ids"`
+	Derechos    []DerechoDTO `json:"derechos"`
+	Activo      bool         `json:"activo"`
+	CreadoEn    time.Time    `json:"creado_en"`
+}
+
+type CreateComplementoRequest struct {
+	Nombre      string       `json:"nombre" binding:"required,min=2,max=100"`
+	Descripcion string       `json:"descripcion" binding:"max=500"`
+	Precios     []PrecioDTO  `json:"precios" binding:"required,min=1,dive"` // Por periodo del plan al que se agrega
+	PlanesIDs   []string     `json:"planes_ids" binding:"required,min=1"`
+	Derechos    []DerechoDTO `json:"derechos,omitempty" binding:"omitempty,dive"`
+}
+
+// UpdateComplementoRequest no afecta a las suscripciones que ya tienen el
+// complemento hasta su próxima renovación.
+type UpdateComplementoRequest struct {
+	Nombre      *string       `json:"nombre,omitempty" binding:"omitempty,min=2,max=100"`
+	Descripcion *string       `json:"descripcion,omitempty" binding:"omitempty,max=500"`
+	Precios     *[]PrecioDTO  `json:"precios,omitempty" binding:"omitempty,min=1,dive"` // Reemplaza la lista completa
+	PlanesIDs   *[]string     `json:"planes_ids,omitempty" binding:"omitempty,min=1"`   // Reemplaza la lista completa
+	Derechos    *[]DerechoDTO `json:"derechos,omitempty" binding:"omitempty,dive"`      // Reemplaza la lista completa
+	Activo      *bool         `json:"activo,omitempty"`
+}
+
+// ComplementoSuscripcionDTO es un complemento contratado con el precio por
+// periodo en la moneda de la suscripción.
+type ComplementoSuscripcionDTO struct {
+	ComplementoID string       `json:"complemento_id"`
+	Nombre        string       `json:"nombre"`
+	Precio        int64        `json:"precio"`
+	Derechos      []DerechoDTO `json:"derechos,omitempty"`
+	AgregadoEn    time.Time    `json:"agregado_en"`
+}
+
+type AgregarComplementoRequest struct {
+	ComplementoID string `json:"complemento_id" binding:"required"`
+}
+
+// AltaComplementoDTO es el resultado de agregar un complemento: Cargo es lo
+// que se cobró por lo que queda del periodo en curso.
+type AltaComplementoDTO struct {
+	SuscripcionID string                    `json:"suscripcion_id"`
+	Complemento   ComplementoSuscripcionDTO `json:"complemento"`
+	Moneda        string                    `json:"moneda"`
+	DiasRestantes int                       `json:"dias_restantes"`
+	Cargo         int64                     `json:"cargo"`
+	FacturaID     string                    `json:"factura_id,omitempty"`
+}
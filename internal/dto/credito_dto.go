@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+type MovimientoCreditoDTO struct {
+	ID            string    `json:"id"`
+	UsuarioID     string    `json:"usuario_id"`
+	Moneda        string    `json:"moneda"`
+	Monto         int64     `json:"monto"` // Positivo suma crédito, negativo lo consume
+	Saldo         int64     `json:"saldo"` // Saldo después del movimiento
+	Motivo        string    `json:"motivo"`
+	SuscripcionID string    `json:"suscripcion_id,omitempty"`
+	FacturaID     string    `json:"factura_id,omitempty"`
+	ReembolsoID   string    `json:"reembolso_id,omitempty"`
+	Nota          string    `json:"nota,omitempty"`
+	Actor         string    `json:"actor"`
+	CreadoEn      time.Time `json:"creado_en"`
+}
+
+type SaldoCreditoDTO struct {
+	UsuarioID string           `json:"usuario_id"`
+	Saldos    []SaldoMonedaDTO `json:"saldos"`
+}
+
+type SaldoMonedaDTO struct {
+	Moneda string `json:"moneda"`
+	Saldo  int64  `json:"saldo"`
+}
+
+// OtorgarCreditoRequest suma crédito a favor del usuario. La nota queda en el
+// libro como justificación.
+type OtorgarCreditoRequest struct {
+	Monto         int64  `json:"monto" binding:"required,min=1"`
+	Moneda        string `json:"moneda,omitempty"` // Opcional, default la moneda por defecto
+	Nota          string `json:"nota" binding:"required,max=500"`
+	SuscripcionID string `json:"suscripcion_id,omitempty"`
+	FacturaID     string `json:"factura_id,omitempty"`
+}
+
+// ReembolsarCreditoRequest devuelve crédito a favor como dinero, sobre el cobro
+// de una factura pagada y en su moneda.
+type ReembolsarCreditoRequest struct {
+	FacturaID string `json:"factura_id" binding:"required"`
+	Monto     int64  `json:"monto" binding:"required,min=1"`
+	Nota      string `json:"nota" binding:"required,max=500"`
+}
@@ -0,0 +1,71 @@
+package dto
+
+import "time"
+
+type CuponDTO struct {
+	ID                  string     `json:"id"`
+	Codigo              string     `json:"codigo"`
+	Descripcion         string     `json:"descripcion"`
+	Tipo                string     `json:"tipo"`
+	Valor               int64      `json:"valor"`
+	Moneda              string     `json:"moneda,omitempty"`
+	Duracion            string     `json:"duracion"`
+	DuracionPeriodos    int        `json:"duracion_periodos,omitempty"`
+	ValidoDesde         *time.Time `json:"valido_desde,omitempty"`
+	ValidoHasta         *time.Time `json:"valido_hasta,omitempty"`
+	MaxCanjes           int        `json:"max_canjes"`
+	MaxCanjesPorUsuario int        `json:"max_canjes_por_usuario"`
+	Canjes              int        `json:"canjes"`
+	PlanesIDs           []string   `json:"planes_ids,omitempty"`
+	Activo              bool       `json:"activo"`
+	CreadoEn            time.Time  `json:"creado_en"`
+}
+
+type CreateCuponRequest struct {
+	Codigo              string   `json:"codigo" binding:"required,min=3,max=50,alphanum"`
+	Descripcion         string   `json:"descripcion" binding:"max=500"`
+	Tipo                string   `json:"tipo" binding:"required,oneof=porcentaje monto_fijo"`
+	Valor               int64    `json:"valor" binding:"required,gt=0"`              // Porcentaje entero o monto en unidad menor
+	Moneda              string   `json:"moneda,omitempty" binding:"omitempty,len=3"` // Requerido si tipo es monto_fijo
+	Duracion            string   `json:"duracion" binding:"required,oneof=una_vez repetido siempre"`
+	DuracionPeriodos    int      `json:"duracion_periodos,omitempty" binding:"omitempty,min=1,max=120"` // Requerido si duracion es repetido
+	ValidoDesde         string   `json:"valido_desde,omitempty"`                                        // YYYY-MM-DD
+	ValidoHasta         string   `json:"valido_hasta,omitempty"`                                        // YYYY-MM-DD, exclusivo
+	MaxCanjes           int      `json:"max_canjes,omitempty" binding:"omitempty,min=0"`
+	MaxCanjesPorUsuario int      `json:"max_canjes_por_usuario,omitempty" binding:"omitempty,min=0"`
+	PlanesIDs           []string `json:"planes_ids,omitempty"`
+}
+
+type UpdateCuponRequest struct {
+	Descripcion         *string   `json:"descripcion,omitempty" binding:"omitempty,max=500"`
+	ValidoHasta         *string   `json:"valido_hasta,omitempty"`
+	MaxCanjes           *int      `json:"max_canjes,omitempty" binding:"omitempty,min=0"`
+	MaxCanjesPorUsuario *int      `json:"max_canjes_por_usuario,omitempty" binding:"omitempty,min=0"`
+	PlanesIDs           *[]string `json:"planes_ids,omitempty"`
+	Activo              *bool     `json:"activo,omitempty"`
+}
+
+type ValidarCuponRequest struct {
+	Codigo string `json:"codigo" binding:"required"`
+	PlanID string `json:"plan_id" binding:"required"`
+	Moneda string `json:"moneda,omitempty" binding:"omitempty,len=3"` // Opcional, default MONEDA_DEFAULT
+}
+
+type ValidacionCuponDTO struct {
+	Cupon          CuponDTO `json:"cupon"`
+	PlanID         string   `json:"plan_id"`
+	Moneda         string   `json:"moneda"`
+	PrecioOriginal int64    `json:"precio_original"`
+	Descuento      int64    `json:"descuento"`
+	PrecioFinal    int64    `json:"precio_final"`
+}
+
+type DescuentoDTO struct {
+	CuponID           string `json:"cupon_id"`
+	Codigo            string `json:"codigo"`
+	Tipo              string `json:"tipo"`
+	Valor             int64  `json:"valor"`
+	Moneda            string `json:"moneda,omitempty"`
+	Duracion          string `json:"duracion"`
+	PeriodosRestantes int    `json:"periodos_restantes,omitempty"`
+}
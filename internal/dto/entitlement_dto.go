@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// EntitlementsDTO es el conjunto de derechos efectivo de un usuario según su
+// suscripción vigente. Sin suscripción vigente Entitlements viene vacío.
+// Titular es false cuando los derechos vienen de un asiento en la suscripción
+// de otro usuario; Asiento indica si ocupa un asiento, y en planes que no son
+// por asiento el titular siempre lo ocupa.
+type EntitlementsDTO struct {
+	UsuarioID     string                 `json:"usuario_id"`
+	SuscripcionID string                 `json:"suscripcion_id,omitempty"`
+	PlanID        string                 `json:"plan_id,omitempty"`
+	Estado        string                 `json:"estado,omitempty"`
+	Titular       bool                   `json:"titular"`
+	Asiento       bool                   `json:"asiento"`
+	Entitlements  map[string]interface{} `json:"entitlements"`
+	VigenteHasta  *time.Time             `json:"vigente_hasta,omitempty"`
+	ResueltoEn    time.Time              `json:"resuelto_en"`
+}
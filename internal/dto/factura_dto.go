@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+type FacturaDTO struct {
+	ID              string               `json:"id"`
+	Numero          int64                `json:"numero,omitempty"`
+	Codigo          string               `json:"codigo,omitempty"`
+	SuscripcionID   string               `json:"suscripcion_id,omitempty"` // Vacío en la compra de un regalo
+	RegaloID        string               `json:"regalo_id,omitempty"`
+	UsuarioID       string               `json:"usuario_id"`
+	Motivo          string               `json:"motivo"`
+	Estado          string               `json:"estado"`
+	Moneda          string               `json:"moneda"`
+	Lineas          []LineaFacturaDTO    `json:"lineas"`
+	Subtotal        int64                `json:"subtotal"`
+	Descuento       int64                `json:"descuento"`
+	Impuesto        int64                `json:"impuesto"`
+	Desglose        *DesgloseImpuestoDTO `json:"desglose_impuesto,omitempty"`
+	CreditoAplicado int64                `json:"credito_aplicado,omitempty"`
+	Total           int64                `json:"total"`
+	Reembolsado     int64                `json:"reembolsado,omitempty"`
+	PeriodoInicio   time.Time            `json:"periodo_inicio"`
+	PeriodoFin      time.Time            `json:"periodo_fin"`
+	EmitidaEn       *time.Time           `json:"emitida_en,omitempty"`
+	PagadaEn        *time.Time           `json:"pagada_en,omitempty"`
+	AnuladaEn       *time.Time           `json:"anulada_en,omitempty"`
+	CreadoEn        time.Time            `json:"creado_en"`
+}
+
+type LineaFacturaDTO struct {
+	Tipo           string `json:"tipo"`
+	Descripcion    string `json:"descripcion"`
+	Cantidad       int    `json:"cantidad"`
+	PrecioUnitario int64  `json:"precio_unitario"`
+	Monto          int64  `json:"monto"`
+}
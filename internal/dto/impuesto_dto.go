@@ -0,0 +1,52 @@
+package dto
+
+import "time"
+
+type TasaImpuestoDTO struct {
+	ID         string    `json:"id"`
+	Pais       string    `json:"pais"`
+	Region     string    `json:"region,omitempty"`
+	Nombre     string    `json:"nombre"`
+	Porcentaje float64   `json:"porcentaje"`
+	Activo     bool      `json:"activo"`
+	CreadoEn   time.Time `json:"creado_en"`
+}
+
+type CreateTasaImpuestoRequest struct {
+	Pais       string  `json:"pais" binding:"required,iso3166_1_alpha2"`
+	Region     string  `json:"region,omitempty" binding:"max=50"` // Opcional, vacío aplica a todo el país
+	Nombre     string  `json:"nombre" binding:"required,min=2,max=50"`
+	Porcentaje float64 `json:"porcentaje" binding:"gte=0,lte=100"`
+}
+
+type UpdateTasaImpuestoRequest struct {
+	Nombre     *string  `json:"nombre,omitempty" binding:"omitempty,min=2,max=50"`
+	Porcentaje *float64 `json:"porcentaje,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Activo     *bool    `json:"activo,omitempty"`
+}
+
+type CalcularImpuestoRequest struct {
+	PlanID string `json:"plan_id" binding:"required"`
+	Moneda string `json:"moneda,omitempty" binding:"omitempty,len=3"` // Opcional, default MONEDA_DEFAULT
+}
+
+// DesgloseImpuestoDTO detalla el impuesto de un cobro. Si Incluido es true el
+// precio ya lo contenía y Base es lo que queda sin él.
+type DesgloseImpuestoDTO struct {
+	Nombre     string  `json:"nombre"`
+	Pais       string  `json:"pais"`
+	Region     string  `json:"region,omitempty"`
+	Porcentaje float64 `json:"porcentaje"`
+	Incluido   bool    `json:"incluido"`
+	Base       int64   `json:"base"`
+	Monto      int64   `json:"monto"`
+	Total      int64   `json:"total"`
+}
+
+type CalculoImpuestoDTO struct {
+	PlanID   string               `json:"plan_id"`
+	Moneda   string               `json:"moneda"`
+	Precio   int64                `json:"precio"`
+	Impuesto *DesgloseImpuestoDTO `json:"impuesto,omitempty"` // Ausente si el país del usuario no tiene impuesto
+	Total    int64                `json:"total"`
+}
@@ -3,23 +3,112 @@ package dto
 import "time"
 
 type PlanSuscripcionDTO struct {
-	ID          string    `json:"id"`
-	Nombre      string    `json:"nombre"`
-	Descripcion string    `json:"descripcion"`
-	Precio      float64   `json:"precio"`
-	Activo      bool      `json:"activo"`
-	CreadoEn    time.Time `json:"creado_en"`
+	ID                string       `json:"id"`
+	Nombre            string       `json:"nombre"`
+	Descripcion       string       `json:"descripcion"`
+	Precios           []PrecioDTO  `json:"precios"`
+	ImpuestoIncluido  bool         `json:"impuesto_incluido"`
+	Intervalo         string       `json:"intervalo"`
+	IntervaloCantidad int          `json:"intervalo_cantidad"`
+	DiasPrueba        int          `json:"dias_prueba"`
+	MaxDiasPausa      int          `json:"max_dias_pausa"`
+	PorAsiento        bool         `json:"por_asiento"`
+	MinAsientos       int          `json:"min_asientos"`
+	MaxAsientos       int          `json:"max_asientos"`
+	Derechos          []DerechoDTO `json:"derechos"`
+	Version           int          `json:"version"`
+	Activo            bool         `json:"activo"`
+	CreadoEn          time.Time    `json:"creado_en"`
+}
+
+// VersionPlanDTO son los términos de una versión del plan. Solo una es la
+// vigente para nuevas suscripciones.
+type VersionPlanDTO struct {
+	Numero           int          `json:"numero"`
+	Precios          []PrecioDTO  `json:"precios"`
+	ImpuestoIncluido bool         `json:"impuesto_incluido"`
+	Derechos         []DerechoDTO `json:"derechos"`
+	Vigente          bool         `json:"vigente"`
+	CreadoEn         time.Time    `json:"creado_en"`
+}
+
+// MigrarVersionPlanRequest mueve a VersionDestino (por defecto la vigente) las
+// suscripciones del plan que están en VersionOrigen, o en cualquier otra
+// versión si se omite. Con DryRun solo se devuelve el reporte.
+type MigrarVersionPlanRequest struct {
+	VersionOrigen  *int `json:"version_origen,omitempty" binding:"omitempty,min=1"`
+	VersionDestino int  `json:"version_destino,omitempty" binding:"omitempty,min=1"`
+	DryRun         bool `json:"dry_run"`
+}
+
+type ResumenMigracionDTO struct {
+	VersionOrigen int    `json:"version_origen"`
+	Moneda        string `json:"moneda"`
+	Suscripciones int    `json:"suscripciones"`
+	PrecioActual  int64  `json:"precio_actual"`
+	PrecioNuevo   int64  `json:"precio_nuevo"`
+}
+
+type OmisionMigracionDTO struct {
+	SuscripcionID string `json:"suscripcion_id"`
+	Motivo        string `json:"motivo"`
+}
+
+// MigracionVersionPlanDTO es el reporte de una migración. En un dry run,
+// Migradas cuenta las suscripciones que se migrarían.
+type MigracionVersionPlanDTO struct {
+	PlanID         string                 `json:"plan_id"`
+	VersionDestino int                    `json:"version_destino"`
+	DryRun         bool                   `json:"dry_run"`
+	Evaluadas      int                    `json:"evaluadas"`
+	Migradas       int                    `json:"migradas"`
+	Resumen        []*ResumenMigracionDTO `json:"resumen"`
+	Omitidas       []*OmisionMigracionDTO `json:"omitidas"`
 }
 
 type CreatePlanRequest struct {
-	Nombre      string  `json:"nombre" binding:"required,min=2,max=100"`
-	Descripcion string  `json:"descripcion" binding:"required,min=10,max=500"`
-	Precio      float64 `json:"precio" binding:"required,gt=0"`
+	Nombre            string       `json:"nombre" binding:"required,min=2,max=100"`
+	Descripcion       string       `json:"descripcion" binding:"required,min=10,max=500"`
+	Precios           []PrecioDTO  `json:"precios" binding:"required,min=1,dive"`
+	ImpuestoIncluido  bool         `json:"impuesto_incluido,omitempty"`                                       // Opcional, default false: el impuesto se suma al precio
+	Intervalo         string       `json:"intervalo,omitempty" binding:"omitempty,oneof=dia semana mes anio"` // Opcional, default mes
+	IntervaloCantidad int          `json:"intervalo_cantidad,omitempty" binding:"omitempty,min=1,max=365"`    // Opcional, default 1
+	DiasPrueba        int          `json:"dias_prueba,omitempty" binding:"omitempty,min=0,max=365"`
+	MaxDiasPausa      int          `json:"max_dias_pausa,omitempty" binding:"omitempty,min=0,max=365"`
+	PorAsiento        bool         `json:"por_asiento,omitempty"`                            // Opcional: el precio es por asiento
+	MinAsientos       int          `json:"min_asientos,omitempty" binding:"omitempty,min=1"` // Opcional, default 1
+	MaxAsientos       int          `json:"max_asientos,omitempty" binding:"omitempty,min=1"` // Opcional, default sin máximo
+	Derechos          []DerechoDTO `json:"derechos,omitempty" binding:"omitempty,dive"`
 }
 
 type UpdatePlanRequest struct {
-	Nombre      *string  `json:"nombre,omitempty" binding:"omitempty,min=2,max=100"`
-	Descripcion *string  `json:"descripcion,omitempty" binding:"omitempty,min=10,max=500"`
-	Precio      *float64 `json:"precio,omitempty" binding:"omitempty,gt=0"`
-	Activo      *bool    `json:"activo,omitempty"`
+	Nombre            *string       `json:"nombre,omitempty" binding:"omitempty,min=2,max=100"`
+	Descripcion       *string       `json:"descripcion,omitempty" binding:"omitempty,min=10,max=500"`
+	Precios           *[]PrecioDTO  `json:"precios,omitempty" binding:"omitempty,min=1,dive"` // Reemplaza la lista completa
+	ImpuestoIncluido  *bool         `json:"impuesto_incluido,omitempty"`
+	Intervalo         *string       `json:"intervalo,omitempty" binding:"omitempty,oneof=dia semana mes anio"`
+	IntervaloCantidad *int          `json:"intervalo_cantidad,omitempty" binding:"omitempty,min=1,max=365"`
+	DiasPrueba        *int          `json:"dias_prueba,omitempty" binding:"omitempty,min=0,max=365"`
+	MaxDiasPausa      *int          `json:"max_dias_pausa,omitempty" binding:"omitempty,min=0,max=365"`
+	PorAsiento        *bool         `json:"por_asiento,omitempty"`
+	MinAsientos       *int          `json:"min_asientos,omitempty" binding:"omitempty,min=1"`
+	MaxAsientos       *int          `json:"max_asientos,omitempty" binding:"omitempty,min=0"` // 0 quita el máximo
+	Derechos          *[]DerechoDTO `json:"derechos,omitempty" binding:"omitempty,dive"`      // Reemplaza la lista completa
+	Activo            *bool         `json:"activo,omitempty"`
+}
+
+// PrecioDTO es un precio del plan en la unidad menor de la moneda (centavos
+// para BOB o USD).
+type PrecioDTO struct {
+	Moneda string `json:"moneda" binding:"required,len=3"`
+	Monto  int64  `json:"monto" binding:"required,gt=0"`
+}
+
+// DerechoDTO declara una funcionalidad (tipo booleano) o un límite numérico
+// (tipo limite, -1 = ilimitado) del plan.
+type DerechoDTO struct {
+	Clave      string `json:"clave" binding:"required,max=50"`
+	Tipo       string `json:"tipo" binding:"required,oneof=booleano limite"`
+	Habilitado bool   `json:"habilitado,omitempty"`
+	Limite     int64  `json:"limite,omitempty" binding:"gte=-1"`
 }
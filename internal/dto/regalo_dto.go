@@ -0,0 +1,47 @@
+package dto
+
+import "time"
+
+// RegaloDTO muestra el estado efectivo del regalo: un código disponible cuya
+// fecha de expiración pasó figura como expirado.
+type RegaloDTO struct {
+	ID            string     `json:"id"`
+	Codigo        string     `json:"codigo"`
+	CompradorID   string     `json:"comprador_id"`
+	PlanID        string     `json:"plan_id"`
+	PlanVersion   int        `json:"plan_version"`
+	Periodos      int        `json:"periodos"`
+	Moneda        string     `json:"moneda"`
+	Precio        int64      `json:"precio"` // Por periodo
+	Estado        string     `json:"estado"`
+	FacturaID     string     `json:"factura_id,omitempty"`
+	ExpiraEn      time.Time  `json:"expira_en"`
+	CanjeadoPor   string     `json:"canjeado_por,omitempty"`
+	CanjeadoEn    *time.Time `json:"canjeado_en,omitempty"`
+	SuscripcionID string     `json:"suscripcion_id,omitempty"`
+	RevocadoEn    *time.Time `json:"revocado_en,omitempty"`
+	CreadoEn      time.Time  `json:"creado_en"`
+	Pago          *PagoDTO   `json:"pago,omitempty"`
+}
+
+type ComprarRegaloRequest struct {
+	PlanID   string `json:"plan_id" binding:"required"`
+	Periodos int    `json:"periodos" binding:"required,min=1,max=36"`
+	Moneda   string `json:"moneda,omitempty" binding:"omitempty,len=3"` // Opcional, default MONEDA_DEFAULT
+}
+
+type CanjearRegaloRequest struct {
+	Codigo string `json:"codigo" binding:"required"`
+}
+
+// CanjeRegaloDTO es el resultado de canjear un código: Extendida indica que se
+// alargó la suscripción vigente en lugar de crear una nueva.
+type CanjeRegaloDTO struct {
+	Regalo      RegaloDTO      `json:"regalo"`
+	Extendida   bool           `json:"extendida"`
+	Suscripcion SuscripcionDTO `json:"suscripcion"`
+}
+
+type ExpirarRegaloRequest struct {
+	ExpiraEn string `json:"expira_en,omitempty"` // YYYY-MM-DD; vacío expira el código ya
+}
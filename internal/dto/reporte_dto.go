@@ -0,0 +1,41 @@
+package dto
+
+// ReporteMRRDTO resume el ingreso recurrente de un rango de fechas en una
+// moneda. Los montos van en la unidad menor de la moneda y son mensuales
+// salvo ARR; no descuentan cupones.
+type ReporteMRRDTO struct {
+	Moneda            string  `json:"moneda"`
+	Desde             string  `json:"desde"`
+	Hasta             string  `json:"hasta"`
+	MRRInicial        int64   `json:"mrr_inicial"`
+	MRR               int64   `json:"mrr"` // Al cierre del rango
+	ARR               int64   `json:"arr"`
+	MRRNuevo          int64   `json:"mrr_nuevo"`
+	MRRExpansion      int64   `json:"mrr_expansion"`
+	MRRContraccion    int64   `json:"mrr_contraccion"` // Positivo: lo que se dejó de cobrar
+	MRRCancelado      int64   `json:"mrr_cancelado"`   // Positivo: lo que se dejó de cobrar
+	ClientesIniciales int     `json:"clientes_iniciales"`
+	Clientes          int     `json:"clientes"`
+	ClientesPerdidos  int     `json:"clientes_perdidos"`
+	TasaChurnClientes float64 `json:"tasa_churn_clientes"` // Porcentaje de los clientes iniciales que se perdieron
+	ARPU              int64   `json:"arpu"`
+}
+
+// ReporteCohortesDTO agrupa a los usuarios por el mes en que empezaron y
+// muestra qué parte de cada cohorte sigue con una suscripción en los meses
+// siguientes.
+type ReporteCohortesDTO struct {
+	Agrupacion string       `json:"agrupacion"` // registro, primera_suscripcion
+	Desde      string       `json:"desde"`      // YYYY-MM
+	Hasta      string       `json:"hasta"`      // YYYY-MM
+	Cohortes   []CohorteDTO `json:"cohortes"`
+}
+
+// CohorteDTO es una fila de la matriz: la posición i de Activos y Retencion
+// es el mes i contado desde el de la cohorte, hasta el mes en curso.
+type CohorteDTO struct {
+	Mes       string    `json:"mes"` // YYYY-MM
+	Usuarios  int       `json:"usuarios"`
+	Activos   []int     `json:"activos"`
+	Retencion []float64 `json:"retencion"` // Porcentaje de Usuarios
+}
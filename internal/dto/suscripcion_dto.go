@@ -3,25 +3,170 @@ package dto
 import "time"
 
 type SuscripcionDTO struct {
-	ID          string              `json:"id"`
-	UsuarioID   string              `json:"usuario_id"`
-	PlanID      string              `json:"plan_id"`
-	FechaInicio time.Time           `json:"fecha_inicio"`
-	FechaFin    time.Time           `json:"fecha_fin"`
-	Estado      string              `json:"estado"`
-	CreadoEn    time.Time           `json:"creado_en"`
-	Usuario     *UsuarioDTO         `json:"usuario,omitempty"`
-	Plan        *PlanSuscripcionDTO `json:"plan,omitempty"`
+	ID                string                      `json:"id"`
+	UsuarioID         string                      `json:"usuario_id"`
+	PlanID            string                      `json:"plan_id"`
+	PlanVersion       int                         `json:"plan_version"`
+	FechaInicio       time.Time                   `json:"fecha_inicio"`
+	FechaFin          time.Time                   `json:"fecha_fin"`
+	Estado            string                      `json:"estado"`
+	Moneda            string                      `json:"moneda"`
+	Precio            int64                       `json:"precio"`
+	Cantidad          int                         `json:"cantidad"`
+	Complementos      []ComplementoSuscripcionDTO `json:"complementos,omitempty"`
+	Impuesto          *DesgloseImpuestoDTO        `json:"impuesto,omitempty"`
+	AutoRenovar       bool                        `json:"auto_renovar"`
+	FinPrueba         *time.Time                  `json:"fin_prueba,omitempty"`
+	PlanPendienteID   string                      `json:"plan_pendiente_id,omitempty"`
+	PausadaDesde      *time.Time                  `json:"pausada_desde,omitempty"`
+	PausadaHasta      *time.Time                  `json:"pausada_hasta,omitempty"`
+	CancelarAlFinal   bool                        `json:"cancelar_al_final"`
+	CanceladaEn       *time.Time                  `json:"cancelada_en,omitempty"`
+	MotivoCancelacion string                      `json:"motivo_cancelacion,omitempty"`
+	Descuento         *DescuentoDTO               `json:"descuento,omitempty"`
+	Transiciones      []TransicionEstadoDTO       `json:"transiciones,omitempty"`
+	CreadoEn          time.Time                   `json:"creado_en"`
+	Usuario           *UsuarioDTO                 `json:"usuario,omitempty"`
+	Plan              *PlanSuscripcionDTO         `json:"plan,omitempty"`
+	Pago              *PagoDTO                    `json:"pago,omitempty"`
 }
 
 type CreateSuscripcionRequest struct {
 	UsuarioID   string `json:"usuario_id" binding:"required"`
 	PlanID      string `json:"plan_id" binding:"required"`
 	FechaInicio string `json:"fecha_inicio,omitempty"`
-	FechaFin    string `json:"fecha_fin,omitempty"`
+	FechaFin    string `json:"fecha_fin,omitempty"`    // No se admite si el alta empieza con prueba gratuita
+	AutoRenovar *bool  `json:"auto_renovar,omitempty"` // Opcional, default true
+	Cupon       string `json:"cupon,omitempty"`
+	Moneda      string `json:"moneda,omitempty" binding:"omitempty,len=3"`   // Opcional, default MONEDA_DEFAULT
+	Cantidad    int    `json:"cantidad,omitempty" binding:"omitempty,min=1"` // Asientos; opcional, default el mínimo del plan
 }
 
 type UpdateSuscripcionRequest struct {
 	FechaFin *string `json:"fecha_fin,omitempty"`
 	Estado   *string `json:"estado,omitempty" binding:"omitempty,oneof=activa vencida cancelada"`
 }
+
+type RenovacionDTO struct {
+	ID               string    `json:"id"`
+	SuscripcionID    string    `json:"suscripcion_id"`
+	PlanAnteriorID   string    `json:"plan_anterior_id"`
+	PlanID           string    `json:"plan_id"`
+	FechaFinAnterior time.Time `json:"fecha_fin_anterior"`
+	FechaFinNueva    time.Time `json:"fecha_fin_nueva"`
+	CreadoEn         time.Time `json:"creado_en"`
+}
+
+type CambiarPlanRequest struct {
+	PlanID string `json:"plan_id" binding:"required"`
+	Modo   string `json:"modo,omitempty" binding:"omitempty,oneof=inmediato fin_periodo"` // Opcional, default inmediato
+}
+
+type CambioPlanDTO struct {
+	SuscripcionID  string    `json:"suscripcion_id"`
+	PlanAnteriorID string    `json:"plan_anterior_id"`
+	PlanNuevoID    string    `json:"plan_nuevo_id"`
+	Modo           string    `json:"modo"`
+	DiasRestantes  int       `json:"dias_restantes"`
+	Moneda         string    `json:"moneda"`
+	PrecioNuevo    int64     `json:"precio_nuevo"`
+	Credito        int64     `json:"credito"`
+	Cargo          int64     `json:"cargo"`
+	MontoNeto      int64     `json:"monto_neto"`
+	FacturaID      string    `json:"factura_id,omitempty"`
+	FechaEfectiva  time.Time `json:"fecha_efectiva"`
+}
+
+type ProgramarCambioPlanRequest struct {
+	PlanID        string `json:"plan_id" binding:"required"`
+	FechaEfectiva string `json:"fecha_efectiva" binding:"required"` // YYYY-MM-DD, posterior a hoy
+}
+
+type CambioProgramadoDTO struct {
+	ID            string     `json:"id"`
+	SuscripcionID string     `json:"suscripcion_id"`
+	UsuarioID     string     `json:"usuario_id"`
+	PlanNuevoID   string     `json:"plan_nuevo_id"`
+	FechaEfectiva time.Time  `json:"fecha_efectiva"`
+	Estado        string     `json:"estado"`
+	CreadoPor     string     `json:"creado_por"`
+	CambioPlanID  string     `json:"cambio_plan_id,omitempty"`
+	AplicadoEn    *time.Time `json:"aplicado_en,omitempty"`
+	CanceladoPor  string     `json:"cancelado_por,omitempty"`
+	CanceladoEn   *time.Time `json:"cancelado_en,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreadoEn      time.Time  `json:"creado_en"`
+}
+
+type CambiarAsientosRequest struct {
+	Cantidad int `json:"cantidad" binding:"required,min=1"` // Asientos a agregar o quitar
+}
+
+// CambioAsientosDTO es el prorrateo de un cambio de asientos. El cargo se
+// factura de inmediato; el crédito pasa al saldo a favor del usuario.
+type CambioAsientosDTO struct {
+	SuscripcionID    string    `json:"suscripcion_id"`
+	CantidadAnterior int       `json:"cantidad_anterior"`
+	CantidadNueva    int       `json:"cantidad_nueva"`
+	Moneda           string    `json:"moneda"`
+	PrecioUnitario   int64     `json:"precio_unitario"`
+	PrecioNuevo      int64     `json:"precio_nuevo"`
+	DiasRestantes    int       `json:"dias_restantes"`
+	Credito          int64     `json:"credito"`
+	Cargo            int64     `json:"cargo"`
+	FacturaID        string    `json:"factura_id,omitempty"`
+	CreadoEn         time.Time `json:"creado_en"`
+}
+
+type AsignarAsientoRequest struct {
+	UsuarioID string `json:"usuario_id" binding:"required"`
+}
+
+type AsientoDTO struct {
+	UsuarioID  string    `json:"usuario_id"`
+	AsignadoEn time.Time `json:"asignado_en"`
+}
+
+type AsientosDTO struct {
+	SuscripcionID string       `json:"suscripcion_id"`
+	Cantidad      int          `json:"cantidad"`
+	Libres        int          `json:"libres"`
+	Asientos      []AsientoDTO `json:"asientos"`
+}
+
+type PausarSuscripcionRequest struct {
+	Dias int `json:"dias" binding:"required,min=1,max=365"`
+}
+
+type CancelarSuscripcionRequest struct {
+	Modo       string `json:"modo,omitempty" binding:"omitempty,oneof=inmediato fin_periodo"` // Opcional, default inmediato
+	Motivo     string `json:"motivo,omitempty" binding:"max=100"`
+	Comentario string `json:"comentario,omitempty" binding:"max=1000"`
+}
+
+type TransicionEstadoDTO struct {
+	Desde string    `json:"desde,omitempty"`
+	Hasta string    `json:"hasta"`
+	Fecha time.Time `json:"fecha"`
+	Actor string    `json:"actor"`
+}
+
+type EventoSuscripcionDTO struct {
+	ID            string                 `json:"id"`
+	SuscripcionID string                 `json:"suscripcion_id"`
+	Tipo          string                 `json:"tipo"`
+	Actor         string                 `json:"actor"`
+	Antes         map[string]interface{} `json:"antes,omitempty"`
+	Despues       map[string]interface{} `json:"despues,omitempty"`
+	CreadoEn      time.Time              `json:"creado_en"`
+}
+
+// PagoDTO acompaña al alta de una suscripción con lo necesario para pagarla.
+type PagoDTO struct {
+	FacturaID string `json:"factura_id"`
+	CobroID   string `json:"cobro_id,omitempty"`
+	Estado    string `json:"estado"` // Estado de la factura
+	Monto     int64  `json:"monto"`
+	Moneda    string `json:"moneda"`
+	URLPago   string `json:"url_pago,omitempty"`
+}
@@ -0,0 +1,54 @@
+package dto
+
+import "time"
+
+type RegistrarUsoRequest struct {
+	SuscripcionID string `json:"suscripcion_id" binding:"required"`
+	Metrica       string `json:"metrica" binding:"required,max=50"`
+	Cantidad      int64  `json:"cantidad" binding:"required,gt=0"`
+	// ClaveIdempotencia puede venir también en el header Idempotency-Key.
+	ClaveIdempotencia string     `json:"clave_idempotencia" binding:"max=100"`
+	OcurridoEn        *time.Time `json:"ocurrido_en"`
+}
+
+type VerificarUsoRequest struct {
+	SuscripcionID string `json:"suscripcion_id" binding:"required"`
+	Metrica       string `json:"metrica" binding:"required,max=50"`
+	Cantidad      int64  `json:"cantidad" binding:"required,gt=0"`
+}
+
+// ConsumoMetricaDTO es el consumo de una métrica en el periodo actual. Limite y
+// Restante valen -1 cuando la métrica es ilimitada.
+type ConsumoMetricaDTO struct {
+	Metrica       string    `json:"metrica"`
+	Consumido     int64     `json:"consumido"`
+	Limite        int64     `json:"limite"`
+	Restante      int64     `json:"restante"`
+	PeriodoInicio time.Time `json:"periodo_inicio"`
+	PeriodoFin    time.Time `json:"periodo_fin"`
+}
+
+type EventoUsoDTO struct {
+	ID                string             `json:"id"`
+	SuscripcionID     string             `json:"suscripcion_id"`
+	Metrica           string             `json:"metrica"`
+	Cantidad          int64              `json:"cantidad"`
+	ClaveIdempotencia string             `json:"clave_idempotencia"`
+	PeriodoInicio     time.Time          `json:"periodo_inicio"`
+	OcurridoEn        time.Time          `json:"ocurrido_en"`
+	CreadoEn          time.Time          `json:"creado_en"`
+	Duplicado         bool               `json:"duplicado"`
+	Consumo           *ConsumoMetricaDTO `json:"consumo,omitempty"`
+}
+
+type VerificacionUsoDTO struct {
+	Permitido bool `json:"permitido"`
+	ConsumoMetricaDTO
+}
+
+type UsoSuscripcionDTO struct {
+	SuscripcionID string               `json:"suscripcion_id"`
+	PeriodoInicio time.Time            `json:"periodo_inicio"`
+	PeriodoFin    time.Time            `json:"periodo_fin"`
+	Metricas      []*ConsumoMetricaDTO `json:"metricas"`
+}
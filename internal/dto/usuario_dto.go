@@ -3,21 +3,24 @@ package dto
 import "time"
 
 type UsuarioDTO struct {
-	ID       string    `json:"id"`
-	Nombre   string    `json:"nombre"`
-	Email    string    `json:"email"`
-	Telefono string    `json:"telefono"`
-	Estado   bool      `json:"estado"`
-	EsAdmin  bool      `json:"es_admin"`
-	CreadoEn time.Time `json:"creado_en"`
+	ID                string    `json:"id"`
+	Nombre            string    `json:"nombre"`
+	Email             string    `json:"email"`
+	Telefono          string    `json:"telefono"`
+	Estado            bool      `json:"estado"`
+	EsAdmin           bool      `json:"es_admin"`
+	PaisFacturacion   string    `json:"pais_facturacion,omitempty"`
+	RegionFacturacion string    `json:"region_facturacion,omitempty"`
+	CreadoEn          time.Time `json:"creado_en"`
 }
 
 type CreateUsuarioRequest struct {
-	Nombre   string `json:"nombre" binding:"required,min=2,max=100"`
-	Email    string `json:"email" binding:"required,email"`
-	Telefono string `json:"telefono" binding:"max=20"`
-	Password string `json:"password" binding:"required,min=6"`
-	EsAdmin  bool   `json:"es_admin"` // Opcional, default false
+	Nombre            string `json:"nombre" binding:"required,min=2,max=100"`
+	Email             string `json:"email" binding:"required,email"`
+	Telefono          string `json:"telefono" binding:"max=20"`
+	Password          string `json:"password" binding:"required,min=6"`
+	PaisFacturacion   string `json:"pais_facturacion,omitempty" binding:"omitempty,iso3166_1_alpha2"`
+	RegionFacturacion string `json:"region_facturacion,omitempty" binding:"max=50"`
 }
 
 type LoginRequest struct {
@@ -26,9 +29,11 @@ type LoginRequest struct {
 }
 
 type UpdateUsuarioRequest struct {
-	Nombre   *string `json:"nombre,omitempty" binding:"omitempty,min=2,max=100"`
-	Telefono *string `json:"telefono,omitempty" binding:"omitempty,max=20"`
-	Password *string `json:"password,omitempty" binding:"omitempty,min=6"`
+	Nombre            *string `json:"nombre,omitempty" binding:"omitempty,min=2,max=100"`
+	Telefono          *string `json:"telefono,omitempty" binding:"omitempty,max=20"`
+	Password          *string `json:"password,omitempty" binding:"omitempty,min=6"`
+	PaisFacturacion   *string `json:"pais_facturacion,omitempty" binding:"omitempty,iso3166_1_alpha2"`
+	RegionFacturacion *string `json:"region_facturacion,omitempty" binding:"omitempty,max=50"`
 }
 
 type LoginResponse struct {
@@ -40,3 +45,10 @@ type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
 	NewPassword     string `json:"new_password" binding:"required,min=6"`
 }
+
+type ExportacionUsuarioDTO struct {
+	Usuario       UsuarioDTO              `json:"usuario"`
+	Suscripciones []*SuscripcionDTO       `json:"suscripciones"`
+	Eventos       []*EventoSuscripcionDTO `json:"eventos"`
+	GeneradoEn    time.Time               `json:"generado_en"`
+}
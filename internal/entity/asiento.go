@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AsientoOcupado reserva el asiento de un usuario. El _id es el del usuario,
+// así que de dos asignaciones simultáneas del mismo usuario solo una lo
+// consigue.
+type AsientoOcupado struct {
+	UsuarioID     primitive.ObjectID `bson:"_id" json:"usuario_id"`
+	SuscripcionID primitive.ObjectID `bson:"suscripcion_id" json:"suscripcion_id"`
+	OcupadoEn     time.Time          `bson:"ocupado_en" json:"ocupado_en"`
+}
+
+func (a AsientoOcupado) GetCollectionName() string {
+	return "asientos_ocupados"
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CambioAsientos registra un cambio en la cantidad de asientos a mitad de
+// periodo con su prorrateo, igual que CambioPlan para los cambios de plan.
+type CambioAsientos struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	SuscripcionID    primitive.ObjectID  `bson:"suscripcion_id" json:"suscripcion_id"`
+	UsuarioID        primitive.ObjectID  `bson:"usuario_id" json:"usuario_id"`
+	CantidadAnterior int                 `bson:"cantidad_anterior" json:"cantidad_anterior"`
+	CantidadNueva    int                 `bson:"cantidad_nueva" json:"cantidad_nueva"`
+	Moneda           string              `bson:"moneda" json:"moneda"`
+	PrecioUnitario   int64               `bson:"precio_unitario" json:"precio_unitario"` // Precio por asiento y periodo en Moneda
+	DiasRestantes    int                 `bson:"dias_restantes" json:"dias_restantes"`
+	Credito          int64               `bson:"credito" json:"credito"` // Por los asientos quitados
+	Cargo            int64               `bson:"cargo" json:"cargo"`     // Por los asientos agregados
+	FacturaID        *primitive.ObjectID `bson:"factura_id,omitempty" json:"factura_id,omitempty"`
+	CreadoEn         time.Time           `bson:"creado_en" json:"creado_en"`
+}
+
+func (c CambioAsientos) GetCollectionName() string {
+	return "cambios_asientos"
+}
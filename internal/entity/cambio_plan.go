@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CambioPlan struct {
+	ID                primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	SuscripcionID     primitive.ObjectID  `bson:"suscripcion_id" json:"suscripcion_id"`
+	UsuarioID         primitive.ObjectID  `bson:"usuario_id" json:"usuario_id"`
+	PlanAnteriorID    primitive.ObjectID  `bson:"plan_anterior_id" json:"plan_anterior_id"`
+	PlanVersionPrevia int                 `bson:"plan_version_previa,omitempty" json:"plan_version_previa,omitempty"`
+	PrecioPrevio      int64               `bson:"precio_previo,omitempty" json:"precio_previo,omitempty"` // Para deshacer el cambio si no se paga
+	PlanNuevoID       primitive.ObjectID  `bson:"plan_nuevo_id" json:"plan_nuevo_id"`
+	PlanVersionNueva  int                 `bson:"plan_version_nueva,omitempty" json:"plan_version_nueva,omitempty"`
+	Modo              string              `bson:"modo" json:"modo"` // inmediato, fin_periodo
+	DiasRestantes     int                 `bson:"dias_restantes" json:"dias_restantes"`
+	Moneda            string              `bson:"moneda" json:"moneda"`
+	PrecioNuevo       int64               `bson:"precio_nuevo" json:"precio_nuevo"` // Precio por periodo del plan nuevo en Moneda, por todos los asientos
+	Credito           int64               `bson:"credito" json:"credito"`
+	Cargo             int64               `bson:"cargo" json:"cargo"`
+	MontoNeto         int64               `bson:"monto_neto" json:"monto_neto"`
+	FacturaID         *primitive.ObjectID `bson:"factura_id,omitempty" json:"factura_id,omitempty"` // Factura del cargo neto de un cambio a un plan más caro
+	FechaEfectiva     time.Time           `bson:"fecha_efectiva" json:"fecha_efectiva"`
+	CreadoEn          time.Time           `bson:"creado_en" json:"creado_en"`
+}
+
+const (
+	ModoCambioInmediato  = "inmediato"
+	ModoCambioFinPeriodo = "fin_periodo"
+)
+
+// Los modos de cancelación usan los mismos valores que los de cambio de plan.
+const (
+	ModoCancelacionInmediata  = ModoCambioInmediato
+	ModoCancelacionFinPeriodo = ModoCambioFinPeriodo
+)
+
+func (c CambioPlan) GetCollectionName() string {
+	return "cambios_plan"
+}
+
+func (c CambioPlan) GetID() string {
+	return c.ID.Hex()
+}
+
+func (c CambioPlan) IsUpgrade() bool {
+	return c.MontoNeto > 0
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CambioProgramado es un cambio de plan que se aplica de inmediato en una
+// fecha elegida, con el mismo prorrateo que un cambio inmediato. Lo aplica la
+// tarea aplicar_cambios_programados.
+type CambioProgramado struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	SuscripcionID primitive.ObjectID  `bson:"suscripcion_id" json:"suscripcion_id"`
+	UsuarioID     primitive.ObjectID  `bson:"usuario_id" json:"usuario_id"`
+	PlanNuevoID   primitive.ObjectID  `bson:"plan_nuevo_id" json:"plan_nuevo_id"`
+	FechaEfectiva time.Time           `bson:"fecha_efectiva" json:"fecha_efectiva"`
+	Estado        string              `bson:"estado" json:"estado"`
+	CreadoPor     string              `bson:"creado_por" json:"creado_por"`
+	CambioPlanID  *primitive.ObjectID `bson:"cambio_plan_id,omitempty" json:"cambio_plan_id,omitempty"` // El cambio registrado al aplicarse
+	AplicadoEn    time.Time           `bson:"aplicado_en,omitempty" json:"aplicado_en,omitempty"`
+	CanceladoPor  string              `bson:"cancelado_por,omitempty" json:"cancelado_por,omitempty"`
+	CanceladoEn   time.Time           `bson:"cancelado_en,omitempty" json:"cancelado_en,omitempty"`
+	Error         string              `bson:"error,omitempty" json:"error,omitempty"` // Por qué no se pudo aplicar
+	CreadoEn      time.Time           `bson:"creado_en" json:"creado_en"`
+}
+
+const (
+	EstadoCambioProgramadoPendiente = "pendiente"
+	EstadoCambioProgramadoAplicado  = "aplicado"
+	EstadoCambioProgramadoCancelado = "cancelado"
+	EstadoCambioProgramadoFallido   = "fallido"
+)
+
+func (c CambioProgramado) GetCollectionName() string {
+	return "cambios_programados"
+}
+
+func (c CambioProgramado) GetID() string {
+	return c.ID.Hex()
+}
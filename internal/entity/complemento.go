@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Complemento es un extra que se contrata encima del plan base, como más
+// almacenamiento o soporte prioritario. Solo se puede agregar a una
+// suscripción de uno de sus planes compatibles.
+type Complemento struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Nombre      string               `bson:"nombre" json:"nombre"`
+	Descripcion string               `bson:"descripcion" json:"descripcion"`
+	Precios     []PrecioPlan         `bson:"precios" json:"precios"` // Por periodo del plan al que se agrega
+	PlanesIDs   []primitive.ObjectID `bson:"planes_ids" json:"planes_ids"`
+	Derechos    []DerechoPlan        `bson:"derechos,omitempty" json:"derechos,omitempty"`
+	Activo      bool                 `bson:"activo" json:"activo"`
+	CreadoEn    time.Time            `bson:"creado_en" json:"creado_en"`
+}
+
+// ComplementoSuscripcion es un complemento contratado. Guarda el precio y los
+// derechos que tenía el complemento al agregarlo o en la última renovación.
+type ComplementoSuscripcion struct {
+	ComplementoID primitive.ObjectID `bson:"complemento_id" json:"complemento_id"`
+	Nombre        string             `bson:"nombre" json:"nombre"`
+	Precio        int64              `bson:"precio" json:"precio"` // En la moneda de la suscripción
+	Derechos      []DerechoPlan      `bson:"derechos,omitempty" json:"derechos,omitempty"`
+	AgregadoEn    time.Time          `bson:"agregado_en" json:"agregado_en"`
+}
+
+func (c Complemento) GetCollectionName() string {
+	return "complementos"
+}
+
+func (c Complemento) PrecioEn(moneda string) (int64, bool) {
+	for _, precio := range c.Precios {
+		if precio.Moneda == moneda {
+			return precio.Monto, true
+		}
+	}
+	return 0, false
+}
+
+func (c Complemento) CompatibleCon(planID primitive.ObjectID) bool {
+	for _, id := range c.PlanesIDs {
+		if id == planID {
+			return true
+		}
+	}
+	return false
+}
+
+// ConComplementos devuelve una copia del plan con los derechos de los
+// complementos sumados a los suyos: un booleano queda habilitado si alguno lo
+// habilita y los límites se suman, salvo que alguno sea ilimitado. Un derecho
+// que el plan declara con otro tipo conserva el del plan.
+func (p PlanSuscripcion) ConComplementos(complementos []ComplementoSuscripcion) *PlanSuscripcion {
+	if len(complementos) == 0 {
+		return &p
+	}
+
+	derechos := append([]DerechoPlan(nil), p.Derechos...)
+	indice := make(map[string]int, len(derechos))
+	for i, derecho := range derechos {
+		indice[derecho.Clave] = i
+	}
+
+	for _, complemento := range complementos {
+		for _, extra := range complemento.Derechos {
+			i, ok := indice[extra.Clave]
+			if !ok {
+				indice[extra.Clave] = len(derechos)
+				derechos = append(derechos, extra)
+				continue
+			}
+
+			derecho := &derechos[i]
+			if derecho.Tipo != extra.Tipo {
+				continue
+			}
+			if derecho.Tipo == TipoDerechoBooleano {
+				derecho.Habilitado = derecho.Habilitado || extra.Habilitado
+			} else if derecho.Limite == LimiteIlimitado || extra.Limite == LimiteIlimitado {
+				derecho.Limite = LimiteIlimitado
+			} else {
+				derecho.Limite += extra.Limite
+			}
+		}
+	}
+
+	p.Derechos = derechos
+	return &p
+}
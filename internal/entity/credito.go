@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MovimientoCredito es una entrada del libro de crédito de un usuario, el
+// dinero que se le debe en una moneda. Los montos no se editan: una corrección
+// es otra entrada. Saldo es el saldo después de esta entrada, así
+// que el saldo actual es el de la última.
+type MovimientoCredito struct {
+	ID            string              `bson:"_id" json:"id"`
+	UsuarioID     primitive.ObjectID  `bson:"usuario_id" json:"usuario_id"`
+	Moneda        string              `bson:"moneda" json:"moneda"`
+	Secuencia     int64               `bson:"secuencia" json:"secuencia"`
+	Monto         int64               `bson:"monto" json:"monto"` // Positivo suma crédito, negativo lo consume
+	Saldo         int64               `bson:"saldo" json:"saldo"`
+	Motivo        string              `bson:"motivo" json:"motivo"`
+	SuscripcionID *primitive.ObjectID `bson:"suscripcion_id,omitempty" json:"suscripcion_id,omitempty"`
+	FacturaID     *primitive.ObjectID `bson:"factura_id,omitempty" json:"factura_id,omitempty"`
+	ReembolsoID   string              `bson:"reembolso_id,omitempty" json:"reembolso_id,omitempty"` // ID del reembolso en la pasarela
+	Nota          string              `bson:"nota,omitempty" json:"nota,omitempty"`
+	Actor         string              `bson:"actor" json:"actor"`
+	CreadoEn      time.Time           `bson:"creado_en" json:"creado_en"`
+}
+
+const (
+	MotivoCreditoCambioPlan         = "cambio_plan"
+	MotivoCreditoCancelacion        = "cancelacion"
+	MotivoCreditoAsientos           = "asientos"
+	MotivoCreditoAjuste             = "ajuste"
+	MotivoCreditoFactura            = "aplicado_factura"
+	MotivoCreditoFacturaAnulada     = "factura_anulada"
+	MotivoCreditoReembolso          = "reembolso"
+	MotivoCreditoReembolsoRevertido = "reembolso_revertido"
+)
+
+// MovimientoCreditoID es determinista para que dos movimientos simultáneos del
+// mismo usuario y moneda no puedan tomar la misma secuencia.
+func MovimientoCreditoID(usuarioID primitive.ObjectID, moneda string, secuencia int64) string {
+	return fmt.Sprintf("%s:%s:%d", usuarioID.Hex(), moneda, secuencia)
+}
+
+func (m MovimientoCredito) GetCollectionName() string {
+	return "movimientos_credito"
+}
+
+func (m MovimientoCredito) GetID() string {
+	return m.ID
+}
@@ -0,0 +1,131 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Cupon struct {
+	ID                  primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Codigo              string               `bson:"codigo" json:"codigo"`
+	Descripcion         string               `bson:"descripcion" json:"descripcion"`
+	Tipo                string               `bson:"tipo" json:"tipo"`                         // porcentaje, monto_fijo
+	Valor               int64                `bson:"valor" json:"valor"`                       // Porcentaje entero o monto en unidad menor
+	Moneda              string               `bson:"moneda,omitempty" json:"moneda,omitempty"` // Solo para monto_fijo
+	Duracion            string               `bson:"duracion" json:"duracion"`                 // una_vez, repetido, siempre
+	DuracionPeriodos    int                  `bson:"duracion_periodos,omitempty" json:"duracion_periodos,omitempty"`
+	ValidoDesde         time.Time            `bson:"valido_desde,omitempty" json:"valido_desde,omitempty"`
+	ValidoHasta         time.Time            `bson:"valido_hasta,omitempty" json:"valido_hasta,omitempty"`
+	MaxCanjes           int                  `bson:"max_canjes" json:"max_canjes"` // 0 = sin límite
+	MaxCanjesPorUsuario int                  `bson:"max_canjes_por_usuario" json:"max_canjes_por_usuario"`
+	Canjes              int                  `bson:"canjes" json:"canjes"`
+	PlanesIDs           []primitive.ObjectID `bson:"planes_ids,omitempty" json:"planes_ids,omitempty"` // Vacío = todos los planes
+	Activo              bool                 `bson:"activo" json:"activo"`
+	CreadoEn            time.Time            `bson:"creado_en" json:"creado_en"`
+}
+
+// CanjeCupon registra cada uso de un cupón.
+type CanjeCupon struct {
+	ID            string             `bson:"_id" json:"id"`
+	CuponID       primitive.ObjectID `bson:"cupon_id" json:"cupon_id"`
+	UsuarioID     primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
+	SuscripcionID primitive.ObjectID `bson:"suscripcion_id" json:"suscripcion_id"`
+	Descuento     int64              `bson:"descuento" json:"descuento"`
+	Moneda        string             `bson:"moneda" json:"moneda"`
+	CreadoEn      time.Time          `bson:"creado_en" json:"creado_en"`
+}
+
+// DescuentoAplicado es la copia del cupón que queda en la suscripción, para
+// que editar o desactivar el cupón no cambie lo que ya se otorgó.
+type DescuentoAplicado struct {
+	CuponID           primitive.ObjectID `bson:"cupon_id" json:"cupon_id"`
+	Codigo            string             `bson:"codigo" json:"codigo"`
+	Tipo              string             `bson:"tipo" json:"tipo"`
+	Valor             int64              `bson:"valor" json:"valor"`
+	Moneda            string             `bson:"moneda,omitempty" json:"moneda,omitempty"`
+	Duracion          string             `bson:"duracion" json:"duracion"`
+	PeriodosRestantes int                `bson:"periodos_restantes" json:"periodos_restantes"` // Incluye el periodo en curso; no aplica a siempre
+}
+
+const (
+	TipoCuponPorcentaje = "porcentaje"
+	TipoCuponMontoFijo  = "monto_fijo"
+)
+
+const (
+	DuracionCuponUnaVez   = "una_vez"
+	DuracionCuponRepetido = "repetido"
+	DuracionCuponSiempre  = "siempre"
+)
+
+func (c Cupon) GetCollectionName() string {
+	return "cupones"
+}
+
+func (c Cupon) GetID() string {
+	return c.ID.Hex()
+}
+
+func (c Cupon) IsVigente(now time.Time) bool {
+	if !c.ValidoDesde.IsZero() && now.Before(c.ValidoDesde) {
+		return false
+	}
+	if !c.ValidoHasta.IsZero() && !now.Before(c.ValidoHasta) {
+		return false
+	}
+	return true
+}
+
+// AplicaAMoneda es siempre cierto para porcentajes; un monto fijo solo se
+// puede descontar de precios en su misma moneda.
+func (c Cupon) AplicaAMoneda(moneda string) bool {
+	return c.Tipo != TipoCuponMontoFijo || c.Moneda == moneda
+}
+
+func (c Cupon) AplicaAPlan(planID primitive.ObjectID) bool {
+	if len(c.PlanesIDs) == 0 {
+		return true
+	}
+	for _, id := range c.PlanesIDs {
+		if id == planID {
+			return true
+		}
+	}
+	return false
+}
+
+// Periodos devuelve cuántos periodos de facturación cubre el descuento.
+func (c Cupon) Periodos() int {
+	switch c.Duracion {
+	case DuracionCuponRepetido:
+		return c.DuracionPeriodos
+	case DuracionCuponSiempre:
+		return 0
+	default:
+		return 1
+	}
+}
+
+func (c CanjeCupon) GetCollectionName() string {
+	return "canjes_cupon"
+}
+
+// CanjesCuponUsuario cuenta los canjes de un usuario en un cupón. Su _id
+// combina cupón y usuario, así el límite por usuario se aplica con un $inc
+// condicional sobre un único documento aunque lleguen dos solicitudes a la vez.
+type CanjesCuponUsuario struct {
+	ID        string             `bson:"_id" json:"id"`
+	CuponID   primitive.ObjectID `bson:"cupon_id" json:"cupon_id"`
+	UsuarioID primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
+	Canjes    int                `bson:"canjes" json:"canjes"`
+}
+
+func (c CanjesCuponUsuario) GetCollectionName() string {
+	return "canjes_cupon_usuario"
+}
+
+func CanjesCuponUsuarioID(cuponID, usuarioID primitive.ObjectID) string {
+	return fmt.Sprintf("%s:%s", cuponID.Hex(), usuarioID.Hex())
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// EventoPago registra cada webhook de la pasarela por su ID, para procesar
+// una sola vez los eventos que el proveedor reenvía.
+type EventoPago struct {
+	ID         string    `bson:"_id" json:"id"`
+	Tipo       string    `bson:"tipo" json:"tipo"`
+	CobroID    string    `bson:"cobro_id" json:"cobro_id"`
+	Estado     string    `bson:"estado" json:"estado"` // procesando, procesado
+	RecibidoEn time.Time `bson:"recibido_en" json:"recibido_en"`
+	// ReservadoHasta vence la reserva de un proceso que se cayó a mitad, para
+	// que el reintento de la pasarela pueda volver a tomarla
+	ReservadoHasta time.Time `bson:"reservado_hasta,omitempty" json:"reservado_hasta,omitempty"`
+	ProcesadoEn    time.Time `bson:"procesado_en,omitempty" json:"procesado_en,omitempty"`
+}
+
+const (
+	EstadoEventoPagoProcesando = "procesando"
+	EstadoEventoPagoProcesado  = "procesado"
+)
+
+func (e EventoPago) GetCollectionName() string {
+	return "eventos_pago"
+}
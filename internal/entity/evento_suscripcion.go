@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventoSuscripcion es una entrada del historial de una suscripción. Antes y
+// Despues guardan solo los campos que cambiaron.
+type EventoSuscripcion struct {
+	ID            primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	SuscripcionID primitive.ObjectID     `bson:"suscripcion_id" json:"suscripcion_id"`
+	UsuarioID     primitive.ObjectID     `bson:"usuario_id" json:"usuario_id"`
+	Tipo          string                 `bson:"tipo" json:"tipo"`
+	Actor         string                 `bson:"actor" json:"actor"`
+	Antes         map[string]interface{} `bson:"antes,omitempty" json:"antes,omitempty"`
+	Despues       map[string]interface{} `bson:"despues,omitempty" json:"despues,omitempty"`
+	CreadoEn      time.Time              `bson:"creado_en" json:"creado_en"`
+}
+
+const (
+	EventoSuscripcionCreada                = "creada"
+	EventoSuscripcionRenovada              = "renovada"
+	EventoSuscripcionPruebaFinalizada      = "prueba_finalizada"
+	EventoSuscripcionPlanCambiado          = "plan_cambiado"
+	EventoSuscripcionCambioProgramado      = "cambio_plan_programado"
+	EventoSuscripcionPausada               = "pausada"
+	EventoSuscripcionReanudada             = "reanudada"
+	EventoSuscripcionCancelada             = "cancelada"
+	EventoSuscripcionCancelacionProgramada = "cancelacion_programada"
+	EventoSuscripcionCancelacionDeshecha   = "cancelacion_deshecha"
+	EventoSuscripcionVencida               = "vencida"
+	EventoSuscripcionAutoRenovacion        = "auto_renovacion_actualizada"
+	EventoSuscripcionEdicionManual         = "edicion_manual"
+	EventoSuscripcionPagoConfirmado        = "pago_confirmado"
+	EventoSuscripcionPagoRechazado         = "pago_rechazado"
+	EventoSuscripcionVersionMigrada        = "version_plan_migrada"
+	EventoSuscripcionAsientosCambiados     = "asientos_cambiados"
+	EventoSuscripcionAsientoAsignado       = "asiento_asignado"
+	EventoSuscripcionAsientoLiberado       = "asiento_liberado"
+	EventoSuscripcionComplementoAgregado   = "complemento_agregado"
+	EventoSuscripcionComplementoQuitado    = "complemento_quitado"
+	EventoSuscripcionRegaloCanjeado        = "regalo_canjeado"
+	EventoSuscripcionCambioAgendado        = "cambio_plan_agendado"
+	EventoSuscripcionCambioDesagendado     = "cambio_plan_agendado_cancelado"
+)
+
+func (e EventoSuscripcion) GetCollectionName() string {
+	return "suscripcion_eventos"
+}
+
+func (e EventoSuscripcion) GetID() string {
+	return e.ID.Hex()
+}
@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Factura guarda lo que se le cobró al usuario por un periodo. Los montos, en
+// la unidad menor de Moneda, se copian de la suscripción y del descuento al
+// generarla, así que editar el plan después no cambia facturas ya emitidas.
+type Factura struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Numero          int64               `bson:"numero,omitempty" json:"numero,omitempty"` // Se asigna al emitir; los borradores no tienen
+	SuscripcionID   primitive.ObjectID  `bson:"suscripcion_id" json:"suscripcion_id"`     // Vacío en la compra de un regalo
+	RegaloID        *primitive.ObjectID `bson:"regalo_id,omitempty" json:"regalo_id,omitempty"`
+	UsuarioID       primitive.ObjectID  `bson:"usuario_id" json:"usuario_id"`
+	Motivo          string              `bson:"motivo" json:"motivo"` // alta, renovacion, asientos, complemento, regalo
+	Estado          string              `bson:"estado" json:"estado"` // borrador, abierta, pagada, anulada
+	Moneda          string              `bson:"moneda" json:"moneda"`
+	Lineas          []LineaFactura      `bson:"lineas" json:"lineas"`
+	Subtotal        int64               `bson:"subtotal" json:"subtotal"`
+	Descuento       int64               `bson:"descuento" json:"descuento"`
+	Impuesto        int64               `bson:"impuesto" json:"impuesto"` // Incluido en Subtotal si el desglose lo indica
+	Desglose        *DesgloseImpuesto   `bson:"desglose_impuesto,omitempty" json:"desglose_impuesto,omitempty"`
+	CreditoAplicado int64               `bson:"credito_aplicado,omitempty" json:"credito_aplicado,omitempty"` // Crédito a favor descontado del total
+	Total           int64               `bson:"total" json:"total"`
+	Reembolsado     int64               `bson:"reembolsado,omitempty" json:"reembolsado,omitempty"` // Suma de los reembolsos del cobro; nunca pasa de Total
+	CobroID         string              `bson:"cobro_id,omitempty" json:"cobro_id,omitempty"`
+	PeriodoInicio   time.Time           `bson:"periodo_inicio" json:"periodo_inicio"`
+	PeriodoFin      time.Time           `bson:"periodo_fin" json:"periodo_fin"`
+	EmitidaEn       time.Time           `bson:"emitida_en,omitempty" json:"emitida_en,omitempty"`
+	PagadaEn        time.Time           `bson:"pagada_en,omitempty" json:"pagada_en,omitempty"`
+	AnuladaEn       time.Time           `bson:"anulada_en,omitempty" json:"anulada_en,omitempty"`
+	CreadoEn        time.Time           `bson:"creado_en" json:"creado_en"`
+}
+
+type LineaFactura struct {
+	Tipo           string              `bson:"tipo" json:"tipo"` // plan, asientos, complemento, descuento, impuesto, credito
+	Descripcion    string              `bson:"descripcion" json:"descripcion"`
+	Cantidad       int                 `bson:"cantidad" json:"cantidad"`
+	PrecioUnitario int64               `bson:"precio_unitario" json:"precio_unitario"`
+	Monto          int64               `bson:"monto" json:"monto"`                                       // Negativo para descuentos y crédito
+	ComplementoID  *primitive.ObjectID `bson:"complemento_id,omitempty" json:"complemento_id,omitempty"` // En las líneas de complemento
+}
+
+// Contador es una secuencia atómica; su _id es el nombre de la secuencia.
+type Contador struct {
+	Nombre string `bson:"_id" json:"nombre"`
+	Valor  int64  `bson:"valor" json:"valor"`
+	// Pendiente es el último valor entregado mientras su documento todavía no
+	// lo guardó. Hasta que se confirme no se entrega otro, así un proceso que
+	// se cae a mitad no deja un hueco: el siguiente termina su asignación.
+	Pendiente *AsignacionContador `bson:"pendiente,omitempty" json:"pendiente,omitempty"`
+}
+
+type AsignacionContador struct {
+	Valor       int64              `bson:"valor" json:"valor"`
+	DocumentoID primitive.ObjectID `bson:"documento_id" json:"documento_id"`
+}
+
+const (
+	EstadoFacturaBorrador = "borrador"
+	EstadoFacturaAbierta  = "abierta"
+	EstadoFacturaPagada   = "pagada"
+	EstadoFacturaAnulada  = "anulada"
+)
+
+const (
+	MotivoFacturaAlta        = "alta"
+	MotivoFacturaRenovacion  = "renovacion"
+	MotivoFacturaAsientos    = "asientos"
+	MotivoFacturaComplemento = "complemento"
+	MotivoFacturaRegalo      = "regalo"
+	MotivoFacturaCambioPlan  = "cambio_plan"
+)
+
+const (
+	LineaFacturaPlan        = "plan"
+	LineaFacturaAsientos    = "asientos"
+	LineaFacturaComplemento = "complemento"
+	LineaFacturaDescuento   = "descuento"
+	LineaFacturaImpuesto    = "impuesto"
+	LineaFacturaCredito     = "credito"
+)
+
+const ContadorFacturas = "facturas"
+
+func (f Factura) GetCollectionName() string {
+	return "facturas"
+}
+
+func (f Factura) GetID() string {
+	return f.ID.Hex()
+}
+
+// Codigo es el número con el que se muestra la factura, p. ej. F-000042.
+func (f Factura) Codigo() string {
+	if f.Numero == 0 {
+		return ""
+	}
+	return fmt.Sprintf("F-%06d", f.Numero)
+}
+
+func (c Contador) GetCollectionName() string {
+	return "contadores"
+}
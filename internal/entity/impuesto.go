@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TasaImpuesto es el impuesto que se cobra en un país o, si Region no está
+// vacío, en una región de ese país. La tasa de la región reemplaza a la del
+// país para los usuarios que facturan ahí.
+type TasaImpuesto struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Pais       string             `bson:"pais" json:"pais"`                         // ISO 3166-1 alfa-2
+	Region     string             `bson:"region,omitempty" json:"region,omitempty"` // Vacío = todo el país
+	Nombre     string             `bson:"nombre" json:"nombre"`                     // IVA, VAT, sales tax...
+	Porcentaje float64            `bson:"porcentaje" json:"porcentaje"`
+	Activo     bool               `bson:"activo" json:"activo"`
+	CreadoEn   time.Time          `bson:"creado_en" json:"creado_en"`
+}
+
+func (t TasaImpuesto) GetCollectionName() string {
+	return "tasas_impuesto"
+}
+
+// Calcular desglosa el impuesto de monto. Si el precio ya incluye el impuesto
+// la base se obtiene quitándolo; si no, el impuesto se suma sobre monto.
+func (t TasaImpuesto) Calcular(monto int64, incluido bool) DesgloseImpuesto {
+	desglose := DesgloseImpuesto{
+		TasaID:     t.ID,
+		Nombre:     t.Nombre,
+		Pais:       t.Pais,
+		Region:     t.Region,
+		Porcentaje: t.Porcentaje,
+		Incluido:   incluido,
+		Base:       monto,
+	}
+
+	if incluido {
+		desglose.Base = int64(math.Round(float64(monto) * 100 / (100 + t.Porcentaje)))
+		desglose.Monto = monto - desglose.Base
+	} else {
+		desglose.Monto = int64(math.Round(float64(monto) * t.Porcentaje / 100))
+	}
+
+	return desglose
+}
+
+// DesgloseImpuesto es la copia del impuesto aplicado a un cobro. Base y Monto
+// están en la unidad menor de la moneda del cobro.
+type DesgloseImpuesto struct {
+	TasaID     primitive.ObjectID `bson:"tasa_id" json:"tasa_id"`
+	Nombre     string             `bson:"nombre" json:"nombre"`
+	Pais       string             `bson:"pais" json:"pais"`
+	Region     string             `bson:"region,omitempty" json:"region,omitempty"`
+	Porcentaje float64            `bson:"porcentaje" json:"porcentaje"`
+	Incluido   bool               `bson:"incluido" json:"incluido"`
+	Base       int64              `bson:"base" json:"base"`
+	Monto      int64              `bson:"monto" json:"monto"`
+}
+
+// Total es lo que paga el usuario: la base más el impuesto.
+func (d DesgloseImpuesto) Total() int64 {
+	return d.Base + d.Monto
+}
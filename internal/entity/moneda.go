@@ -0,0 +1,45 @@
+package entity
+
+import "math"
+
+// decimalesMoneda lista las monedas ISO 4217 aceptadas y cuántos decimales
+// tiene cada una. Los montos se guardan como enteros en la unidad menor
+// (centavos para BOB o USD, unidades para CLP o PYG).
+var decimalesMoneda = map[string]int{
+	"ARS": 2,
+	"BOB": 2,
+	"BRL": 2,
+	"CLP": 0,
+	"COP": 2,
+	"EUR": 2,
+	"MXN": 2,
+	"PEN": 2,
+	"PYG": 0,
+	"USD": 2,
+	"UYU": 2,
+}
+
+// MonedaLegacy es la moneda de los planes y suscripciones guardados cuando el
+// precio era un float64 sin moneda.
+const MonedaLegacy = "BOB"
+
+// PrecioPlan es el precio de un periodo del plan en una moneda.
+type PrecioPlan struct {
+	Moneda string `bson:"moneda" json:"moneda"`
+	Monto  int64  `bson:"monto" json:"monto"` // Unidad menor de la moneda
+}
+
+func MonedaValida(codigo string) bool {
+	_, ok := decimalesMoneda[codigo]
+	return ok
+}
+
+func DecimalesMoneda(codigo string) int {
+	return decimalesMoneda[codigo]
+}
+
+// MontoDesdeDecimal convierte un monto en unidades mayores a la unidad menor
+// de la moneda, redondeando al entero más cercano.
+func MontoDesdeDecimal(monto float64, moneda string) int64 {
+	return int64(math.Round(monto * math.Pow10(DecimalesMoneda(moneda))))
+}
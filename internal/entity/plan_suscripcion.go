@@ -7,12 +7,55 @@ import (
 )
 
 type PlanSuscripcion struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Nombre      string             `bson:"nombre" json:"nombre"`
-	Descripcion string             `bson:"descripcion" json:"descripcion"`
-	Precio      float64            `bson:"precio" json:"precio"`
-	Activo      bool               `bson:"activo" json:"activo"`
-	CreadoEn    time.Time          `bson:"creado_en" json:"creado_en"`
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Nombre            string             `bson:"nombre" json:"nombre"`
+	Descripcion       string             `bson:"descripcion" json:"descripcion"`
+	Precios           []PrecioPlan       `bson:"precios" json:"precios"`
+	PrecioLegacy      float64            `bson:"precio,omitempty" json:"-"`                  // Solo en planes anteriores a multi-moneda
+	ImpuestoIncluido  bool               `bson:"impuesto_incluido" json:"impuesto_incluido"` // Los precios ya incluyen el impuesto
+	Intervalo         string             `bson:"intervalo" json:"intervalo"`                 // dia, semana, mes, anio
+	IntervaloCantidad int                `bson:"intervalo_cantidad" json:"intervalo_cantidad"`
+	DiasPrueba        int                `bson:"dias_prueba" json:"dias_prueba"`
+	MaxDiasPausa      int                `bson:"max_dias_pausa" json:"max_dias_pausa"`     // Tope de días en pausa cada 12 meses; 0 no permite pausar
+	PorAsiento        bool               `bson:"por_asiento,omitempty" json:"por_asiento"` // El precio es por asiento y se multiplica por la cantidad
+	MinAsientos       int                `bson:"min_asientos,omitempty" json:"min_asientos"`
+	MaxAsientos       int                `bson:"max_asientos,omitempty" json:"max_asientos"` // 0 = sin máximo
+	Derechos          []DerechoPlan      `bson:"derechos,omitempty" json:"derechos,omitempty"`
+	Version           int                `bson:"version,omitempty" json:"version"` // Versión vigente de precios y derechos
+	Activo            bool               `bson:"activo" json:"activo"`
+	CreadoEn          time.Time          `bson:"creado_en" json:"creado_en"`
+}
+
+const (
+	IntervaloDia    = "dia"
+	IntervaloSemana = "semana"
+	IntervaloMes    = "mes"
+	IntervaloAnio   = "anio"
+)
+
+const (
+	TipoDerechoBooleano = "booleano"
+	TipoDerechoLimite   = "limite"
+
+	LimiteIlimitado int64 = -1
+)
+
+// DerechoPlan es algo que el plan le permite hacer al usuario: una
+// funcionalidad que se habilita o un límite numérico como max_proyectos.
+type DerechoPlan struct {
+	Clave      string `bson:"clave" json:"clave"`
+	Tipo       string `bson:"tipo" json:"tipo"` // booleano, limite
+	Habilitado bool   `bson:"habilitado,omitempty" json:"habilitado,omitempty"`
+	Limite     int64  `bson:"limite,omitempty" json:"limite,omitempty"` // LimiteIlimitado = sin límite
+}
+
+// Valor es lo que ven los demás servicios: un bool para las funcionalidades y
+// un número para los límites.
+func (d DerechoPlan) Valor() interface{} {
+	if d.Tipo == TipoDerechoLimite {
+		return d.Limite
+	}
+	return d.Habilitado
 }
 
 func (p PlanSuscripcion) GetCollectionName() string {
@@ -23,6 +66,114 @@ func (p PlanSuscripcion) IsActive() bool {
 	return p.Activo
 }
 
+// ListaPrecios devuelve los precios del plan; los planes antiguos que solo
+// tienen precio decimal se leen como un único precio en MonedaLegacy.
+func (p PlanSuscripcion) ListaPrecios() []PrecioPlan {
+	if len(p.Precios) == 0 && p.PrecioLegacy > 0 {
+		return []PrecioPlan{{
+			Moneda: MonedaLegacy,
+			Monto:  MontoDesdeDecimal(p.PrecioLegacy, MonedaLegacy),
+		}}
+	}
+	return p.Precios
+}
+
+func (p PlanSuscripcion) PrecioEn(moneda string) (int64, bool) {
+	for _, precio := range p.ListaPrecios() {
+		if precio.Moneda == moneda {
+			return precio.Monto, true
+		}
+	}
+	return 0, false
+}
+
+// VersionActual tolera planes creados antes del versionado, que están en su
+// primera versión.
+func (p PlanSuscripcion) VersionActual() int {
+	return max(p.Version, 1)
+}
+
+// ConVersion devuelve una copia del plan con los términos de la versión v,
+// que es lo que ve una suscripción que quedó en esa versión.
+func (p PlanSuscripcion) ConVersion(v *VersionPlan) *PlanSuscripcion {
+	p.Version = v.Numero
+	p.Precios = v.Precios
+	p.PrecioLegacy = 0
+	p.ImpuestoIncluido = v.ImpuestoIncluido
+	p.Derechos = v.Derechos
+	return &p
+}
+
+// LimitesAsientos devuelve la cantidad mínima y máxima de asientos que admite
+// el plan; max 0 no tiene tope. Un plan que no es por asiento admite uno solo.
+func (p PlanSuscripcion) LimitesAsientos() (int, int) {
+	if !p.PorAsiento {
+		return 1, 1
+	}
+	return max(p.MinAsientos, 1), p.MaxAsientos
+}
+
+func (p PlanSuscripcion) AdmiteAsientos(cantidad int) bool {
+	minimo, maximo := p.LimitesAsientos()
+	return cantidad >= minimo && (maximo == 0 || cantidad <= maximo)
+}
+
+func (p PlanSuscripcion) HasTrial() bool {
+	return p.DiasPrueba > 0
+}
+
 func (p PlanSuscripcion) GetID() string {
 	return p.ID.Hex()
 }
+
+// PeriodoFacturacion devuelve el intervalo del plan; los planes creados antes
+// de existir el campo se facturan mensualmente.
+func (p PlanSuscripcion) PeriodoFacturacion() (string, int) {
+	intervalo := p.Intervalo
+	if intervalo == "" {
+		intervalo = IntervaloMes
+	}
+
+	cantidad := p.IntervaloCantidad
+	if cantidad < 1 {
+		cantidad = 1
+	}
+
+	return intervalo, cantidad
+}
+
+// CalcularFechaFin suma un periodo de facturación a desde. diaAncla es el día
+// del mes en que empezó la suscripción: en intervalos mensuales y anuales el
+// resultado se ajusta al último día del mes cuando ese día no existe, y vuelve
+// al día ancla en los meses que sí lo tienen (31 ene -> 28 feb -> 31 mar).
+func (p PlanSuscripcion) CalcularFechaFin(desde time.Time, diaAncla int) time.Time {
+	intervalo, cantidad := p.PeriodoFacturacion()
+
+	switch intervalo {
+	case IntervaloDia:
+		return desde.AddDate(0, 0, cantidad)
+	case IntervaloSemana:
+		return desde.AddDate(0, 0, 7*cantidad)
+	case IntervaloAnio:
+		return addMonths(desde, 12*cantidad, diaAncla)
+	default:
+		return addMonths(desde, cantidad, diaAncla)
+	}
+}
+
+func addMonths(t time.Time, months, diaAncla int) time.Time {
+	if diaAncla < 1 {
+		diaAncla = t.Day()
+	}
+
+	// Día 1 para que time.Date no desborde al mes siguiente
+	target := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	lastDay := target.AddDate(0, 1, -1).Day()
+	day := diaAncla
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return target.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecordatorioVencimiento registra un aviso de que una suscripción está por
+// vencer. Se guarda antes de enviar el aviso, y el ID determinista hace que
+// solo una réplica pueda registrarlo.
+type RecordatorioVencimiento struct {
+	ID            string             `bson:"_id" json:"id"`
+	SuscripcionID primitive.ObjectID `bson:"suscripcion_id" json:"suscripcion_id"`
+	UsuarioID     primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
+	FechaFin      time.Time          `bson:"fecha_fin" json:"fecha_fin"`
+	Dias          int                `bson:"dias" json:"dias"` // Ventana que disparó el aviso
+	Estado        string             `bson:"estado" json:"estado"`
+	Canal         string             `bson:"canal" json:"canal"`
+	EnviadoEn     time.Time          `bson:"enviado_en,omitempty" json:"enviado_en,omitempty"`
+	CreadoEn      time.Time          `bson:"creado_en" json:"creado_en"`
+}
+
+func (r RecordatorioVencimiento) GetCollectionName() string {
+	return "recordatorios_vencimiento"
+}
+
+const (
+	EstadoRecordatorioEnviando = "enviando"
+	EstadoRecordatorioEnviado  = "enviado"
+)
+
+// RecordatorioVencimientoID incluye la fecha de fin para que, si la
+// suscripción se extiende, el periodo nuevo tenga sus propios avisos.
+func RecordatorioVencimientoID(suscripcionID primitive.ObjectID, fechaFin time.Time, dias int) string {
+	return fmt.Sprintf("%s:%d:%d", suscripcionID.Hex(), fechaFin.Unix(), dias)
+}
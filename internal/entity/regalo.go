@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Regalo es una suscripción que un usuario le compra a otro. Pagada la compra,
+// queda un código de un solo uso que da Periodos periodos del plan a quien lo
+// canjee, en una suscripción nueva o extendiendo la suya.
+type Regalo struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Codigo        string              `bson:"codigo" json:"codigo"`
+	CompradorID   primitive.ObjectID  `bson:"comprador_id" json:"comprador_id"`
+	PlanID        primitive.ObjectID  `bson:"plan_id" json:"plan_id"`
+	PlanVersion   int                 `bson:"plan_version" json:"plan_version"` // Versión del plan vigente al comprarlo
+	Periodos      int                 `bson:"periodos" json:"periodos"`
+	Moneda        string              `bson:"moneda" json:"moneda"`
+	Precio        int64               `bson:"precio" json:"precio"` // Por periodo, sin impuesto
+	Estado        string              `bson:"estado" json:"estado"` // pendiente_pago, disponible, canjeado, revocado, anulado
+	FacturaID     *primitive.ObjectID `bson:"factura_id,omitempty" json:"factura_id,omitempty"`
+	ExpiraEn      time.Time           `bson:"expira_en" json:"expira_en"`
+	CanjeadoPor   *primitive.ObjectID `bson:"canjeado_por,omitempty" json:"canjeado_por,omitempty"`
+	CanjeadoEn    time.Time           `bson:"canjeado_en,omitempty" json:"canjeado_en,omitempty"`
+	SuscripcionID *primitive.ObjectID `bson:"suscripcion_id,omitempty" json:"suscripcion_id,omitempty"` // La creada o extendida al canjearlo
+	RevocadoEn    time.Time           `bson:"revocado_en,omitempty" json:"revocado_en,omitempty"`
+	CreadoEn      time.Time           `bson:"creado_en" json:"creado_en"`
+}
+
+const (
+	EstadoRegaloPendientePago = "pendiente_pago"
+	EstadoRegaloDisponible    = "disponible"
+	EstadoRegaloCanjeado      = "canjeado"
+	EstadoRegaloRevocado      = "revocado"
+	EstadoRegaloAnulado       = "anulado" // El pago de la compra fue rechazado
+
+	// EstadoRegaloExpirado no se guarda: es un regalo disponible cuyo
+	// ExpiraEn ya pasó.
+	EstadoRegaloExpirado = "expirado"
+)
+
+func (r Regalo) GetCollectionName() string {
+	return "regalos"
+}
+
+// EstadoEn devuelve el estado del regalo en now, contando la expiración.
+func (r Regalo) EstadoEn(now time.Time) string {
+	if r.Estado == EstadoRegaloDisponible && !now.Before(r.ExpiraEn) {
+		return EstadoRegaloExpirado
+	}
+	return r.Estado
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Renovacion struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SuscripcionID    primitive.ObjectID `bson:"suscripcion_id" json:"suscripcion_id"`
+	UsuarioID        primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
+	PlanAnteriorID   primitive.ObjectID `bson:"plan_anterior_id" json:"plan_anterior_id"`
+	PlanID           primitive.ObjectID `bson:"plan_id" json:"plan_id"`
+	FechaFinAnterior time.Time          `bson:"fecha_fin_anterior" json:"fecha_fin_anterior"`
+	FechaFinNueva    time.Time          `bson:"fecha_fin_nueva" json:"fecha_fin_nueva"`
+	CreadoEn         time.Time          `bson:"creado_en" json:"creado_en"`
+}
+
+func (r Renovacion) GetCollectionName() string {
+	return "renovaciones"
+}
+
+func (r Renovacion) GetID() string {
+	return r.ID.Hex()
+}
+
+func (r Renovacion) CambioPlan() bool {
+	return r.PlanAnteriorID != r.PlanID
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MRRSuscripcion es lo que aporta una suscripción al ingreso recurrente
+// mensual, en la unidad menor de su moneda. Es float porque normalizar planes
+// anuales o semanales a un mes no da montos enteros; se redondea al sumar.
+type MRRSuscripcion struct {
+	SuscripcionID primitive.ObjectID `bson:"_id" json:"suscripcion_id"`
+	UsuarioID     primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
+	MRR           float64            `bson:"mrr" json:"mrr"`
+}
+
+const (
+	CohortePorRegistro           = "registro"
+	CohortePorPrimeraSuscripcion = "primera_suscripcion"
+)
+
+// CohorteUsuario es un usuario dentro de un reporte de cohortes: Inicio decide
+// su cohorte y Periodos son los tramos en los que tuvo una suscripción.
+type CohorteUsuario struct {
+	UsuarioID primitive.ObjectID   `bson:"_id"`
+	Inicio    time.Time            `bson:"inicio"`
+	Periodos  []PeriodoSuscripcion `bson:"periodos"`
+}
+
+type PeriodoSuscripcion struct {
+	Inicio time.Time `bson:"fecha_inicio"`
+	Fin    time.Time `bson:"fecha_fin"`
+}
+
+// Activo indica si alguno de los periodos cubre el instante en.
+func (u CohorteUsuario) Activo(en time.Time) bool {
+	for _, periodo := range u.Periodos {
+		if !periodo.Inicio.After(en) && periodo.Fin.After(en) {
+			return true
+		}
+	}
+	return false
+}
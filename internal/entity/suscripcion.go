@@ -1,25 +1,85 @@
 package entity
 
 import (
+	"math"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Suscripcion struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UsuarioID   primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
-	PlanID      primitive.ObjectID `bson:"plan_id" json:"plan_id"`
-	FechaInicio time.Time          `bson:"fecha_inicio" json:"fecha_inicio"`
-	FechaFin    time.Time          `bson:"fecha_fin" json:"fecha_fin"`
-	Estado      string             `bson:"estado" json:"estado"` // activa, vencida, cancelada
-	CreadoEn    time.Time          `bson:"creado_en" json:"creado_en"`
+	ID                    primitive.ObjectID       `bson:"_id,omitempty" json:"id"`
+	UsuarioID             primitive.ObjectID       `bson:"usuario_id" json:"usuario_id"`
+	PlanID                primitive.ObjectID       `bson:"plan_id" json:"plan_id"`
+	PlanVersion           int                      `bson:"plan_version,omitempty" json:"plan_version"` // Versión del plan cuyos términos aplican
+	FechaInicio           time.Time                `bson:"fecha_inicio" json:"fecha_inicio"`
+	FechaFin              time.Time                `bson:"fecha_fin" json:"fecha_fin"`
+	InicioPeriodo         time.Time                `bson:"inicio_periodo" json:"inicio_periodo"` // Inicio del periodo de facturación en curso
+	Estado                string                   `bson:"estado" json:"estado"`                 // pendiente_pago, activa, en_prueba, pausada, vencida, cancelada
+	Moneda                string                   `bson:"moneda" json:"moneda"`
+	Precio                int64                    `bson:"precio" json:"precio"`                         // Precio del periodo en curso: el del plan por la cantidad de asientos, sin complementos
+	Cantidad              int                      `bson:"cantidad,omitempty" json:"cantidad"`           // Asientos contratados
+	Asientos              []AsignacionAsiento      `bson:"asientos,omitempty" json:"asientos,omitempty"` // Usuarios que ocupan un asiento
+	Complementos          []ComplementoSuscripcion `bson:"complementos,omitempty" json:"complementos,omitempty"`
+	Impuesto              *DesgloseImpuesto        `bson:"impuesto,omitempty" json:"impuesto,omitempty"` // Impuesto del cobro del periodo en curso
+	AutoRenovar           bool                     `bson:"auto_renovar" json:"auto_renovar"`
+	PlanPendienteID       *primitive.ObjectID      `bson:"plan_pendiente_id,omitempty" json:"plan_pendiente_id,omitempty"`
+	UsoPrueba             bool                     `bson:"uso_prueba" json:"uso_prueba"`
+	FinPrueba             time.Time                `bson:"fin_prueba,omitempty" json:"fin_prueba,omitempty"`
+	PausadaDesde          time.Time                `bson:"pausada_desde,omitempty" json:"pausada_desde,omitempty"`
+	PausadaHasta          time.Time                `bson:"pausada_hasta,omitempty" json:"pausada_hasta,omitempty"`
+	Pausas                []PeriodoPausa           `bson:"pausas,omitempty" json:"pausas,omitempty"`
+	DiaAnclaPausa         int                      `bson:"dia_ancla,omitempty" json:"-"` // Día de renovación después de la última pausa
+	CancelarAlFinal       bool                     `bson:"cancelar_al_final" json:"cancelar_al_final"`
+	AutoRenovarPrevio     *bool                    `bson:"auto_renovar_previo,omitempty" json:"-"` // auto_renovar antes de programar la cancelación
+	CanceladaEn           time.Time                `bson:"cancelada_en,omitempty" json:"cancelada_en,omitempty"`
+	MotivoCancelacion     string                   `bson:"motivo_cancelacion,omitempty" json:"motivo_cancelacion,omitempty"`
+	ComentarioCancelacion string                   `bson:"comentario_cancelacion,omitempty" json:"comentario_cancelacion,omitempty"`
+	Descuento             *DescuentoAplicado       `bson:"descuento,omitempty" json:"descuento,omitempty"`
+	Transiciones          []TransicionEstado       `bson:"transiciones,omitempty" json:"transiciones,omitempty"`
+	// EventosPendientes guarda, por ID, los eventos del historial que se
+	// escribieron junto con el cambio y todavía no se copiaron a su colección
+	EventosPendientes map[string]EventoSuscripcion `bson:"eventos_pendientes,omitempty" json:"-"`
+	CreadoEn          time.Time                    `bson:"creado_en" json:"creado_en"`
 }
 
+type AsignacionAsiento struct {
+	UsuarioID  primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
+	AsignadoEn time.Time          `bson:"asignado_en" json:"asignado_en"`
+}
+
+type PeriodoPausa struct {
+	Inicio time.Time `bson:"inicio" json:"inicio"`
+	Fin    time.Time `bson:"fin" json:"fin"`
+}
+
+// TransicionEstado registra un cambio de estado y quién lo hizo: el ID del
+// usuario autenticado o ActorSistema para las tareas programadas.
+type TransicionEstado struct {
+	Desde string    `bson:"desde" json:"desde"`
+	Hasta string    `bson:"hasta" json:"hasta"`
+	Fecha time.Time `bson:"fecha" json:"fecha"`
+	Actor string    `bson:"actor" json:"actor"`
+}
+
+const (
+	ActorSistema  = "sistema"
+	ActorPasarela = "pasarela_pagos"
+)
+
+// Motivos de cancelación que pone el sistema cuando el alta nunca se pagó.
+const (
+	MotivoCancelacionPagoRechazado    = "pago_rechazado"
+	MotivoCancelacionPagoNoConfirmado = "pago_no_confirmado"
+)
+
 const (
-	EstadoSuscripcionActiva    = "activa"
-	EstadoSuscripcionVencida   = "vencida"
-	EstadoSuscripcionCancelada = "cancelada"
+	EstadoSuscripcionPendientePago = "pendiente_pago"
+	EstadoSuscripcionActiva        = "activa"
+	EstadoSuscripcionEnPrueba      = "en_prueba"
+	EstadoSuscripcionPausada       = "pausada"
+	EstadoSuscripcionVencida       = "vencida"
+	EstadoSuscripcionCancelada     = "cancelada"
 )
 
 func (s Suscripcion) GetCollectionName() string {
@@ -27,13 +87,131 @@ func (s Suscripcion) GetCollectionName() string {
 }
 
 func (s Suscripcion) IsActive() bool {
-	return s.Estado == EstadoSuscripcionActiva && time.Now().Before(s.FechaFin)
+	return (s.Estado == EstadoSuscripcionActiva || s.Estado == EstadoSuscripcionEnPrueba) && time.Now().Before(s.FechaFin)
+}
+
+// IsCancellationScheduled indica que la suscripción sigue vigente pero se
+// cancelará al llegar a fecha_fin.
+func (s Suscripcion) IsCancellationScheduled() bool {
+	return s.CancelarAlFinal && s.IsActive()
+}
+
+func (s Suscripcion) IsPaused() bool {
+	return s.Estado == EstadoSuscripcionPausada
+}
+
+// IsVigente indica que la suscripción da o puede volver a dar derechos: la que
+// espera el pago, la activa, la de prueba y la pausada.
+func (s Suscripcion) IsVigente() bool {
+	switch s.Estado {
+	case EstadoSuscripcionPausada:
+		return true
+	case EstadoSuscripcionPendientePago, EstadoSuscripcionActiva, EstadoSuscripcionEnPrueba:
+		return time.Now().Before(s.FechaFin)
+	}
+	return false
+}
+
+// GetMoneda devuelve MonedaLegacy para suscripciones creadas antes de guardar
+// la moneda.
+func (s Suscripcion) GetMoneda() string {
+	if s.Moneda == "" {
+		return MonedaLegacy
+	}
+	return s.Moneda
+}
+
+// GetPlanVersion devuelve 1 para suscripciones creadas antes del versionado
+// de planes.
+func (s Suscripcion) GetPlanVersion() int {
+	return max(s.PlanVersion, 1)
+}
+
+// GetCantidad devuelve 1 para suscripciones creadas antes de existir los
+// asientos.
+func (s Suscripcion) GetCantidad() int {
+	return max(s.Cantidad, 1)
+}
+
+func (s Suscripcion) TieneAsiento(usuarioID primitive.ObjectID) bool {
+	for _, asiento := range s.Asientos {
+		if asiento.UsuarioID == usuarioID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Suscripcion) TieneComplemento(complementoID primitive.ObjectID) bool {
+	for _, complemento := range s.Complementos {
+		if complemento.ComplementoID == complementoID {
+			return true
+		}
+	}
+	return false
+}
+
+// PrecioComplementos es lo que suman los complementos por periodo, aparte del
+// precio del plan.
+func PrecioComplementos(complementos []ComplementoSuscripcion) int64 {
+	var total int64
+	for _, complemento := range complementos {
+		total += complemento.Precio
+	}
+	return total
+}
+
+func (s Suscripcion) IsTrial() bool {
+	return s.Estado == EstadoSuscripcionEnPrueba
+}
+
+// DiasPausados suma los días en pausa desde la fecha indicada, contando
+// también la pausa en curso hasta now.
+func (s Suscripcion) DiasPausados(desde, now time.Time) int {
+	pausas := s.Pausas
+	if !s.PausadaDesde.IsZero() {
+		pausas = append(pausas[:len(pausas):len(pausas)], PeriodoPausa{Inicio: s.PausadaDesde, Fin: now})
+	}
+
+	var total time.Duration
+	for _, pausa := range pausas {
+		inicio := pausa.Inicio
+		if inicio.Before(desde) {
+			inicio = desde
+		}
+		if pausa.Fin.After(inicio) {
+			total += pausa.Fin.Sub(inicio)
+		}
+	}
+	return int(math.Ceil(total.Hours() / 24))
 }
 
 func (s Suscripcion) IsExpired() bool {
 	return time.Now().After(s.FechaFin)
 }
 
+// DiaAncla es el día del mes en que se renueva la suscripción. Si empezó con
+// prueba gratuita, el primer periodo pagado arranca al terminar la prueba. Una
+// pausa corre el ancla para que la renovación conserve los días pausados.
+func (s Suscripcion) DiaAncla() int {
+	if s.DiaAnclaPausa > 0 {
+		return s.DiaAnclaPausa
+	}
+	if !s.FinPrueba.IsZero() {
+		return s.FinPrueba.Day()
+	}
+	return s.FechaInicio.Day()
+}
+
+// InicioPeriodoActual tolera suscripciones creadas antes de guardar
+// inicio_periodo, para las que el periodo empieza en fecha_inicio.
+func (s Suscripcion) InicioPeriodoActual() time.Time {
+	if s.InicioPeriodo.IsZero() {
+		return s.FechaInicio
+	}
+	return s.InicioPeriodo
+}
+
 func (s Suscripcion) GetID() string {
 	return s.ID.Hex()
 }
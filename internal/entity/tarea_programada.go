@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// TareaProgramada guarda el lease de una tarea en segundo plano para que
+// solo una réplica la ejecute a la vez.
+type TareaProgramada struct {
+	Nombre           string    `bson:"_id" json:"nombre"`
+	Titular          string    `bson:"titular" json:"titular"`
+	BloqueadaHasta   time.Time `bson:"bloqueada_hasta" json:"bloqueada_hasta"`
+	ProximaEjecucion time.Time `bson:"proxima_ejecucion" json:"proxima_ejecucion"`
+	UltimaEjecucion  time.Time `bson:"ultima_ejecucion" json:"ultima_ejecucion"`
+	UltimoError      string    `bson:"ultimo_error" json:"ultimo_error"`
+}
+
+func (t TareaProgramada) GetCollectionName() string {
+	return "tareas_programadas"
+}
+
+func (t TareaProgramada) IsLocked() bool {
+	return time.Now().Before(t.BloqueadaHasta)
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EstadoEventoUsoProcesando = "procesando"
+	EstadoEventoUsoRegistrado = "registrado"
+)
+
+// EventoUso es un consumo informado por otro servicio. Su _id combina la
+// suscripción y la clave de idempotencia, así un reintento del mismo evento no
+// se cuenta dos veces.
+type EventoUso struct {
+	ID                string             `bson:"_id" json:"id"`
+	SuscripcionID     primitive.ObjectID `bson:"suscripcion_id" json:"suscripcion_id"`
+	UsuarioID         primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
+	Metrica           string             `bson:"metrica" json:"metrica"`
+	Cantidad          int64              `bson:"cantidad" json:"cantidad"`
+	ClaveIdempotencia string             `bson:"clave_idempotencia" json:"clave_idempotencia"`
+	PeriodoInicio     time.Time          `bson:"periodo_inicio" json:"periodo_inicio"`
+	Estado            string             `bson:"estado" json:"estado"` // procesando, registrado
+	// ReservadoHasta vence la reserva de un proceso que se cayó a mitad, para
+	// que un reintento con la misma clave pueda volver a tomarla
+	ReservadoHasta time.Time `bson:"reservado_hasta,omitempty" json:"-"`
+	OcurridoEn     time.Time `bson:"ocurrido_en" json:"ocurrido_en"`
+	CreadoEn       time.Time `bson:"creado_en" json:"creado_en"`
+}
+
+func (e EventoUso) GetCollectionName() string {
+	return "eventos_uso"
+}
+
+// ReservaVencida indica si el evento quedó en procesando más allá de su
+// reserva.
+func (e EventoUso) ReservaVencida(now time.Time) bool {
+	return e.Estado == EstadoEventoUsoProcesando && !e.ReservadoHasta.After(now)
+}
+
+func EventoUsoID(suscripcionID primitive.ObjectID, clave string) string {
+	return fmt.Sprintf("%s:%s", suscripcionID.Hex(), clave)
+}
+
+// UsoPeriodo acumula el consumo de una métrica en un periodo de facturación.
+// Al renovarse la suscripción cambia el inicio del periodo y el consumo vuelve
+// a empezar en un documento nuevo.
+type UsoPeriodo struct {
+	ID            string             `bson:"_id" json:"id"`
+	SuscripcionID primitive.ObjectID `bson:"suscripcion_id" json:"suscripcion_id"`
+	UsuarioID     primitive.ObjectID `bson:"usuario_id" json:"usuario_id"`
+	Metrica       string             `bson:"metrica" json:"metrica"`
+	PeriodoInicio time.Time          `bson:"periodo_inicio" json:"periodo_inicio"`
+	PeriodoFin    time.Time          `bson:"periodo_fin" json:"periodo_fin"`
+	Consumido     int64              `bson:"consumido" json:"consumido"`
+	ActualizadoEn time.Time          `bson:"actualizado_en" json:"actualizado_en"`
+}
+
+func (u UsoPeriodo) GetCollectionName() string {
+	return "uso_periodos"
+}
+
+func UsoPeriodoID(suscripcionID primitive.ObjectID, metrica string, inicio time.Time) string {
+	return fmt.Sprintf("%s:%s:%d", suscripcionID.Hex(), metrica, inicio.Unix())
+}
+
+// LimiteDe devuelve el límite que el plan declara para la métrica; ok es false
+// si la métrica no es un límite del plan.
+func (p PlanSuscripcion) LimiteDe(metrica string) (int64, bool) {
+	for _, derecho := range p.Derechos {
+		if derecho.Clave == metrica && derecho.Tipo == TipoDerechoLimite {
+			return derecho.Limite, true
+		}
+	}
+	return 0, false
+}
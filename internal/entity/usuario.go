@@ -7,14 +7,16 @@ import (
 )
 
 type Usuario struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Nombre   string             `bson:"nombre" json:"nombre"`
-	Email    string             `bson:"email" json:"email"`
-	Telefono string             `bson:"telefono" json:"telefono"`
-	Password string             `bson:"password" json:"-"`
-	Estado   bool               `bson:"estado" json:"estado"`
-	EsAdmin  bool               `bson:"es_admin" json:"es_admin"`
-	CreadoEn time.Time          `bson:"creado_en" json:"creado_en"`
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Nombre            string             `bson:"nombre" json:"nombre"`
+	Email             string             `bson:"email" json:"email"`
+	Telefono          string             `bson:"telefono" json:"telefono"`
+	Password          string             `bson:"password" json:"-"`
+	Estado            bool               `bson:"estado" json:"estado"`
+	EsAdmin           bool               `bson:"es_admin" json:"es_admin"`
+	PaisFacturacion   string             `bson:"pais_facturacion,omitempty" json:"pais_facturacion,omitempty"` // ISO 3166-1 alfa-2, define el impuesto
+	RegionFacturacion string             `bson:"region_facturacion,omitempty" json:"region_facturacion,omitempty"`
+	CreadoEn          time.Time          `bson:"creado_en" json:"creado_en"`
 }
 
 func (u Usuario) GetCollectionName() string {
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VersionPlan guarda los términos de un plan que quedan fijos para quien se
+// suscribió con ellos: precios, impuesto incluido y derechos. El resto de los
+// campos del plan (nombre, intervalo, prueba) se edita sin crear versión.
+type VersionPlan struct {
+	ID               string             `bson:"_id" json:"id"`
+	PlanID           primitive.ObjectID `bson:"plan_id" json:"plan_id"`
+	Numero           int                `bson:"numero" json:"numero"`
+	Precios          []PrecioPlan       `bson:"precios" json:"precios"`
+	ImpuestoIncluido bool               `bson:"impuesto_incluido" json:"impuesto_incluido"`
+	Derechos         []DerechoPlan      `bson:"derechos,omitempty" json:"derechos,omitempty"`
+	CreadoEn         time.Time          `bson:"creado_en" json:"creado_en"`
+}
+
+func (v VersionPlan) GetCollectionName() string {
+	return "versiones_plan"
+}
+
+// VersionPlanID es determinista para que dos ediciones simultáneas del mismo
+// plan no puedan crear la misma versión.
+func VersionPlanID(planID primitive.ObjectID, numero int) string {
+	return fmt.Sprintf("%s:%d", planID.Hex(), numero)
+}
+
+func (v VersionPlan) PrecioEn(moneda string) (int64, bool) {
+	for _, precio := range v.Precios {
+		if precio.Moneda == moneda {
+			return precio.Monto, true
+		}
+	}
+	return 0, false
+}
+
+// NuevaVersionPlan toma los términos vigentes del plan como versión numero.
+func NuevaVersionPlan(plan *PlanSuscripcion, numero int) *VersionPlan {
+	return &VersionPlan{
+		ID:               VersionPlanID(plan.ID, numero),
+		PlanID:           plan.ID,
+		Numero:           numero,
+		Precios:          plan.ListaPrecios(),
+		ImpuestoIncluido: plan.ImpuestoIncluido,
+		Derechos:         plan.Derechos,
+		CreadoEn:         time.Now(),
+	}
+}
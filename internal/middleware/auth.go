@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"sw2p2go/internal/actor"
 	"sw2p2go/internal/dto"
 
 	"github.com/gin-gonic/gin"
@@ -60,8 +61,12 @@ func (am *AuthMiddleware) JWT() gin.HandlerFunc {
 				return
 			}
 
+			esAdmin, _ := claims["es_admin"].(bool)
+
 			c.Set("user_id", userID)
 			c.Set("user_email", email)
+			c.Set("es_admin", esAdmin)
+			c.Request = c.Request.WithContext(actor.With(c.Request.Context(), userID))
 		} else {
 			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Token inválido", "invalid_claims"))
 			c.Abort()
@@ -72,6 +77,19 @@ func (am *AuthMiddleware) JWT() gin.HandlerFunc {
 	}
 }
 
+// AdminOnly debe usarse después de JWT, ya que depende del claim es_admin.
+func (am *AuthMiddleware) AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("es_admin") {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("Acceso restringido a administradores", "forbidden"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func (am *AuthMiddleware) CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
@@ -0,0 +1,22 @@
+package notification
+
+import "context"
+
+// Canal envía avisos a los usuarios (correo, SMS, push). Los servicios solo
+// hablan con esta interfaz, así agregar o cambiar de proveedor no toca la
+// lógica de negocio.
+type Canal interface {
+	// Nombre identifica el canal en los registros de lo enviado.
+	Nombre() string
+	Enviar(ctx context.Context, mensaje Mensaje) error
+}
+
+type Mensaje struct {
+	Referencia string // ID de lo que origina el aviso, para que el proveedor descarte reenvíos
+	UsuarioID  string
+	Nombre     string
+	Email      string
+	Telefono   string
+	Asunto     string
+	Cuerpo     string
+}
@@ -0,0 +1,23 @@
+package notification
+
+import (
+	"context"
+	"log"
+)
+
+// LogCanal escribe los avisos en el log en vez de enviarlos, para desarrollo
+// local.
+type LogCanal struct{}
+
+func NewLogCanal() *LogCanal {
+	return &LogCanal{}
+}
+
+func (c *LogCanal) Nombre() string {
+	return "log"
+}
+
+func (c *LogCanal) Enviar(ctx context.Context, mensaje Mensaje) error {
+	log.Printf("Aviso %s para %s <%s>: %s", mensaje.Referencia, mensaje.Nombre, mensaje.Email, mensaje.Asunto)
+	return nil
+}
@@ -0,0 +1,109 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// FakeGateway guarda los cobros en memoria para desarrollo local. Con
+// autoConfirmar los cobros salen pagados al crearse; si no, quedan pendientes
+// hasta que se llame a SetEstado y se envíe el webhook correspondiente.
+type FakeGateway struct {
+	autoConfirmar bool
+
+	mu     sync.Mutex
+	cobros map[string]*Cobro
+}
+
+func NewFakeGateway(autoConfirmar bool) *FakeGateway {
+	return &FakeGateway{
+		autoConfirmar: autoConfirmar,
+		cobros:        make(map[string]*Cobro),
+	}
+}
+
+func (g *FakeGateway) CrearCobro(ctx context.Context, req CobroRequest) (*Cobro, error) {
+	id := "fake_cob_" + randomID()
+
+	estado := EstadoCobroPendiente
+	if g.autoConfirmar {
+		estado = EstadoCobroPagado
+	}
+
+	cobro := &Cobro{
+		ID:         id,
+		Referencia: req.Referencia,
+		Monto:      req.Monto,
+		Moneda:     req.Moneda,
+		Estado:     estado,
+		URLPago:    "https://pagos.local/checkout/" + id,
+		CreadoEn:   time.Now(),
+	}
+
+	g.mu.Lock()
+	g.cobros[id] = cobro
+	g.mu.Unlock()
+
+	copia := *cobro
+	return &copia, nil
+}
+
+func (g *FakeGateway) Reembolsar(ctx context.Context, cobroID string, monto int64) (*Reembolso, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cobro, ok := g.cobros[cobroID]
+	if !ok {
+		return nil, ErrCobroNoEncontrado
+	}
+	if cobro.Estado != EstadoCobroPagado || monto <= 0 || monto > cobro.Monto {
+		return nil, ErrReembolsoInvalido
+	}
+
+	cobro.Estado = EstadoCobroReembolsado
+
+	return &Reembolso{
+		ID:       "fake_ree_" + randomID(),
+		CobroID:  cobroID,
+		Monto:    monto,
+		CreadoEn: time.Now(),
+	}, nil
+}
+
+func (g *FakeGateway) ObtenerCobro(ctx context.Context, cobroID string) (*Cobro, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cobro, ok := g.cobros[cobroID]
+	if !ok {
+		return nil, ErrCobroNoEncontrado
+	}
+
+	copia := *cobro
+	return &copia, nil
+}
+
+// SetEstado simula que el proveedor resolvió el cobro.
+func (g *FakeGateway) SetEstado(cobroID, estado string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cobro, ok := g.cobros[cobroID]
+	if !ok {
+		return ErrCobroNoEncontrado
+	}
+
+	cobro.Estado = estado
+	return nil
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))[:24]
+	}
+	return hex.EncodeToString(b)
+}
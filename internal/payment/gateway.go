@@ -0,0 +1,96 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+const (
+	EstadoCobroPendiente   = "pendiente"
+	EstadoCobroPagado      = "pagado"
+	EstadoCobroFallido     = "fallido"
+	EstadoCobroReembolsado = "reembolsado"
+)
+
+const (
+	EventoCobroPagado  = "cobro.pagado"
+	EventoCobroFallido = "cobro.fallido"
+)
+
+var (
+	ErrCobroNoEncontrado = errors.New("cobro no encontrado")
+	ErrReembolsoInvalido = errors.New("el cobro no se puede reembolsar por ese monto")
+)
+
+// Gateway es la pasarela de pagos (PaymentGateway). Los servicios solo hablan
+// con esta interfaz, así cambiar de proveedor no toca la lógica de negocio.
+type Gateway interface {
+	// CrearCobro inicia el cobro. Puede quedar pendiente hasta que el usuario
+	// pague en URLPago, o volver ya pagado si el proveedor cobra en el acto.
+	CrearCobro(ctx context.Context, req CobroRequest) (*Cobro, error)
+	Reembolsar(ctx context.Context, cobroID string, monto int64) (*Reembolso, error)
+	ObtenerCobro(ctx context.Context, cobroID string) (*Cobro, error)
+}
+
+type CobroRequest struct {
+	Referencia  string // ID de la factura
+	UsuarioID   string
+	Monto       int64  // Unidad menor de Moneda
+	Moneda      string // ISO 4217
+	Descripcion string
+}
+
+type Cobro struct {
+	ID         string
+	Referencia string
+	Monto      int64
+	Moneda     string
+	Estado     string
+	URLPago    string
+	CreadoEn   time.Time
+}
+
+type Reembolso struct {
+	ID       string
+	CobroID  string
+	Monto    int64
+	CreadoEn time.Time
+}
+
+// Evento es el cuerpo de los webhooks que envía la pasarela.
+type Evento struct {
+	ID       string    `json:"id"`
+	Tipo     string    `json:"tipo"`
+	CobroID  string    `json:"cobro_id"`
+	CreadoEn time.Time `json:"creado_en"`
+}
+
+// Firmar calcula la firma HMAC-SHA256 en hexadecimal de un webhook.
+func Firmar(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerificarFirma compara en tiempo constante. Sin secreto configurado ningún
+// webhook se acepta.
+func VerificarFirma(secret string, payload []byte, firma string) bool {
+	if secret == "" || firma == "" {
+		return false
+	}
+
+	esperada, err := hex.DecodeString(Firmar(secret, payload))
+	if err != nil {
+		return false
+	}
+	recibida, err := hex.DecodeString(firma)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(esperada, recibida)
+}
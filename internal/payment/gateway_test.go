@@ -0,0 +1,36 @@
+package payment
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerificarFirma(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","tipo":"cobro.pagado","cobro_id":"cob_1"}`)
+	firma := Firmar("secreto", payload)
+
+	tests := []struct {
+		name    string
+		secret  string
+		payload []byte
+		firma   string
+		want    bool
+	}{
+		{"firma correcta", "secreto", payload, firma, true},
+		{"hexadecimal en mayúsculas", "secreto", payload, strings.ToUpper(firma), true},
+		{"otro secreto", "otro", payload, firma, false},
+		{"cuerpo modificado", "secreto", []byte(`{"id":"evt_2"}`), firma, false},
+		{"sin secreto configurado", "", payload, Firmar("", payload), false},
+		{"sin firma", "secreto", payload, "", false},
+		{"firma no hexadecimal", "secreto", payload, "zz" + firma[2:], false},
+		{"firma truncada", "secreto", payload, firma[:len(firma)-2], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerificarFirma(tt.secret, tt.payload, tt.firma); got != tt.want {
+				t.Errorf("VerificarFirma() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TareaVencerSuscripciones     = "vencer_suscripciones"
+	TareaRenovarSuscripciones    = "renovar_suscripciones"
+	TareaProcesarPruebas         = "procesar_pruebas"
+	TareaReanudarPausas          = "reanudar_pausas"
+	TareaAplicarCambiosPlan      = "aplicar_cambios_programados"
+	TareaRecordarVencimientos    = "recordar_vencimientos"
+	TareaCancelarPagosPendientes = "cancelar_pagos_pendientes"
+	TareaPublicarEventos         = "publicar_eventos_pendientes"
+)
+
+var (
+	ErrTareaNoEncontrada = errors.New("tarea no encontrada")
+	ErrTareaEnEjecucion  = errors.New("la tarea se está ejecutando en otra instancia")
+)
+
+// JobFunc ejecuta una tarea y devuelve la cantidad de registros procesados.
+type JobFunc func(ctx context.Context) (int64, error)
+
+type job struct {
+	nombre    string
+	intervalo time.Duration
+	run       JobFunc
+}
+
+// Scheduler ejecuta tareas periódicas. Cada ejecución toma un lease en Mongo,
+// así que con varias réplicas solo una procesa la tarea en cada intervalo.
+type Scheduler struct {
+	tareaRepo repositories.TareaRepository
+	lease     time.Duration
+	titular   string
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(tareaRepo repositories.TareaRepository, lease time.Duration) *Scheduler {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "desconocido"
+	}
+
+	return &Scheduler{
+		tareaRepo: tareaRepo,
+		lease:     lease,
+		titular:   fmt.Sprintf("%s-%s", hostname, primitive.NewObjectID().Hex()),
+		jobs:      make(map[string]*job),
+	}
+}
+
+func (s *Scheduler) Register(nombre string, intervalo time.Duration, run JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[nombre] = &job{
+		nombre:    nombre,
+		intervalo: intervalo,
+		run:       run,
+	}
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+
+	log.Printf("Scheduler iniciado con %d tareas (titular %s)", len(s.jobs), s.titular)
+}
+
+// Stop cancela las tareas en curso y espera a que terminen.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	s.wg.Wait()
+
+	log.Println("Scheduler detenido")
+}
+
+// Trigger ejecuta una tarea de inmediato sin esperar a su próximo intervalo.
+func (s *Scheduler) Trigger(ctx context.Context, nombre string) (int64, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[nombre]
+	s.mu.Unlock()
+
+	if !ok {
+		return 0, ErrTareaNoEncontrada
+	}
+
+	return s.execute(ctx, j, true)
+}
+
+// Status devuelve el estado del lease de cada tarea registrada.
+func (s *Scheduler) Status(ctx context.Context) ([]*entity.TareaProgramada, error) {
+	tareas, err := s.tareaRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	porNombre := make(map[string]*entity.TareaProgramada, len(tareas))
+	for _, tarea := range tareas {
+		porNombre[tarea.Nombre] = tarea
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]*entity.TareaProgramada, 0, len(s.jobs))
+	for nombre := range s.jobs {
+		tarea, ok := porNombre[nombre]
+		if !ok {
+			tarea = &entity.TareaProgramada{Nombre: nombre}
+		}
+		result = append(result, tarea)
+	}
+
+	sort.Slice(result, func(i, k int) bool {
+		return result[i].Nombre < result[k].Nombre
+	})
+
+	return result, nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.intervalo)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.execute(ctx, j, false); err != nil && !errors.Is(err, ErrTareaEnEjecucion) {
+			log.Printf("Error ejecutando tarea %s: %v", j.nombre, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, j *job, force bool) (int64, error) {
+	acquired, err := s.tareaRepo.AcquireLock(ctx, j.nombre, s.titular, s.lease, j.intervalo, force)
+	if err != nil {
+		return 0, err
+	}
+	if !acquired {
+		return 0, ErrTareaEnEjecucion
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, s.lease)
+	defer cancel()
+
+	processed, runErr := j.run(runCtx)
+
+	// El lease se libera con un contexto propio para que un apagado no lo deje tomado
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer releaseCancel()
+
+	if err := s.tareaRepo.ReleaseLock(releaseCtx, j.nombre, s.titular, runErr); err != nil {
+		log.Printf("Error liberando tarea %s: %v", j.nombre, err)
+	}
+
+	if processed > 0 {
+		log.Printf("Tarea %s procesó %d registros", j.nombre, processed)
+	}
+
+	return processed, runErr
+}
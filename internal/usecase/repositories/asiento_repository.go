@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrAsientoOcupado = errors.New("el usuario ya tiene un asiento reservado")
+
+type asientoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAsientoRepository(db *mongo.Database) AsientoRepository {
+	return &asientoRepository{
+		collection: db.Collection("asientos_ocupados"),
+	}
+}
+
+// Ocupar guarda la reserva. Devuelve ErrAsientoOcupado si el usuario ya tiene
+// una.
+func (r *asientoRepository) Ocupar(ctx context.Context, asiento *entity.AsientoOcupado) error {
+	_, err := r.collection.InsertOne(ctx, asiento)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAsientoOcupado
+	}
+	return err
+}
+
+func (r *asientoRepository) GetByUsuario(ctx context.Context, usuarioID primitive.ObjectID) (*entity.AsientoOcupado, error) {
+	var asiento entity.AsientoOcupado
+	err := r.collection.FindOne(ctx, bson.M{"_id": usuarioID}).Decode(&asiento)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &asiento, nil
+}
+
+// Reasignar pasa a asiento la reserva anterior, solo si nadie la cambió desde
+// que se leyó. Devuelve ErrAsientoOcupado si otro la tomó antes.
+func (r *asientoRepository) Reasignar(ctx context.Context, asiento, anterior *entity.AsientoOcupado) error {
+	filter := bson.M{
+		"_id":            anterior.UsuarioID,
+		"suscripcion_id": anterior.SuscripcionID,
+		"ocupado_en":     anterior.OcupadoEn,
+	}
+	update := bson.M{"$set": bson.M{
+		"suscripcion_id": asiento.SuscripcionID,
+		"ocupado_en":     asiento.OcupadoEn,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAsientoOcupado
+	}
+	return nil
+}
+
+// Liberar borra la reserva si todavía es de la suscripción.
+func (r *asientoRepository) Liberar(ctx context.Context, usuarioID, suscripcionID primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": usuarioID, "suscripcion_id": suscripcionID})
+	return err
+}
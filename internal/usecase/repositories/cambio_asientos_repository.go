@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type cambioAsientosRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCambioAsientosRepository(db *mongo.Database) CambioAsientosRepository {
+	return &cambioAsientosRepository{
+		collection: db.Collection("cambios_asientos"),
+	}
+}
+
+func (r *cambioAsientosRepository) Create(ctx context.Context, cambio *entity.CambioAsientos) error {
+	if cambio.ID.IsZero() {
+		cambio.ID = primitive.NewObjectID()
+	}
+	if cambio.CreadoEn.IsZero() {
+		cambio.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, cambio)
+	return err
+}
+
+// GetByFacturaID devuelve el cambio que facturó la factura, o nil si no hay
+// ninguno.
+func (r *cambioAsientosRepository) GetByFacturaID(ctx context.Context, facturaID primitive.ObjectID) (*entity.CambioAsientos, error) {
+	var cambio entity.CambioAsientos
+	err := r.collection.FindOne(ctx, bson.M{"factura_id": facturaID}).Decode(&cambio)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &cambio, nil
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrCambioProgramadoModificado   = errors.New("el cambio programado fue modificado por otro proceso")
+	ErrCambioProgramadoPendiente    = errors.New("la suscripción ya tiene un cambio de plan programado")
+	errCambioProgramadoNoEncontrado = errors.New("cambio programado no encontrado")
+)
+
+type cambioProgramadoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCambioProgramadoRepository(db *mongo.Database) CambioProgramadoRepository {
+	return &cambioProgramadoRepository{
+		collection: db.Collection("cambios_programados"),
+	}
+}
+
+func (r *cambioProgramadoRepository) Create(ctx context.Context, cambio *entity.CambioProgramado) error {
+	if cambio.ID.IsZero() {
+		cambio.ID = primitive.NewObjectID()
+	}
+	if cambio.CreadoEn.IsZero() {
+		cambio.CreadoEn = time.Now()
+	}
+
+	// El índice único de los pendientes rechaza un segundo cambio simultáneo
+	_, err := r.collection.InsertOne(ctx, cambio)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrCambioProgramadoPendiente
+	}
+	return err
+}
+
+func (r *cambioProgramadoRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.CambioProgramado, error) {
+	var cambio entity.CambioProgramado
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&cambio)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errCambioProgramadoNoEncontrado
+		}
+		return nil, err
+	}
+	return &cambio, nil
+}
+
+func (r *cambioProgramadoRepository) GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.CambioProgramado, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"fecha_efectiva": -1})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	return r.find(ctx, filter, opts)
+}
+
+func (r *cambioProgramadoRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+func (r *cambioProgramadoRepository) ExistsPendiente(ctx context.Context, suscripcionID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"suscripcion_id": suscripcionID,
+		"estado":         entity.EstadoCambioProgramadoPendiente,
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetDue devuelve, ordenados por _id y a partir de afterID, los cambios
+// pendientes cuya fecha efectiva ya llegó.
+func (r *cambioProgramadoRepository) GetDue(ctx context.Context, now time.Time, afterID primitive.ObjectID, limit int) ([]*entity.CambioProgramado, error) {
+	filter := bson.M{
+		"estado":         entity.EstadoCambioProgramadoPendiente,
+		"fecha_efectiva": bson.M{"$lte": now},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	return r.find(ctx, filter, opts)
+}
+
+// UpdateIfMatch aplica updates solo si el cambio sigue teniendo los valores de
+// expected; si no devuelve ErrCambioProgramadoModificado.
+func (r *cambioProgramadoRepository) UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}) error {
+	filter := bson.M{"_id": id}
+	for k, v := range expected {
+		filter[k] = v
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrCambioProgramadoModificado
+	}
+
+	return nil
+}
+
+func (r *cambioProgramadoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *cambioProgramadoRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*entity.CambioProgramado, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var cambios []*entity.CambioProgramado
+	for cursor.Next(ctx) {
+		var cambio entity.CambioProgramado
+		if err := cursor.Decode(&cambio); err != nil {
+			continue
+		}
+		cambios = append(cambios, &cambio)
+	}
+
+	return cambios, cursor.Err()
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errComplementoNoEncontrado = errors.New("complemento no encontrado")
+
+type complementoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewComplementoRepository(db *mongo.Database) ComplementoRepository {
+	return &complementoRepository{
+		collection: db.Collection("complementos"),
+	}
+}
+
+func (r *complementoRepository) Create(ctx context.Context, complemento *entity.Complemento) error {
+	if complemento.ID.IsZero() {
+		complemento.ID = primitive.NewObjectID()
+	}
+	if complemento.CreadoEn.IsZero() {
+		complemento.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, complemento)
+	return err
+}
+
+func (r *complementoRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Complemento, error) {
+	var complemento entity.Complemento
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&complemento)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errComplementoNoEncontrado
+		}
+		return nil, err
+	}
+	return &complemento, nil
+}
+
+func (r *complementoRepository) GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Complemento, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"creado_en": -1})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var complementos []*entity.Complemento
+	for cursor.Next(ctx) {
+		var complemento entity.Complemento
+		if err := cursor.Decode(&complemento); err != nil {
+			continue
+		}
+		complementos = append(complementos, &complemento)
+	}
+
+	return complementos, cursor.Err()
+}
+
+func (r *complementoRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+func (r *complementoRepository) Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errComplementoNoEncontrado
+	}
+
+	return nil
+}
+
+// Delete desactiva el complemento: las suscripciones que lo tienen lo
+// conservan hasta su próxima renovación.
+func (r *complementoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.Update(ctx, id, map[string]interface{}{"activo": false})
+}
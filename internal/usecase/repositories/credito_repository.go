@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrSaldoInsuficiente  = errors.New("el saldo de crédito no alcanza")
+	ErrCreditoConcurrente = errors.New("el crédito fue modificado por otro proceso")
+	errMovimientoSinMonto = errors.New("el movimiento de crédito no tiene monto")
+)
+
+const intentosRegistrarCredito = 3
+
+type creditoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCreditoRepository(db *mongo.Database) CreditoRepository {
+	return &creditoRepository{
+		collection: db.Collection("movimientos_credito"),
+	}
+}
+
+// Registrar agrega el movimiento al final del libro del usuario en su moneda,
+// completando secuencia y saldo. Rechaza con ErrSaldoInsuficiente lo que
+// dejaría el saldo negativo. Si otro proceso toma la misma secuencia se
+// reintenta sobre el saldo nuevo.
+func (r *creditoRepository) Registrar(ctx context.Context, movimiento *entity.MovimientoCredito) error {
+	if movimiento.Monto == 0 {
+		return errMovimientoSinMonto
+	}
+	if movimiento.CreadoEn.IsZero() {
+		movimiento.CreadoEn = time.Now()
+	}
+
+	for intento := 0; intento < intentosRegistrarCredito; intento++ {
+		ultimo, err := r.GetUltimo(ctx, movimiento.UsuarioID, movimiento.Moneda)
+		if err != nil {
+			return err
+		}
+
+		var secuencia, saldo int64
+		if ultimo != nil {
+			secuencia, saldo = ultimo.Secuencia, ultimo.Saldo
+		}
+		if saldo+movimiento.Monto < 0 {
+			return ErrSaldoInsuficiente
+		}
+
+		movimiento.Secuencia = secuencia + 1
+		movimiento.Saldo = saldo + movimiento.Monto
+		movimiento.ID = entity.MovimientoCreditoID(movimiento.UsuarioID, movimiento.Moneda, movimiento.Secuencia)
+
+		_, err = r.collection.InsertOne(ctx, movimiento)
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+
+	return ErrCreditoConcurrente
+}
+
+// GetUltimo devuelve nil sin error si el usuario nunca tuvo crédito en esa
+// moneda.
+func (r *creditoRepository) GetUltimo(ctx context.Context, usuarioID primitive.ObjectID, moneda string) (*entity.MovimientoCredito, error) {
+	opts := options.FindOne()
+	opts.SetSort(bson.M{"secuencia": -1})
+
+	var movimiento entity.MovimientoCredito
+	err := r.collection.FindOne(ctx, bson.M{"usuario_id": usuarioID, "moneda": moneda}, opts).Decode(&movimiento)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &movimiento, nil
+}
+
+// GetSaldos devuelve el saldo del usuario en cada moneda en la que tuvo
+// movimientos.
+func (r *creditoRepository) GetSaldos(ctx context.Context, usuarioID primitive.ObjectID) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"usuario_id": usuarioID}}},
+		{{Key: "$sort", Value: bson.M{"secuencia": -1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$moneda",
+			"saldo": bson.M{"$first": "$saldo"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	saldos := make(map[string]int64)
+	for cursor.Next(ctx) {
+		var row struct {
+			Moneda string `bson:"_id"`
+			Saldo  int64  `bson:"saldo"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			continue
+		}
+		saldos[row.Moneda] = row.Saldo
+	}
+
+	return saldos, cursor.Err()
+}
+
+// AsociarReembolso guarda el ID que la pasarela le dio al reembolso de un
+// movimiento ya registrado. Es el único campo que se completa después; montos
+// y saldos no se tocan.
+func (r *creditoRepository) AsociarReembolso(ctx context.Context, id, reembolsoID string) error {
+	filter := bson.M{
+		"_id":          id,
+		"motivo":       entity.MotivoCreditoReembolso,
+		"reembolso_id": bson.M{"$exists": false},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"reembolso_id": reembolsoID}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrCreditoConcurrente
+	}
+
+	return nil
+}
+
+func (r *creditoRepository) GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.MovimientoCredito, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "creado_en", Value: -1}, {Key: "secuencia", Value: -1}})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var movimientos []*entity.MovimientoCredito
+	for cursor.Next(ctx) {
+		var movimiento entity.MovimientoCredito
+		if err := cursor.Decode(&movimiento); err != nil {
+			continue
+		}
+		movimientos = append(movimientos, &movimiento)
+	}
+
+	return movimientos, cursor.Err()
+}
+
+func (r *creditoRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
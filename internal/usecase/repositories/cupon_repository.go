@@ -0,0 +1,270 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrCuponAgotado       = errors.New("el cupón alcanzó el máximo de canjes")
+	ErrCuponLimiteUsuario = errors.New("el usuario alcanzó el límite de canjes del cupón")
+	errCuponNoEncontrado  = errors.New("cupón no encontrado")
+)
+
+type cuponRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCuponRepository(db *mongo.Database) CuponRepository {
+	return &cuponRepository{
+		collection: db.Collection("cupones"),
+	}
+}
+
+func (r *cuponRepository) Create(ctx context.Context, cupon *entity.Cupon) error {
+	if cupon.ID.IsZero() {
+		cupon.ID = primitive.NewObjectID()
+	}
+	if cupon.CreadoEn.IsZero() {
+		cupon.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, cupon)
+	return err
+}
+
+func (r *cuponRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Cupon, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *cuponRepository) GetByCodigo(ctx context.Context, codigo string) (*entity.Cupon, error) {
+	return r.findOne(ctx, bson.M{"codigo": codigo})
+}
+
+func (r *cuponRepository) CodigoExists(ctx context.Context, codigo string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"codigo": codigo})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *cuponRepository) GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Cupon, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"creado_en": -1})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var cupones []*entity.Cupon
+	for cursor.Next(ctx) {
+		var cupon entity.Cupon
+		if err := cursor.Decode(&cupon); err != nil {
+			continue
+		}
+		cupones = append(cupones, &cupon)
+	}
+
+	return cupones, cursor.Err()
+}
+
+func (r *cuponRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+func (r *cuponRepository) Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errCuponNoEncontrado
+	}
+
+	return nil
+}
+
+func (r *cuponRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.Update(ctx, id, map[string]interface{}{"activo": false})
+}
+
+// IncrementCanjes suma un canje solo si el cupón sigue activo y no llegó a su
+// máximo; el filtro y el $inc se evalúan juntos, así que dos solicitudes
+// simultáneas no pueden pasarse del límite.
+func (r *cuponRepository) IncrementCanjes(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":    id,
+		"activo": true,
+		"$or": bson.A{
+			bson.M{"max_canjes": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$canjes", "$max_canjes"}}},
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"canjes": 1}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrCuponAgotado
+	}
+
+	return nil
+}
+
+// DecrementCanjes revierte un IncrementCanjes cuando el resto del canje falla.
+func (r *cuponRepository) DecrementCanjes(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "canjes": bson.M{"$gt": 0}}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"canjes": -1}})
+	return err
+}
+
+func (r *cuponRepository) findOne(ctx context.Context, filter bson.M) (*entity.Cupon, error) {
+	var cupon entity.Cupon
+	err := r.collection.FindOne(ctx, filter).Decode(&cupon)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errCuponNoEncontrado
+		}
+		return nil, err
+	}
+	return &cupon, nil
+}
+
+type canjeCuponRepository struct {
+	collection *mongo.Collection
+	contadores *mongo.Collection
+}
+
+func NewCanjeCuponRepository(db *mongo.Database) CanjeCuponRepository {
+	return &canjeCuponRepository{
+		collection: db.Collection("canjes_cupon"),
+		contadores: db.Collection("canjes_cupon_usuario"),
+	}
+}
+
+// Create suma el canje al contador del usuario y lo registra. Devuelve
+// ErrCuponLimiteUsuario si el usuario ya llegó a maxPorUsuario (0 = sin
+// límite). Si el registro falla, el contador se revierte.
+func (r *canjeCuponRepository) Create(ctx context.Context, canje *entity.CanjeCupon, maxPorUsuario int) error {
+	if canje.ID == "" {
+		canje.ID = primitive.NewObjectID().Hex()
+	}
+	if canje.CreadoEn.IsZero() {
+		canje.CreadoEn = time.Now()
+	}
+
+	if err := r.incrementarUsuario(ctx, canje.CuponID, canje.UsuarioID, maxPorUsuario); err != nil {
+		return err
+	}
+
+	if _, err := r.collection.InsertOne(ctx, canje); err != nil {
+		return errors.Join(err, r.decrementarUsuario(ctx, canje.CuponID, canje.UsuarioID))
+	}
+
+	return nil
+}
+
+// Delete borra el canje y lo descuenta del contador del usuario.
+func (r *canjeCuponRepository) Delete(ctx context.Context, canje *entity.CanjeCupon) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": canje.ID})
+	if err != nil || result.DeletedCount == 0 {
+		return err
+	}
+
+	return r.decrementarUsuario(ctx, canje.CuponID, canje.UsuarioID)
+}
+
+// incrementarUsuario suma un canje al contador del usuario solo si no llegó
+// a max; el filtro y el $inc se evalúan juntos sobre un único documento, así
+// que dos solicitudes simultáneas no pueden pasarse del límite. El contador se
+// crea la primera vez a partir de los canjes ya registrados.
+func (r *canjeCuponRepository) incrementarUsuario(ctx context.Context, cuponID, usuarioID primitive.ObjectID, max int) error {
+	id := entity.CanjesCuponUsuarioID(cuponID, usuarioID)
+	filter := bson.M{"_id": id}
+	if max > 0 {
+		filter["canjes"] = bson.M{"$lt": max}
+	}
+	update := bson.M{"$inc": bson.M{"canjes": 1}}
+
+	for intento := 0; intento < 2; intento++ {
+		result, err := r.contadores.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount > 0 {
+			return nil
+		}
+
+		creado, err := r.crearContador(ctx, id, cuponID, usuarioID)
+		if err != nil {
+			return err
+		}
+		if !creado {
+			break
+		}
+	}
+
+	return ErrCuponLimiteUsuario
+}
+
+// crearContador inserta el contador con los canjes ya registrados del usuario
+// y devuelve false si ya existía. Si otra solicitud lo crea a la vez, el
+// índice único de _id deja uno solo.
+func (r *canjeCuponRepository) crearContador(ctx context.Context, id string, cuponID, usuarioID primitive.ObjectID) (bool, error) {
+	existe, err := r.contadores.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil || existe > 0 {
+		return false, err
+	}
+
+	usados, err := r.CountByUsuario(ctx, cuponID, usuarioID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = r.contadores.InsertOne(ctx, entity.CanjesCuponUsuario{
+		ID:        id,
+		CuponID:   cuponID,
+		UsuarioID: usuarioID,
+		Canjes:    int(usados),
+	})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *canjeCuponRepository) decrementarUsuario(ctx context.Context, cuponID, usuarioID primitive.ObjectID) error {
+	filter := bson.M{"_id": entity.CanjesCuponUsuarioID(cuponID, usuarioID), "canjes": bson.M{"$gt": 0}}
+	_, err := r.contadores.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"canjes": -1}})
+	return err
+}
+
+func (r *canjeCuponRepository) CountByUsuario(ctx context.Context, cuponID, usuarioID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"cupon_id": cuponID, "usuario_id": usuarioID})
+}
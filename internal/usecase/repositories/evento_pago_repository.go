@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type eventoPagoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEventoPagoRepository(db *mongo.Database) EventoPagoRepository {
+	return &eventoPagoRepository{
+		collection: db.Collection("eventos_pago"),
+	}
+}
+
+// Reservar inserta el evento por el tiempo de reserva y devuelve false si ya
+// existía, es decir, si otra entrega del mismo webhook ya lo procesó o lo está
+// procesando. Una reserva vencida se vuelve a tomar: el proceso que la tenía
+// se cayó antes de marcarla procesada o liberarla.
+func (r *eventoPagoRepository) Reservar(ctx context.Context, evento *entity.EventoPago, reserva time.Duration) (bool, error) {
+	now := time.Now()
+	if evento.RecibidoEn.IsZero() {
+		evento.RecibidoEn = now
+	}
+	evento.Estado = entity.EstadoEventoPagoProcesando
+	evento.ReservadoHasta = now.Add(reserva)
+
+	_, err := r.collection.InsertOne(ctx, evento)
+	if err == nil {
+		return true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	filter := bson.M{
+		"_id":             evento.ID,
+		"estado":          entity.EstadoEventoPagoProcesando,
+		"reservado_hasta": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"reservado_hasta": evento.ReservadoHasta}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r *eventoPagoRepository) MarcarProcesado(ctx context.Context, id string) error {
+	update := bson.M{"$set": bson.M{
+		"estado":       entity.EstadoEventoPagoProcesado,
+		"procesado_en": time.Now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// Liberar borra la reserva de un evento que no se pudo procesar, para que el
+// reintento de la pasarela lo vuelva a intentar.
+func (r *eventoPagoRepository) Liberar(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "estado": entity.EstadoEventoPagoProcesando})
+	return err
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type eventoSuscripcionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEventoSuscripcionRepository(db *mongo.Database) EventoSuscripcionRepository {
+	return &eventoSuscripcionRepository{
+		collection: db.Collection("suscripcion_eventos"),
+	}
+}
+
+func (r *eventoSuscripcionRepository) Create(ctx context.Context, evento *entity.EventoSuscripcion) error {
+	if evento.ID.IsZero() {
+		evento.ID = primitive.NewObjectID()
+	}
+	if evento.CreadoEn.IsZero() {
+		evento.CreadoEn = time.Now()
+	}
+
+	// Un evento pendiente puede copiarse dos veces, desde la operación que lo
+	// generó y desde la tarea que publica los que quedaron; su _id ya existe
+	_, err := r.collection.InsertOne(ctx, evento)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *eventoSuscripcionRepository) GetBySuscripcionID(ctx context.Context, suscripcionID primitive.ObjectID, limit, offset int) ([]*entity.EventoSuscripcion, error) {
+	return r.find(ctx, bson.M{"suscripcion_id": suscripcionID}, limit, offset)
+}
+
+func (r *eventoSuscripcionRepository) GetByUsuarioID(ctx context.Context, usuarioID primitive.ObjectID, limit, offset int) ([]*entity.EventoSuscripcion, error) {
+	return r.find(ctx, bson.M{"usuario_id": usuarioID}, limit, offset)
+}
+
+func (r *eventoSuscripcionRepository) CountBySuscripcionID(ctx context.Context, suscripcionID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"suscripcion_id": suscripcionID})
+}
+
+func (r *eventoSuscripcionRepository) find(ctx context.Context, filter bson.M, limit, offset int) ([]*entity.EventoSuscripcion, error) {
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "creado_en", Value: -1}, {Key: "_id", Value: -1}})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var eventos []*entity.EventoSuscripcion
+	for cursor.Next(ctx) {
+		var evento entity.EventoSuscripcion
+		if err := cursor.Decode(&evento); err != nil {
+			continue
+		}
+		eventos = append(eventos, &evento)
+	}
+
+	return eventos, cursor.Err()
+}
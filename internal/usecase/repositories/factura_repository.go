@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrFacturaModificada = errors.New("la factura fue modificada por otro proceso")
+	ErrContadorOcupado   = errors.New("la secuencia tiene una asignación sin confirmar")
+	ErrReembolsoExcedido = errors.New("el reembolso supera lo que queda por reembolsar de la factura")
+)
+
+type facturaRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFacturaRepository(db *mongo.Database) FacturaRepository {
+	return &facturaRepository{
+		collection: db.Collection("facturas"),
+	}
+}
+
+func (r *facturaRepository) Create(ctx context.Context, factura *entity.Factura) error {
+	if factura.ID.IsZero() {
+		factura.ID = primitive.NewObjectID()
+	}
+	if factura.CreadoEn.IsZero() {
+		factura.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, factura)
+	return err
+}
+
+func (r *facturaRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Factura, error) {
+	var factura entity.Factura
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&factura)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("factura no encontrada")
+		}
+		return nil, err
+	}
+	return &factura, nil
+}
+
+func (r *facturaRepository) GetByCobroID(ctx context.Context, cobroID string) (*entity.Factura, error) {
+	var factura entity.Factura
+	err := r.collection.FindOne(ctx, bson.M{"cobro_id": cobroID}).Decode(&factura)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("factura no encontrada")
+		}
+		return nil, err
+	}
+	return &factura, nil
+}
+
+func (r *facturaRepository) GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Factura, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "creado_en", Value: -1}, {Key: "_id", Value: -1}})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var facturas []*entity.Factura
+	for cursor.Next(ctx) {
+		var factura entity.Factura
+		if err := cursor.Decode(&factura); err != nil {
+			continue
+		}
+		facturas = append(facturas, &factura)
+	}
+
+	return facturas, cursor.Err()
+}
+
+func (r *facturaRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// UpdateIfMatch aplica updates solo si la factura sigue coincidiendo con
+// expected; si no, devuelve ErrFacturaModificada.
+func (r *facturaRepository) UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}) error {
+	filter := bson.M{"_id": id}
+	for k, v := range expected {
+		filter[k] = v
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrFacturaModificada
+	}
+
+	return nil
+}
+
+// SumarReembolso suma monto a lo reembolsado de la factura en una sola
+// actualización, solo si no pasa del total; si no, devuelve
+// ErrReembolsoExcedido. Un monto negativo deshace un reembolso que falló.
+func (r *facturaRepository) SumarReembolso(ctx context.Context, id primitive.ObjectID, monto int64) error {
+	filter := bson.M{
+		"_id": id,
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$reembolsado", 0}}, monto}},
+			"$total",
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"reembolsado": monto}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrReembolsoExcedido
+	}
+
+	return nil
+}
+
+type contadorRepository struct {
+	collection *mongo.Collection
+}
+
+func NewContadorRepository(db *mongo.Database) ContadorRepository {
+	return &contadorRepository{
+		collection: db.Collection("contadores"),
+	}
+}
+
+// Get devuelve la secuencia; si todavía no existe, una vacía.
+func (r *contadorRepository) Get(ctx context.Context, nombre string) (*entity.Contador, error) {
+	contador := entity.Contador{Nombre: nombre}
+	err := r.collection.FindOne(ctx, bson.M{"_id": nombre}).Decode(&contador)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	return &contador, nil
+}
+
+// Asignar incrementa la secuencia y deja el nuevo valor pendiente para
+// documentoID, en una sola actualización. Devuelve ErrContadorOcupado si ya
+// hay una asignación sin confirmar. La primera llamada crea la secuencia y
+// devuelve 1.
+func (r *contadorRepository) Asignar(ctx context.Context, nombre string, documentoID primitive.ObjectID) (int64, error) {
+	filter := bson.M{"_id": nombre, "pendiente": bson.M{"$exists": false}}
+	update := bson.A{
+		bson.M{"$set": bson.M{"valor": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$valor", 0}}, 1}}}},
+		bson.M{"$set": bson.M{"pendiente": bson.M{"valor": "$valor", "documento_id": documentoID}}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	// Si la secuencia existe con una asignación pendiente, el upsert intenta
+	// insertarla y choca con el _id
+	var contador entity.Contador
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&contador)
+	if mongo.IsDuplicateKeyError(err) {
+		return 0, ErrContadorOcupado
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return contador.Valor, nil
+}
+
+// Reasignar pasa el valor pendiente a documentoID si la asignación sigue
+// siendo la misma, para cuando su documento ya no lo puede tomar.
+func (r *contadorRepository) Reasignar(ctx context.Context, nombre string, pendiente entity.AsignacionContador, documentoID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":                    nombre,
+		"pendiente.valor":        pendiente.Valor,
+		"pendiente.documento_id": pendiente.DocumentoID,
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"pendiente.documento_id": documentoID}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// Confirmar quita la asignación pendiente una vez que documentoID guardó su
+// valor. Confirmarla dos veces no tiene efecto.
+func (r *contadorRepository) Confirmar(ctx context.Context, nombre string, documentoID primitive.ObjectID) error {
+	filter := bson.M{"_id": nombre, "pendiente.documento_id": documentoID}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"pendiente": ""}})
+	return err
+}
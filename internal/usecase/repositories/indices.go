@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"sw2p2go/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CrearIndices crea los índices únicos de los que dependen los repositorios
+// para rechazar escrituras simultáneas. Si ya existen no hace nada, así que se
+// llama en cada arranque.
+func CrearIndices(ctx context.Context, db *mongo.Database) error {
+	// Una suscripción tiene como mucho un cambio programado pendiente
+	_, err := db.Collection("cambios_programados").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "suscripcion_id", Value: 1}},
+		Options: options.Index().
+			SetName("suscripcion_id_pendiente").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"estado": entity.EstadoCambioProgramadoPendiente}),
+	})
+	return err
+}
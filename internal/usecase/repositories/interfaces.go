@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"sw2p2go/internal/entity"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -29,15 +30,192 @@ type PlanRepository interface {
 	GetActivePlans(ctx context.Context, limit, offset int) ([]*entity.PlanSuscripcion, error)
 }
 
+type VersionPlanRepository interface {
+	Create(ctx context.Context, version *entity.VersionPlan) error
+	GetByNumero(ctx context.Context, planID primitive.ObjectID, numero int) (*entity.VersionPlan, error)
+	GetByPlan(ctx context.Context, planID primitive.ObjectID) ([]*entity.VersionPlan, error)
+	Delete(ctx context.Context, planID primitive.ObjectID, numero int) error
+}
+
 type SuscripcionRepository interface {
 	Create(ctx context.Context, suscripcion *entity.Suscripcion) error
 	GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Suscripcion, error)
 	GetByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int) ([]*entity.Suscripcion, error)
 	GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Suscripcion, error)
-	Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error
+	Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}, evento *entity.EventoSuscripcion) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
 	GetActiveSuscripcionByUserID(ctx context.Context, userID primitive.ObjectID) (*entity.Suscripcion, error)
 	CountActiveSuscripcionesByPlan(ctx context.Context, planID primitive.ObjectID) (int64, error)
 	GetSuscripcionesWithDetails(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]map[string]interface{}, error)
+	GetExpired(ctx context.Context, now time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error)
+	GetRenewable(ctx context.Context, until time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error)
+	UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}, evento *entity.EventoSuscripcion) error
+	ApplyTransition(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}, transicion entity.TransicionEstado, evento *entity.EventoSuscripcion) error
+	HasUsedTrial(ctx context.Context, userID primitive.ObjectID, planID *primitive.ObjectID) (bool, error)
+	GetEndedTrials(ctx context.Context, now time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error)
+	GetPausesEnded(ctx context.Context, now time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error)
+	GetPendientesPago(ctx context.Context, creadoAntes time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error)
+	GetPorVencer(ctx context.Context, desde, hasta time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error)
+	GetVigentesByPlan(ctx context.Context, planID primitive.ObjectID, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error)
+	GetActiveByAsiento(ctx context.Context, userID primitive.ObjectID) (*entity.Suscripcion, error)
+	CambiarCantidad(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, cantidadAnterior, cantidadNueva int, updates map[string]interface{}, evento *entity.EventoSuscripcion) error
+	AsignarAsiento(ctx context.Context, id primitive.ObjectID, asignacion entity.AsignacionAsiento, evento *entity.EventoSuscripcion) error
+	LiberarAsiento(ctx context.Context, id, userID primitive.ObjectID, evento *entity.EventoSuscripcion) error
+	AgregarComplemento(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, complemento entity.ComplementoSuscripcion, evento *entity.EventoSuscripcion) error
+	QuitarComplemento(ctx context.Context, id, complementoID primitive.ObjectID, evento *entity.EventoSuscripcion) error
+	GetConEventosPendientes(ctx context.Context, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error)
+	QuitarEventoPendiente(ctx context.Context, id, eventoID primitive.ObjectID) error
+}
+
+type EventoSuscripcionRepository interface {
+	Create(ctx context.Context, evento *entity.EventoSuscripcion) error
+	GetBySuscripcionID(ctx context.Context, suscripcionID primitive.ObjectID, limit, offset int) ([]*entity.EventoSuscripcion, error)
+	GetByUsuarioID(ctx context.Context, usuarioID primitive.ObjectID, limit, offset int) ([]*entity.EventoSuscripcion, error)
+	CountBySuscripcionID(ctx context.Context, suscripcionID primitive.ObjectID) (int64, error)
+}
+
+type CuponRepository interface {
+	Create(ctx context.Context, cupon *entity.Cupon) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Cupon, error)
+	GetByCodigo(ctx context.Context, codigo string) (*entity.Cupon, error)
+	CodigoExists(ctx context.Context, codigo string) (bool, error)
+	GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Cupon, error)
+	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	IncrementCanjes(ctx context.Context, id primitive.ObjectID) error
+	DecrementCanjes(ctx context.Context, id primitive.ObjectID) error
+}
+
+type CanjeCuponRepository interface {
+	Create(ctx context.Context, canje *entity.CanjeCupon, maxPorUsuario int) error
+	Delete(ctx context.Context, canje *entity.CanjeCupon) error
+	CountByUsuario(ctx context.Context, cuponID, usuarioID primitive.ObjectID) (int64, error)
+}
+
+type FacturaRepository interface {
+	Create(ctx context.Context, factura *entity.Factura) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Factura, error)
+	GetByCobroID(ctx context.Context, cobroID string) (*entity.Factura, error)
+	GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Factura, error)
+	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}) error
+	SumarReembolso(ctx context.Context, id primitive.ObjectID, monto int64) error
+}
+
+type TasaImpuestoRepository interface {
+	Create(ctx context.Context, tasa *entity.TasaImpuesto) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*entity.TasaImpuesto, error)
+	GetAplicable(ctx context.Context, pais, region string) (*entity.TasaImpuesto, error)
+	Exists(ctx context.Context, pais, region string, excluir primitive.ObjectID) (bool, error)
+	GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.TasaImpuesto, error)
+	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type EventoUsoRepository interface {
+	Reservar(ctx context.Context, evento *entity.EventoUso, reserva time.Duration) (bool, error)
+	GetByID(ctx context.Context, id string) (*entity.EventoUso, error)
+	MarcarRegistrado(ctx context.Context, id string) error
+	Liberar(ctx context.Context, id string) error
+}
+
+type UsoPeriodoRepository interface {
+	Consumir(ctx context.Context, uso *entity.UsoPeriodo, cantidad, limite int64) (*entity.UsoPeriodo, error)
+	Revertir(ctx context.Context, id string, cantidad int64) error
+	GetByID(ctx context.Context, id string) (*entity.UsoPeriodo, error)
+}
+
+type EventoPagoRepository interface {
+	Reservar(ctx context.Context, evento *entity.EventoPago, reserva time.Duration) (bool, error)
+	MarcarProcesado(ctx context.Context, id string) error
+	Liberar(ctx context.Context, id string) error
+}
+
+type ContadorRepository interface {
+	Get(ctx context.Context, nombre string) (*entity.Contador, error)
+	Asignar(ctx context.Context, nombre string, documentoID primitive.ObjectID) (int64, error)
+	Reasignar(ctx context.Context, nombre string, pendiente entity.AsignacionContador, documentoID primitive.ObjectID) (bool, error)
+	Confirmar(ctx context.Context, nombre string, documentoID primitive.ObjectID) error
+}
+
+type CambioPlanRepository interface {
+	Create(ctx context.Context, cambio *entity.CambioPlan) error
+	GetByFacturaID(ctx context.Context, facturaID primitive.ObjectID) (*entity.CambioPlan, error)
+}
+
+type CreditoRepository interface {
+	Registrar(ctx context.Context, movimiento *entity.MovimientoCredito) error
+	GetUltimo(ctx context.Context, usuarioID primitive.ObjectID, moneda string) (*entity.MovimientoCredito, error)
+	GetSaldos(ctx context.Context, usuarioID primitive.ObjectID) (map[string]int64, error)
+	AsociarReembolso(ctx context.Context, id, reembolsoID string) error
+	GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.MovimientoCredito, error)
+	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+}
+
+type RecordatorioRepository interface {
+	Create(ctx context.Context, recordatorio *entity.RecordatorioVencimiento) error
+	MarcarEnviado(ctx context.Context, id string, enviadoEn time.Time) error
+	Delete(ctx context.Context, id string) error
+}
+
+type ReporteRepository interface {
+	GetMRRSuscripciones(ctx context.Context, moneda string, en time.Time) ([]*entity.MRRSuscripcion, error)
+	GetCambiosMRR(ctx context.Context, moneda string, desde, hasta time.Time) (map[primitive.ObjectID]float64, error)
+	GetCohorteUsuarios(ctx context.Context, criterio string, desde, hasta time.Time) ([]*entity.CohorteUsuario, error)
+}
+
+type CambioProgramadoRepository interface {
+	Create(ctx context.Context, cambio *entity.CambioProgramado) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*entity.CambioProgramado, error)
+	GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.CambioProgramado, error)
+	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	ExistsPendiente(ctx context.Context, suscripcionID primitive.ObjectID) (bool, error)
+	GetDue(ctx context.Context, now time.Time, afterID primitive.ObjectID, limit int) ([]*entity.CambioProgramado, error)
+	UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type ComplementoRepository interface {
+	Create(ctx context.Context, complemento *entity.Complemento) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Complemento, error)
+	GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Complemento, error)
+	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type RegaloRepository interface {
+	Create(ctx context.Context, regalo *entity.Regalo) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Regalo, error)
+	GetByCodigo(ctx context.Context, codigo string) (*entity.Regalo, error)
+	CodigoExists(ctx context.Context, codigo string) (bool, error)
+	GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Regalo, error)
+	Count(ctx context.Context, filters map[string]interface{}) (int64, error)
+	UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}) error
+}
+
+type CambioAsientosRepository interface {
+	Create(ctx context.Context, cambio *entity.CambioAsientos) error
+	GetByFacturaID(ctx context.Context, facturaID primitive.ObjectID) (*entity.CambioAsientos, error)
+}
+
+type AsientoRepository interface {
+	Ocupar(ctx context.Context, asiento *entity.AsientoOcupado) error
+	GetByUsuario(ctx context.Context, usuarioID primitive.ObjectID) (*entity.AsientoOcupado, error)
+	Reasignar(ctx context.Context, asiento, anterior *entity.AsientoOcupado) error
+	Liberar(ctx context.Context, usuarioID, suscripcionID primitive.ObjectID) error
+}
+
+type RenovacionRepository interface {
+	Create(ctx context.Context, renovacion *entity.Renovacion) error
+	GetBySuscripcionID(ctx context.Context, suscripcionID primitive.ObjectID, limit, offset int) ([]*entity.Renovacion, error)
+}
+
+type TareaRepository interface {
+	AcquireLock(ctx context.Context, nombre, titular string, lease, intervalo time.Duration, force bool) (bool, error)
+	ReleaseLock(ctx context.Context, nombre, titular string, runErr error) error
+	GetAll(ctx context.Context) ([]*entity.TareaProgramada, error)
 }
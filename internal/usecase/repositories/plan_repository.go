@@ -53,7 +53,7 @@ func (r *planRepository) GetAll(ctx context.Context, filters map[string]interfac
 	}
 
 	opts := options.Find()
-	opts.SetSort(bson.M{"precio": 1}) // Ordenar por precio ascendente
+	opts.SetSort(bson.M{"precios.monto": 1}) // Ordenar por el precio más bajo del plan
 	opts.SetLimit(int64(limit))
 	opts.SetSkip(int64(offset))
 
@@ -120,7 +120,7 @@ func (r *planRepository) GetActivePlans(ctx context.Context, limit, offset int)
 	filter := bson.M{"activo": true}
 
 	opts := options.Find()
-	opts.SetSort(bson.M{"precio": 1})
+	opts.SetSort(bson.M{"precios.monto": 1})
 	opts.SetLimit(int64(limit))
 	opts.SetSkip(int64(offset))
 
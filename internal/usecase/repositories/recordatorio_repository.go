@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrRecordatorioRegistrado = errors.New("el recordatorio ya fue registrado")
+
+type recordatorioRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRecordatorioRepository(db *mongo.Database) RecordatorioRepository {
+	return &recordatorioRepository{
+		collection: db.Collection("recordatorios_vencimiento"),
+	}
+}
+
+// Create devuelve ErrRecordatorioRegistrado si otra réplica ya registró el
+// mismo recordatorio.
+func (r *recordatorioRepository) Create(ctx context.Context, recordatorio *entity.RecordatorioVencimiento) error {
+	if recordatorio.CreadoEn.IsZero() {
+		recordatorio.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, recordatorio)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrRecordatorioRegistrado
+	}
+	return err
+}
+
+func (r *recordatorioRepository) MarcarEnviado(ctx context.Context, id string, enviadoEn time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"estado":     entity.EstadoRecordatorioEnviado,
+		"enviado_en": enviadoEn,
+	}})
+	return err
+}
+
+func (r *recordatorioRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrRegaloModificado   = errors.New("el regalo fue modificado por otro proceso")
+	errRegaloNoEncontrado = errors.New("regalo no encontrado")
+)
+
+type regaloRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRegaloRepository(db *mongo.Database) RegaloRepository {
+	return &regaloRepository{
+		collection: db.Collection("regalos"),
+	}
+}
+
+func (r *regaloRepository) Create(ctx context.Context, regalo *entity.Regalo) error {
+	if regalo.ID.IsZero() {
+		regalo.ID = primitive.NewObjectID()
+	}
+	if regalo.CreadoEn.IsZero() {
+		regalo.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, regalo)
+	return err
+}
+
+func (r *regaloRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Regalo, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *regaloRepository) GetByCodigo(ctx context.Context, codigo string) (*entity.Regalo, error) {
+	return r.findOne(ctx, bson.M{"codigo": codigo})
+}
+
+func (r *regaloRepository) CodigoExists(ctx context.Context, codigo string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"codigo": codigo})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *regaloRepository) GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Regalo, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"creado_en": -1})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var regalos []*entity.Regalo
+	for cursor.Next(ctx) {
+		var regalo entity.Regalo
+		if err := cursor.Decode(&regalo); err != nil {
+			continue
+		}
+		regalos = append(regalos, &regalo)
+	}
+
+	return regalos, cursor.Err()
+}
+
+func (r *regaloRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// UpdateIfMatch aplica updates solo si el regalo sigue teniendo los valores de
+// expected; si no devuelve ErrRegaloModificado. Es lo que evita que un código
+// se canjee dos veces.
+func (r *regaloRepository) UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}) error {
+	filter := bson.M{"_id": id}
+	for k, v := range expected {
+		filter[k] = v
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrRegaloModificado
+	}
+
+	return nil
+}
+
+func (r *regaloRepository) findOne(ctx context.Context, filter bson.M) (*entity.Regalo, error) {
+	var regalo entity.Regalo
+	err := r.collection.FindOne(ctx, filter).Decode(&regalo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errRegaloNoEncontrado
+		}
+		return nil, err
+	}
+	return &regalo, nil
+}
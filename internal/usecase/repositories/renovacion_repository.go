@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type renovacionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRenovacionRepository(db *mongo.Database) RenovacionRepository {
+	return &renovacionRepository{
+		collection: db.Collection("renovaciones"),
+	}
+}
+
+func (r *renovacionRepository) Create(ctx context.Context, renovacion *entity.Renovacion) error {
+	if renovacion.ID.IsZero() {
+		renovacion.ID = primitive.NewObjectID()
+	}
+	if renovacion.CreadoEn.IsZero() {
+		renovacion.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, renovacion)
+	return err
+}
+
+func (r *renovacionRepository) GetBySuscripcionID(ctx context.Context, suscripcionID primitive.ObjectID, limit, offset int) ([]*entity.Renovacion, error) {
+	filter := bson.M{"suscripcion_id": suscripcionID}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"creado_en": -1})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var renovaciones []*entity.Renovacion
+	for cursor.Next(ctx) {
+		var renovacion entity.Renovacion
+		if err := cursor.Decode(&renovacion); err != nil {
+			continue
+		}
+		renovaciones = append(renovaciones, &renovacion)
+	}
+
+	return renovaciones, cursor.Err()
+}
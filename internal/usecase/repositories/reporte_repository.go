@@ -0,0 +1,304 @@
+package repositories
+
+import (
+	"context"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// tiposEventoPrecio son los eventos del historial que pueden cambiar lo que
+// paga una suscripción por periodo.
+var tiposEventoPrecio = []string{
+	entity.EventoSuscripcionPlanCambiado,
+	entity.EventoSuscripcionRenovada,
+	entity.EventoSuscripcionVersionMigrada,
+	entity.EventoSuscripcionAsientosCambiados,
+	entity.EventoSuscripcionComplementoAgregado,
+	entity.EventoSuscripcionComplementoQuitado,
+}
+
+// motivosAltaImpaga son los motivos con los que se cancela un alta que nunca
+// se pagó. Esas suscripciones quedan canceladas con su fecha_fin, pero no
+// llegaron a aportar MRR.
+var motivosAltaImpaga = []string{
+	entity.MotivoCancelacionPagoRechazado,
+	entity.MotivoCancelacionPagoNoConfirmado,
+}
+
+type reporteRepository struct {
+	usuarios      *mongo.Collection
+	suscripciones *mongo.Collection
+	eventos       *mongo.Collection
+}
+
+func NewReporteRepository(db *mongo.Database) ReporteRepository {
+	return &reporteRepository{
+		usuarios:      db.Collection("usuarios"),
+		suscripciones: db.Collection("suscripciones"),
+		eventos:       db.Collection("suscripcion_eventos"),
+	}
+}
+
+// GetMRRSuscripciones devuelve el MRR de cada suscripción en moneda que estaba
+// pagando en el instante en: ya empezada, sin vencer, fuera de la prueba y de
+// una pausa. El monto es el precio actual de la suscripción más sus
+// complementos, sin cupones, llevado a un mes según el periodo del plan.
+func (r *reporteRepository) GetMRRSuscripciones(ctx context.Context, moneda string, en time.Time) ([]*entity.MRRSuscripcion, error) {
+	match := bson.M{
+		"estado":             bson.M{"$ne": entity.EstadoSuscripcionPendientePago},
+		"motivo_cancelacion": bson.M{"$nin": motivosAltaImpaga},
+		"fecha_inicio":       bson.M{"$lte": en},
+		"fecha_fin":          bson.M{"$gt": en},
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"fin_prueba": bson.M{"$exists": false}},
+				bson.M{"fin_prueba": bson.M{"$lte": en}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"estado": bson.M{"$ne": entity.EstadoSuscripcionPausada}},
+				bson.M{"pausada_desde": bson.M{"$gt": en}},
+			}},
+			filtroMoneda("moneda", moneda),
+		},
+		"pausas": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"inicio": bson.M{"$lte": en},
+			"fin":    bson.M{"$gt": en},
+		}}},
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "planes_suscripcion",
+			"localField":   "plan_id",
+			"foreignField": "_id",
+			"as":           "plan",
+		}}},
+		{{Key: "$unwind", Value: "$plan"}},
+		{{Key: "$project", Value: bson.M{
+			"usuario_id": 1,
+			"mrr": mensualizar(bson.M{"$add": bson.A{
+				// Las suscripciones anteriores a multi-moneda no guardaron el precio
+				bson.M{"$cond": bson.A{
+					bson.M{"$gt": bson.A{"$precio", 0}},
+					"$precio",
+					bson.M{"$multiply": bson.A{
+						precioPlanEn("$plan", moneda),
+						bson.M{"$max": bson.A{"$cantidad", 1}},
+					}},
+				}},
+				bson.M{"$sum": bson.M{"$ifNull": bson.A{"$complementos.precio", bson.A{}}}},
+			}}, "$plan"),
+		}}},
+	}
+
+	cursor, err := r.suscripciones.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*entity.MRRSuscripcion
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetCambiosMRR suma, por suscripción, cuánto cambió su MRR por los eventos
+// del historial en [desde, hasta); hasta cero no pone límite. Cada lado del
+// cambio se lleva a un mes con el periodo de su propio plan, así pasar de un
+// plan mensual a uno anual cuenta bien.
+func (r *reporteRepository) GetCambiosMRR(ctx context.Context, moneda string, desde, hasta time.Time) (map[primitive.ObjectID]float64, error) {
+	creadoEn := bson.M{"$gte": desde}
+	if !hasta.IsZero() {
+		creadoEn["$lt"] = hasta
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"tipo":      bson.M{"$in": tiposEventoPrecio},
+			"creado_en": creadoEn,
+			"$or": bson.A{
+				bson.M{"antes.precio": bson.M{"$exists": true}},
+				bson.M{"antes.complementos": bson.M{"$exists": true}},
+			},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "suscripciones",
+			"localField":   "suscripcion_id",
+			"foreignField": "_id",
+			"as":           "suscripcion",
+		}}},
+		{{Key: "$unwind", Value: "$suscripcion"}},
+		{{Key: "$match", Value: filtroMoneda("suscripcion.moneda", moneda)}},
+		{{Key: "$addFields", Value: bson.M{
+			"plan_despues_id": bson.M{"$ifNull": bson.A{"$despues.plan_id", "$suscripcion.plan_id"}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"plan_antes_id": bson.M{"$ifNull": bson.A{"$antes.plan_id", "$plan_despues_id"}},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "planes_suscripcion",
+			"localField":   "plan_antes_id",
+			"foreignField": "_id",
+			"as":           "plan_antes",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "planes_suscripcion",
+			"localField":   "plan_despues_id",
+			"foreignField": "_id",
+			"as":           "plan_despues",
+		}}},
+		{{Key: "$unwind", Value: "$plan_antes"}},
+		{{Key: "$unwind", Value: "$plan_despues"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": "$suscripcion_id",
+			"cambio": bson.M{"$sum": bson.M{"$subtract": bson.A{
+				mensualizar(montoEvento("$despues"), "$plan_despues"),
+				mensualizar(montoEvento("$antes"), "$plan_antes"),
+			}}},
+		}}},
+	}
+
+	cursor, err := r.eventos.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	cambios := make(map[primitive.ObjectID]float64)
+	for cursor.Next(ctx) {
+		var row struct {
+			SuscripcionID primitive.ObjectID `bson:"_id"`
+			Cambio        float64            `bson:"cambio"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			continue
+		}
+		cambios[row.SuscripcionID] = row.Cambio
+	}
+
+	return cambios, cursor.Err()
+}
+
+// GetCohorteUsuarios devuelve los usuarios cuya cohorte empieza en [desde,
+// hasta), con los periodos de todas sus suscripciones. Las que nunca se
+// pagaron no cuentan ni como periodo ni como primera suscripción.
+func (r *reporteRepository) GetCohorteUsuarios(ctx context.Context, criterio string, desde, hasta time.Time) ([]*entity.CohorteUsuario, error) {
+	rango := bson.M{"$gte": desde, "$lt": hasta}
+	pagada := bson.M{"$ne": entity.EstadoSuscripcionPendientePago}
+	altaPagada := bson.M{"$nin": motivosAltaImpaga}
+
+	var cursor *mongo.Cursor
+	var err error
+	if criterio == entity.CohortePorPrimeraSuscripcion {
+		cursor, err = r.suscripciones.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"estado": pagada, "motivo_cancelacion": altaPagada}}},
+			{{Key: "$group", Value: bson.M{
+				"_id":    "$usuario_id",
+				"inicio": bson.M{"$min": "$fecha_inicio"},
+				"periodos": bson.M{"$push": bson.M{
+					"fecha_inicio": "$fecha_inicio",
+					"fecha_fin":    "$fecha_fin",
+				}},
+			}}},
+			{{Key: "$match", Value: bson.M{"inicio": rango}}},
+		})
+	} else {
+		cursor, err = r.usuarios.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"creado_en": rango}}},
+			{{Key: "$lookup", Value: bson.M{
+				"from": "suscripciones",
+				"let":  bson.M{"usuario_id": "$_id"},
+				"pipeline": bson.A{
+					bson.M{"$match": bson.M{
+						"$expr":              bson.M{"$eq": bson.A{"$usuario_id", "$$usuario_id"}},
+						"estado":             pagada,
+						"motivo_cancelacion": altaPagada,
+					}},
+					bson.M{"$project": bson.M{"_id": 0, "fecha_inicio": 1, "fecha_fin": 1}},
+				},
+				"as": "periodos",
+			}}},
+			{{Key: "$project", Value: bson.M{"inicio": "$creado_en", "periodos": 1}}},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*entity.CohorteUsuario
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// filtroMoneda incluye las suscripciones sin moneda guardada cuando se pide la
+// moneda en la que estaban los precios antes de multi-moneda.
+func filtroMoneda(campo, moneda string) bson.M {
+	if moneda != entity.MonedaLegacy {
+		return bson.M{campo: moneda}
+	}
+	return bson.M{campo: bson.M{"$in": bson.A{moneda, "", nil}}}
+}
+
+// montoEvento es lo que pagaba (o pasa a pagar) la suscripción según un lado
+// del evento. Los eventos solo guardan los campos que cambiaron y los dos
+// lados tienen los mismos, así que un campo ausente cuenta cero en ambos.
+func montoEvento(lado string) bson.M {
+	return bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{lado + ".precio", 0}},
+		bson.M{"$sum": bson.M{"$ifNull": bson.A{lado + ".complementos.precio", bson.A{}}}},
+	}}
+}
+
+// precioPlanEn es el precio del plan en moneda, o 0 si no tiene. Como
+// PlanSuscripcion.ListaPrecios, lee el precio decimal de los planes antiguos
+// como su precio en MonedaLegacy.
+func precioPlanEn(plan, moneda string) bson.M {
+	legacy := interface{}(0)
+	if moneda == entity.MonedaLegacy {
+		legacy = bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{
+			bson.M{"$ifNull": bson.A{plan + ".precio", 0}},
+			entity.MontoDesdeDecimal(1, entity.MonedaLegacy),
+		}}}}
+	}
+
+	return bson.M{"$ifNull": bson.A{
+		bson.M{"$first": bson.M{"$map": bson.M{
+			"input": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{plan + ".precios", bson.A{}}},
+				"cond":  bson.M{"$eq": bson.A{"$$this.moneda", moneda}},
+			}},
+			"in": "$$this.monto",
+		}}},
+		legacy,
+	}}
+}
+
+// mensualizar lleva el monto de un periodo del plan a un mes: un año son 12
+// meses, un mes tiene 52/12 semanas y 365/12 días.
+func mensualizar(monto interface{}, plan string) bson.M {
+	porPeriodo := bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": bson.M{"$eq": bson.A{plan + ".intervalo", entity.IntervaloDia}}, "then": 365.0 / 12},
+			bson.M{"case": bson.M{"$eq": bson.A{plan + ".intervalo", entity.IntervaloSemana}}, "then": 52.0 / 12},
+			bson.M{"case": bson.M{"$eq": bson.A{plan + ".intervalo", entity.IntervaloAnio}}, "then": 1.0 / 12},
+		},
+		"default": 1.0,
+	}}
+
+	return bson.M{"$divide": bson.A{
+		bson.M{"$multiply": bson.A{monto, porPeriodo}},
+		bson.M{"$max": bson.A{plan + ".intervalo_cantidad", 1}},
+	}}
+}
@@ -12,6 +12,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var ErrSuscripcionModificada = errors.New("la suscripción fue modificada por otro proceso")
+
 type suscripcionRepository struct {
 	collection *mongo.Collection
 }
@@ -101,9 +103,9 @@ func (r *suscripcionRepository) GetAll(ctx context.Context, filters map[string]i
 	return suscripciones, cursor.Err()
 }
 
-func (r *suscripcionRepository) Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error {
+func (r *suscripcionRepository) Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}, evento *entity.EventoSuscripcion) error {
 	filter := bson.M{"_id": id}
-	update := bson.M{"$set": updates}
+	update := conEventoPendiente(bson.M{"$set": updates}, evento)
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -142,9 +144,21 @@ func (r *suscripcionRepository) Count(ctx context.Context, filters map[string]in
 }
 
 func (r *suscripcionRepository) GetActiveSuscripcionByUserID(ctx context.Context, userID primitive.ObjectID) (*entity.Suscripcion, error) {
+	// Una suscripción pausada sigue vigente aunque su fecha_fin haya pasado,
+	// porque se extiende al reanudarla
 	filter := bson.M{
 		"usuario_id": userID,
-		"estado":     entity.EstadoSuscripcionActiva,
+		"$or": []bson.M{
+			{
+				"estado": bson.M{"$in": []string{
+					entity.EstadoSuscripcionPendientePago,
+					entity.EstadoSuscripcionActiva,
+					entity.EstadoSuscripcionEnPrueba,
+				}},
+				"fecha_fin": bson.M{"$gt": time.Now()},
+			},
+			{"estado": entity.EstadoSuscripcionPausada},
+		},
 	}
 
 	var suscripcion entity.Suscripcion
@@ -161,7 +175,12 @@ func (r *suscripcionRepository) GetActiveSuscripcionByUserID(ctx context.Context
 func (r *suscripcionRepository) CountActiveSuscripcionesByPlan(ctx context.Context, planID primitive.ObjectID) (int64, error) {
 	filter := bson.M{
 		"plan_id": planID,
-		"estado":  entity.EstadoSuscripcionActiva,
+		"estado": bson.M{"$in": []string{
+			entity.EstadoSuscripcionPendientePago,
+			entity.EstadoSuscripcionActiva,
+			entity.EstadoSuscripcionEnPrueba,
+			entity.EstadoSuscripcionPausada,
+		}},
 	}
 
 	return r.collection.CountDocuments(ctx, filter)
@@ -213,3 +232,454 @@ func (r *suscripcionRepository) GetSuscripcionesWithDetails(ctx context.Context,
 
 	return results, nil
 }
+
+// GetExpired devuelve, ordenadas por _id y a partir de afterID, las
+// suscripciones activas cuya fecha_fin ya pasó.
+func (r *suscripcionRepository) GetExpired(ctx context.Context, now time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error) {
+	filter := bson.M{
+		"estado":    entity.EstadoSuscripcionActiva,
+		"fecha_fin": bson.M{"$lte": now},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suscripciones []*entity.Suscripcion
+	for cursor.Next(ctx) {
+		var suscripcion entity.Suscripcion
+		if err := cursor.Decode(&suscripcion); err != nil {
+			continue
+		}
+		suscripciones = append(suscripciones, &suscripcion)
+	}
+
+	return suscripciones, cursor.Err()
+}
+
+// GetRenewable devuelve, ordenadas por _id y a partir de afterID, las
+// suscripciones activas con renovación automática que vencen antes de until.
+func (r *suscripcionRepository) GetRenewable(ctx context.Context, until time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error) {
+	filter := bson.M{
+		"estado":       entity.EstadoSuscripcionActiva,
+		"auto_renovar": true,
+		"fecha_fin":    bson.M{"$lte": until},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suscripciones []*entity.Suscripcion
+	for cursor.Next(ctx) {
+		var suscripcion entity.Suscripcion
+		if err := cursor.Decode(&suscripcion); err != nil {
+			continue
+		}
+		suscripciones = append(suscripciones, &suscripcion)
+	}
+
+	return suscripciones, cursor.Err()
+}
+
+// GetPendientesPago devuelve las suscripciones creadas antes de creadoAntes
+// que todavía esperan el pago del alta.
+func (r *suscripcionRepository) GetPendientesPago(ctx context.Context, creadoAntes time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error) {
+	filter := bson.M{
+		"estado":    entity.EstadoSuscripcionPendientePago,
+		"creado_en": bson.M{"$lte": creadoAntes},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suscripciones []*entity.Suscripcion
+	for cursor.Next(ctx) {
+		var suscripcion entity.Suscripcion
+		if err := cursor.Decode(&suscripcion); err != nil {
+			continue
+		}
+		suscripciones = append(suscripciones, &suscripcion)
+	}
+
+	return suscripciones, cursor.Err()
+}
+
+// GetPorVencer devuelve las suscripciones que terminan en (desde, hasta] y no
+// se van a renovar solas.
+func (r *suscripcionRepository) GetPorVencer(ctx context.Context, desde, hasta time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error) {
+	filter := bson.M{
+		"estado": bson.M{"$in": bson.A{
+			entity.EstadoSuscripcionActiva,
+			entity.EstadoSuscripcionEnPrueba,
+		}},
+		"fecha_fin": bson.M{"$gt": desde, "$lte": hasta},
+		"$or": bson.A{
+			bson.M{"auto_renovar": false},
+			bson.M{"cancelar_al_final": true},
+		},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suscripciones []*entity.Suscripcion
+	for cursor.Next(ctx) {
+		var suscripcion entity.Suscripcion
+		if err := cursor.Decode(&suscripcion); err != nil {
+			continue
+		}
+		suscripciones = append(suscripciones, &suscripcion)
+	}
+
+	return suscripciones, cursor.Err()
+}
+
+// UpdateIfMatch aplica updates solo si el documento todavía tiene los valores
+// de expected, para que dos procesos no modifiquen la misma suscripción a la vez.
+// evento, si no es nil, queda pendiente de copiar al historial en la misma
+// escritura; lo mismo vale para el resto de las actualizaciones.
+func (r *suscripcionRepository) UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}, evento *entity.EventoSuscripcion) error {
+	return r.updateIfMatch(ctx, id, expected, bson.M{"$set": updates}, evento)
+}
+
+// ApplyTransition funciona como UpdateIfMatch y además agrega la transición al
+// historial de estados de la suscripción.
+func (r *suscripcionRepository) ApplyTransition(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}, transicion entity.TransicionEstado, evento *entity.EventoSuscripcion) error {
+	return r.updateIfMatch(ctx, id, expected, bson.M{
+		"$set":  updates,
+		"$push": bson.M{"transiciones": transicion},
+	}, evento)
+}
+
+func (r *suscripcionRepository) updateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, update bson.M, evento *entity.EventoSuscripcion) error {
+	filter := bson.M{"_id": id}
+	for k, v := range expected {
+		filter[k] = v
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, conEventoPendiente(update, evento))
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrSuscripcionModificada
+	}
+
+	return nil
+}
+
+// conEventoPendiente agrega evento a eventos_pendientes en la misma escritura
+// que update. Así el cambio y su entrada en el historial no se pueden separar
+// aunque el proceso se caiga antes de copiar el evento a su colección. Con
+// evento nil devuelve update sin tocar.
+func conEventoPendiente(update bson.M, evento *entity.EventoSuscripcion) bson.M {
+	if evento == nil {
+		return update
+	}
+
+	set := bson.M{}
+	switch actual := update["$set"].(type) {
+	case bson.M:
+		for k, v := range actual {
+			set[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range actual {
+			set[k] = v
+		}
+	}
+	set["eventos_pendientes."+evento.ID.Hex()] = evento
+
+	result := bson.M{}
+	for k, v := range update {
+		result[k] = v
+	}
+	result["$set"] = set
+	return result
+}
+
+// GetConEventosPendientes devuelve, ordenadas por _id y a partir de afterID,
+// las suscripciones con eventos del historial todavía sin copiar.
+func (r *suscripcionRepository) GetConEventosPendientes(ctx context.Context, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error) {
+	filter := bson.M{
+		"eventos_pendientes": bson.M{"$exists": true, "$ne": bson.M{}},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suscripciones []*entity.Suscripcion
+	for cursor.Next(ctx) {
+		var suscripcion entity.Suscripcion
+		if err := cursor.Decode(&suscripcion); err != nil {
+			continue
+		}
+		suscripciones = append(suscripciones, &suscripcion)
+	}
+
+	return suscripciones, cursor.Err()
+}
+
+// QuitarEventoPendiente borra de la suscripción un evento que ya se copió al
+// historial.
+func (r *suscripcionRepository) QuitarEventoPendiente(ctx context.Context, id, eventoID primitive.ObjectID) error {
+	update := bson.M{"$unset": bson.M{"eventos_pendientes." + eventoID.Hex(): ""}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// HasUsedTrial indica si el usuario ya tuvo una prueba gratuita; con planID nil
+// se consideran las pruebas de cualquier plan.
+func (r *suscripcionRepository) HasUsedTrial(ctx context.Context, userID primitive.ObjectID, planID *primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"usuario_id": userID,
+		"uso_prueba": true,
+	}
+	if planID != nil {
+		filter["plan_id"] = *planID
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *suscripcionRepository) GetEndedTrials(ctx context.Context, now time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error) {
+	filter := bson.M{
+		"estado":     entity.EstadoSuscripcionEnPrueba,
+		"fin_prueba": bson.M{"$lte": now},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suscripciones []*entity.Suscripcion
+	for cursor.Next(ctx) {
+		var suscripcion entity.Suscripcion
+		if err := cursor.Decode(&suscripcion); err != nil {
+			continue
+		}
+		suscripciones = append(suscripciones, &suscripcion)
+	}
+
+	return suscripciones, cursor.Err()
+}
+
+func (r *suscripcionRepository) GetPausesEnded(ctx context.Context, now time.Time, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error) {
+	filter := bson.M{
+		"estado":        entity.EstadoSuscripcionPausada,
+		"pausada_hasta": bson.M{"$lte": now},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suscripciones []*entity.Suscripcion
+	for cursor.Next(ctx) {
+		var suscripcion entity.Suscripcion
+		if err := cursor.Decode(&suscripcion); err != nil {
+			continue
+		}
+		suscripciones = append(suscripciones, &suscripcion)
+	}
+
+	return suscripciones, cursor.Err()
+}
+
+// GetVigentesByPlan recorre por lotes las suscripciones del plan que todavía no
+// terminaron: las que se pueden migrar de versión.
+func (r *suscripcionRepository) GetVigentesByPlan(ctx context.Context, planID primitive.ObjectID, afterID primitive.ObjectID, limit int) ([]*entity.Suscripcion, error) {
+	filter := bson.M{
+		"plan_id": planID,
+		"estado": bson.M{"$in": []string{
+			entity.EstadoSuscripcionPendientePago,
+			entity.EstadoSuscripcionActiva,
+			entity.EstadoSuscripcionEnPrueba,
+			entity.EstadoSuscripcionPausada,
+		}},
+	}
+	if !afterID.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+	opts.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suscripciones []*entity.Suscripcion
+	for cursor.Next(ctx) {
+		var suscripcion entity.Suscripcion
+		if err := cursor.Decode(&suscripcion); err != nil {
+			continue
+		}
+		suscripciones = append(suscripciones, &suscripcion)
+	}
+
+	return suscripciones, cursor.Err()
+}
+
+// GetActiveByAsiento busca la suscripción vigente en la que el usuario ocupa un
+// asiento, que puede ser de otro titular.
+func (r *suscripcionRepository) GetActiveByAsiento(ctx context.Context, userID primitive.ObjectID) (*entity.Suscripcion, error) {
+	filter := bson.M{
+		"asientos.usuario_id": userID,
+		"$or": []bson.M{
+			{
+				"estado": bson.M{"$in": []string{
+					entity.EstadoSuscripcionPendientePago,
+					entity.EstadoSuscripcionActiva,
+					entity.EstadoSuscripcionEnPrueba,
+				}},
+				"fecha_fin": bson.M{"$gt": time.Now()},
+			},
+			{"estado": entity.EstadoSuscripcionPausada},
+		},
+	}
+
+	var suscripcion entity.Suscripcion
+	err := r.collection.FindOne(ctx, filter).Decode(&suscripcion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &suscripcion, nil
+}
+
+// CambiarCantidad funciona como UpdateIfMatch, pero además exige que la
+// cantidad de asientos no haya cambiado y que los asientos asignados entren en
+// la cantidad nueva.
+func (r *suscripcionRepository) CambiarCantidad(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, cantidadAnterior, cantidadNueva int, updates map[string]interface{}, evento *entity.EventoSuscripcion) error {
+	filter := bson.M{
+		"cantidad": cantidadAnterior,
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$size": bson.M{"$ifNull": bson.A{"$asientos", bson.A{}}}},
+			cantidadNueva,
+		}},
+	}
+	// Las suscripciones anteriores a los asientos no guardan la cantidad
+	if cantidadAnterior == 1 {
+		filter["cantidad"] = bson.M{"$in": bson.A{nil, 0, 1}}
+	}
+	for k, v := range expected {
+		filter[k] = v
+	}
+
+	return r.updateIfMatch(ctx, id, filter, bson.M{"$set": updates}, evento)
+}
+
+// AsignarAsiento agrega al usuario solo si no tiene ya un asiento en la
+// suscripción y queda alguno libre.
+func (r *suscripcionRepository) AsignarAsiento(ctx context.Context, id primitive.ObjectID, asignacion entity.AsignacionAsiento, evento *entity.EventoSuscripcion) error {
+	filter := bson.M{
+		"asientos.usuario_id": bson.M{"$ne": asignacion.UsuarioID},
+		"$expr": bson.M{"$lt": bson.A{
+			bson.M{"$size": bson.M{"$ifNull": bson.A{"$asientos", bson.A{}}}},
+			bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$cantidad", 1}}, 1}},
+		}},
+	}
+
+	return r.updateIfMatch(ctx, id, filter, bson.M{"$push": bson.M{"asientos": asignacion}}, evento)
+}
+
+func (r *suscripcionRepository) LiberarAsiento(ctx context.Context, id, userID primitive.ObjectID, evento *entity.EventoSuscripcion) error {
+	filter := bson.M{"asientos.usuario_id": userID}
+	return r.updateIfMatch(ctx, id, filter, bson.M{"$pull": bson.M{"asientos": bson.M{"usuario_id": userID}}}, evento)
+}
+
+// AgregarComplemento funciona como UpdateIfMatch, pero solo agrega el
+// complemento si la suscripción no lo tiene ya.
+func (r *suscripcionRepository) AgregarComplemento(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, complemento entity.ComplementoSuscripcion, evento *entity.EventoSuscripcion) error {
+	filter := bson.M{"complementos.complemento_id": bson.M{"$ne": complemento.ComplementoID}}
+	for k, v := range expected {
+		filter[k] = v
+	}
+
+	return r.updateIfMatch(ctx, id, filter, bson.M{"$push": bson.M{"complementos": complemento}}, evento)
+}
+
+func (r *suscripcionRepository) QuitarComplemento(ctx context.Context, id, complementoID primitive.ObjectID, evento *entity.EventoSuscripcion) error {
+	filter := bson.M{"complementos.complemento_id": complementoID}
+	return r.updateIfMatch(ctx, id, filter, bson.M{"$pull": bson.M{"complementos": bson.M{"complemento_id": complementoID}}}, evento)
+}
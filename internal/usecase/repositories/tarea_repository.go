@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type tareaRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTareaRepository(db *mongo.Database) TareaRepository {
+	return &tareaRepository{
+		collection: db.Collection("tareas_programadas"),
+	}
+}
+
+// AcquireLock toma el lease de la tarea si nadie más lo tiene. Cuando force es
+// false además exige que ya haya pasado la próxima ejecución programada, de
+// modo que varias réplicas no repitan la misma tarea dentro de un intervalo.
+func (r *tareaRepository) AcquireLock(ctx context.Context, nombre, titular string, lease, intervalo time.Duration, force bool) (bool, error) {
+	now := time.Now()
+
+	filter := bson.M{
+		"_id":             nombre,
+		"bloqueada_hasta": bson.M{"$lt": now},
+	}
+
+	set := bson.M{
+		"titular":         titular,
+		"bloqueada_hasta": now.Add(lease),
+	}
+	update := bson.M{"$set": set}
+
+	if force {
+		update["$setOnInsert"] = bson.M{"proxima_ejecucion": now.Add(intervalo)}
+	} else {
+		filter["proxima_ejecucion"] = bson.M{"$lte": now}
+		set["proxima_ejecucion"] = now.Add(intervalo)
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		// El documento existe pero no cumple el filtro: otra réplica tiene el lease
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *tareaRepository) ReleaseLock(ctx context.Context, nombre, titular string, runErr error) error {
+	ultimoError := ""
+	if runErr != nil {
+		ultimoError = runErr.Error()
+	}
+
+	filter := bson.M{"_id": nombre, "titular": titular}
+	update := bson.M{"$set": bson.M{
+		"bloqueada_hasta":  time.Time{},
+		"ultima_ejecucion": time.Now(),
+		"ultimo_error":     ultimoError,
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *tareaRepository) GetAll(ctx context.Context) ([]*entity.TareaProgramada, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{"_id": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tareas []*entity.TareaProgramada
+	for cursor.Next(ctx) {
+		var tarea entity.TareaProgramada
+		if err := cursor.Decode(&tarea); err != nil {
+			continue
+		}
+		tareas = append(tareas, &tarea)
+	}
+
+	return tareas, cursor.Err()
+}
@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errTasaImpuestoNoEncontrada = errors.New("tasa de impuesto no encontrada")
+
+type tasaImpuestoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTasaImpuestoRepository(db *mongo.Database) TasaImpuestoRepository {
+	return &tasaImpuestoRepository{
+		collection: db.Collection("tasas_impuesto"),
+	}
+}
+
+func (r *tasaImpuestoRepository) Create(ctx context.Context, tasa *entity.TasaImpuesto) error {
+	if tasa.ID.IsZero() {
+		tasa.ID = primitive.NewObjectID()
+	}
+	if tasa.CreadoEn.IsZero() {
+		tasa.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, tasa)
+	return err
+}
+
+func (r *tasaImpuestoRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.TasaImpuesto, error) {
+	var tasa entity.TasaImpuesto
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&tasa)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errTasaImpuestoNoEncontrada
+		}
+		return nil, err
+	}
+	return &tasa, nil
+}
+
+// GetAplicable devuelve la tasa activa de la región o, si no hay, la del país.
+// Devuelve nil sin error cuando el lugar no tiene impuesto configurado.
+func (r *tasaImpuestoRepository) GetAplicable(ctx context.Context, pais, region string) (*entity.TasaImpuesto, error) {
+	filter := bson.M{
+		"pais":   pais,
+		"activo": true,
+		"$or": bson.A{
+			bson.M{"region": region},
+			bson.M{"region": bson.M{"$exists": false}},
+			bson.M{"region": ""},
+		},
+	}
+
+	// Las tasas sin región quedan al final
+	opts := options.FindOne().SetSort(bson.M{"region": -1})
+
+	var tasa entity.TasaImpuesto
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&tasa)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tasa, nil
+}
+
+// Exists indica si ya hay una tasa activa para el mismo país y región.
+func (r *tasaImpuestoRepository) Exists(ctx context.Context, pais, region string, excluir primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"pais":   pais,
+		"activo": true,
+		"_id":    bson.M{"$ne": excluir},
+	}
+	if region == "" {
+		filter["region"] = bson.M{"$in": bson.A{nil, ""}}
+	} else {
+		filter["region"] = region
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *tasaImpuestoRepository) GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.TasaImpuesto, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: "pais", Value: 1}, {Key: "region", Value: 1}})
+	opts.SetLimit(int64(limit))
+	opts.SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tasas []*entity.TasaImpuesto
+	for cursor.Next(ctx) {
+		var tasa entity.TasaImpuesto
+		if err := cursor.Decode(&tasa); err != nil {
+			continue
+		}
+		tasas = append(tasas, &tasa)
+	}
+
+	return tasas, cursor.Err()
+}
+
+func (r *tasaImpuestoRepository) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	filter := bson.M{}
+	for k, v := range filters {
+		filter[k] = v
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+func (r *tasaImpuestoRepository) Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errTasaImpuestoNoEncontrada
+	}
+
+	return nil
+}
+
+// Delete desactiva la tasa; las facturas que la usaron guardan su propia copia.
+func (r *tasaImpuestoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.Update(ctx, id, map[string]interface{}{"activo": false})
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrCuotaExcedida         = errors.New("el consumo supera la cuota del plan")
+	errEventoUsoNoEncontrado = errors.New("evento de uso no encontrado")
+)
+
+type eventoUsoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEventoUsoRepository(db *mongo.Database) EventoUsoRepository {
+	return &eventoUsoRepository{
+		collection: db.Collection("eventos_uso"),
+	}
+}
+
+// Reservar inserta el evento en estado procesando por el tiempo de reserva y
+// devuelve false si la clave de idempotencia ya se usó en la suscripción. Una
+// reserva vencida se reemplaza por la nueva: el proceso que la tenía se cayó
+// antes de registrar el evento o liberarlo.
+func (r *eventoUsoRepository) Reservar(ctx context.Context, evento *entity.EventoUso, reserva time.Duration) (bool, error) {
+	now := time.Now()
+	if evento.CreadoEn.IsZero() {
+		evento.CreadoEn = now
+	}
+	evento.Estado = entity.EstadoEventoUsoProcesando
+	evento.ReservadoHasta = now.Add(reserva)
+
+	_, err := r.collection.InsertOne(ctx, evento)
+	if err == nil {
+		return true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	filter := bson.M{
+		"_id":             evento.ID,
+		"estado":          entity.EstadoEventoUsoProcesando,
+		"reservado_hasta": bson.M{"$lte": now},
+	}
+
+	result, err := r.collection.ReplaceOne(ctx, filter, evento)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r *eventoUsoRepository) GetByID(ctx context.Context, id string) (*entity.EventoUso, error) {
+	var evento entity.EventoUso
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&evento)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errEventoUsoNoEncontrado
+		}
+		return nil, err
+	}
+	return &evento, nil
+}
+
+func (r *eventoUsoRepository) MarcarRegistrado(ctx context.Context, id string) error {
+	update := bson.M{"$set": bson.M{"estado": entity.EstadoEventoUsoRegistrado}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// Liberar borra la reserva de un evento que no se pudo contar, para que un
+// reintento con la misma clave vuelva a intentarlo.
+func (r *eventoUsoRepository) Liberar(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "estado": entity.EstadoEventoUsoProcesando})
+	return err
+}
+
+type usoPeriodoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUsoPeriodoRepository(db *mongo.Database) UsoPeriodoRepository {
+	return &usoPeriodoRepository{
+		collection: db.Collection("uso_periodos"),
+	}
+}
+
+// Consumir suma cantidad al acumulado del periodo solo si no supera limite
+// (LimiteIlimitado no acota). El filtro y el $inc se evalúan juntos, así que
+// dos consumos simultáneos no pueden pasarse de la cuota.
+func (r *usoPeriodoRepository) Consumir(ctx context.Context, uso *entity.UsoPeriodo, cantidad, limite int64) (*entity.UsoPeriodo, error) {
+	filter := bson.M{"_id": uso.ID}
+	if limite != entity.LimiteIlimitado {
+		if cantidad > limite {
+			return nil, ErrCuotaExcedida
+		}
+		filter["consumido"] = bson.M{"$lte": limite - cantidad}
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"consumido": cantidad},
+		"$set": bson.M{"actualizado_en": time.Now()},
+		"$setOnInsert": bson.M{
+			"suscripcion_id": uso.SuscripcionID,
+			"usuario_id":     uso.UsuarioID,
+			"metrica":        uso.Metrica,
+			"periodo_inicio": uso.PeriodoInicio,
+			"periodo_fin":    uso.PeriodoFin,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	// Si el documento existe pero no cumple el filtro, el upsert intenta
+	// insertarlo y choca con el _id: es la cuota agotada. Un primer choque
+	// también puede ser otro consumo que creó el documento a la vez, por eso
+	// se reintenta una vez.
+	for intento := 0; intento < 2; intento++ {
+		var result entity.UsoPeriodo
+		err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+		if err == nil {
+			return &result, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+	}
+
+	return nil, ErrCuotaExcedida
+}
+
+// Revertir descuenta un consumo cuyo evento no se pudo confirmar.
+func (r *usoPeriodoRepository) Revertir(ctx context.Context, id string, cantidad int64) error {
+	filter := bson.M{"_id": id, "consumido": bson.M{"$gte": cantidad}}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"consumido": -cantidad}})
+	return err
+}
+
+// GetByID devuelve nil sin error si todavía no hubo consumo en el periodo.
+func (r *usoPeriodoRepository) GetByID(ctx context.Context, id string) (*entity.UsoPeriodo, error) {
+	var uso entity.UsoPeriodo
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&uso)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &uso, nil
+}
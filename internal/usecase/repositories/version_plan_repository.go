@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrVersionPlanExistente = errors.New("el plan fue modificado por otro proceso")
+
+type versionPlanRepository struct {
+	collection *mongo.Collection
+}
+
+func NewVersionPlanRepository(db *mongo.Database) VersionPlanRepository {
+	return &versionPlanRepository{
+		collection: db.Collection("versiones_plan"),
+	}
+}
+
+func (r *versionPlanRepository) Create(ctx context.Context, version *entity.VersionPlan) error {
+	if version.CreadoEn.IsZero() {
+		version.CreadoEn = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, version)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrVersionPlanExistente
+	}
+	return err
+}
+
+// GetByNumero devuelve nil sin error si el plan nunca guardó esa versión, como
+// la primera de un plan que no se editó desde que existe el versionado.
+func (r *versionPlanRepository) GetByNumero(ctx context.Context, planID primitive.ObjectID, numero int) (*entity.VersionPlan, error) {
+	var version entity.VersionPlan
+	err := r.collection.FindOne(ctx, bson.M{"_id": entity.VersionPlanID(planID, numero)}).Decode(&version)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *versionPlanRepository) GetByPlan(ctx context.Context, planID primitive.ObjectID) ([]*entity.VersionPlan, error) {
+	opts := options.Find()
+	opts.SetSort(bson.M{"numero": -1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"plan_id": planID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var versiones []*entity.VersionPlan
+	for cursor.Next(ctx) {
+		var version entity.VersionPlan
+		if err := cursor.Decode(&version); err != nil {
+			continue
+		}
+		versiones = append(versiones, &version)
+	}
+
+	return versiones, cursor.Err()
+}
+
+func (r *versionPlanRepository) Delete(ctx context.Context, planID primitive.ObjectID, numero int) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": entity.VersionPlanID(planID, numero)})
+	return err
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type complementoService struct {
+	complementoRepo repositories.ComplementoRepository
+	planRepo        repositories.PlanRepository
+}
+
+func NewComplementoService(complementoRepo repositories.ComplementoRepository, planRepo repositories.PlanRepository) ComplementoService {
+	return &complementoService{
+		complementoRepo: complementoRepo,
+		planRepo:        planRepo,
+	}
+}
+
+func (s *complementoService) CreateComplemento(ctx context.Context, req *dto.CreateComplementoRequest) (*dto.ComplementoDTO, error) {
+	precios, err := preciosDesdeDTO(req.Precios)
+	if err != nil {
+		return nil, err
+	}
+
+	derechos, err := derechosDesdeDTO(req.Derechos)
+	if err != nil {
+		return nil, err
+	}
+
+	planesIDs, err := s.parsePlanesIDs(ctx, req.PlanesIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	complemento := &entity.Complemento{
+		Nombre:      strings.TrimSpace(req.Nombre),
+		Descripcion: strings.TrimSpace(req.Descripcion),
+		Precios:     precios,
+		PlanesIDs:   planesIDs,
+		Derechos:    derechos,
+		Activo:      true,
+		CreadoEn:    time.Now(),
+	}
+
+	if err := s.complementoRepo.Create(ctx, complemento); err != nil {
+		return nil, err
+	}
+
+	return complementoToDTO(complemento), nil
+}
+
+// GetAllComplementos lista el catálogo; con planID solo los complementos
+// compatibles con ese plan.
+func (s *complementoService) GetAllComplementos(ctx context.Context, planID string, showInactive bool, limit, offset int) ([]*dto.ComplementoDTO, int64, error) {
+	filters := make(map[string]interface{})
+	if !showInactive {
+		filters["activo"] = true
+	}
+	if planID != "" {
+		objectID, err := primitive.ObjectIDFromHex(planID)
+		if err != nil {
+			return nil, 0, errors.New("ID de plan inválido")
+		}
+		filters["planes_ids"] = objectID
+	}
+
+	complementos, err := s.complementoRepo.GetAll(ctx, filters, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.complementoRepo.Count(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var dtos []*dto.ComplementoDTO
+	for _, complemento := range complementos {
+		dtos = append(dtos, complementoToDTO(complemento))
+	}
+
+	return dtos, total, nil
+}
+
+func (s *complementoService) GetComplementoByID(ctx context.Context, id string) (*dto.ComplementoDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de complemento inválido")
+	}
+
+	complemento, err := s.complementoRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return complementoToDTO(complemento), nil
+}
+
+// UpdateComplemento cambia el catálogo. Las suscripciones guardan una copia
+// del precio y los derechos, que se actualiza al renovarse.
+func (s *complementoService) UpdateComplemento(ctx context.Context, id string, req *dto.UpdateComplementoRequest) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID de complemento inválido")
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Nombre != nil {
+		updates["nombre"] = strings.TrimSpace(*req.Nombre)
+	}
+
+	if req.Descripcion != nil {
+		updates["descripcion"] = strings.TrimSpace(*req.Descripcion)
+	}
+
+	if req.Precios != nil {
+		precios, err := preciosDesdeDTO(*req.Precios)
+		if err != nil {
+			return err
+		}
+		updates["precios"] = precios
+	}
+
+	if req.PlanesIDs != nil {
+		planesIDs, err := s.parsePlanesIDs(ctx, *req.PlanesIDs)
+		if err != nil {
+			return err
+		}
+		updates["planes_ids"] = planesIDs
+	}
+
+	if req.Derechos != nil {
+		derechos, err := derechosDesdeDTO(*req.Derechos)
+		if err != nil {
+			return err
+		}
+		updates["derechos"] = derechos
+	}
+
+	if req.Activo != nil {
+		updates["activo"] = *req.Activo
+	}
+
+	if len(updates) == 0 {
+		return errors.New("no hay campos para actualizar")
+	}
+
+	return s.complementoRepo.Update(ctx, objectID, updates)
+}
+
+func (s *complementoService) DeleteComplemento(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID de complemento inválido")
+	}
+
+	return s.complementoRepo.Delete(ctx, objectID)
+}
+
+func (s *complementoService) parsePlanesIDs(ctx context.Context, ids []string) ([]primitive.ObjectID, error) {
+	planesIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		planID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, errors.New("ID de plan inválido")
+		}
+		if _, err := s.planRepo.GetByID(ctx, planID); err != nil {
+			return nil, err
+		}
+		planesIDs = append(planesIDs, planID)
+	}
+	return planesIDs, nil
+}
+
+func complementoToDTO(complemento *entity.Complemento) *dto.ComplementoDTO {
+	result := &dto.ComplementoDTO{
+		ID:          complemento.ID.Hex(),
+		Nombre:      complemento.Nombre,
+		Descripcion: complemento.Descripcion,
+		Precios:     preciosToDTO(complemento.Precios),
+		PlanesIDs:   make([]string, 0, len(complemento.PlanesIDs)),
+		Derechos:    derechosToDTO(complemento.Derechos),
+		Activo:      complemento.Activo,
+		CreadoEn:    complemento.CreadoEn,
+	}
+
+	for _, planID := range complemento.PlanesIDs {
+		result.PlanesIDs = append(result.PlanesIDs, planID.Hex())
+	}
+
+	return result
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sw2p2go/config"
+	"sw2p2go/internal/actor"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/payment"
+	"sw2p2go/internal/usecase/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrFacturaNoReembolsable = errors.New("la factura no tiene un cobro pagado que se pueda reembolsar")
+
+type creditoService struct {
+	creditoRepo repositories.CreditoRepository
+	userRepo    repositories.UsuarioRepository
+	facturaRepo repositories.FacturaRepository
+	gateway     payment.Gateway
+	cfg         *config.Config
+}
+
+func NewCreditoService(
+	creditoRepo repositories.CreditoRepository,
+	userRepo repositories.UsuarioRepository,
+	facturaRepo repositories.FacturaRepository,
+	gateway payment.Gateway,
+	cfg *config.Config,
+) CreditoService {
+	return &creditoService{
+		creditoRepo: creditoRepo,
+		userRepo:    userRepo,
+		facturaRepo: facturaRepo,
+		gateway:     gateway,
+		cfg:         cfg,
+	}
+}
+
+func (s *creditoService) GetSaldo(ctx context.Context, userID string) (*dto.SaldoCreditoDTO, error) {
+	usuarioID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	saldos, err := s.creditoRepo.GetSaldos(ctx, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.SaldoCreditoDTO{
+		UsuarioID: userID,
+		Saldos:    make([]dto.SaldoMonedaDTO, 0, len(saldos)),
+	}
+	for moneda, saldo := range saldos {
+		result.Saldos = append(result.Saldos, dto.SaldoMonedaDTO{Moneda: moneda, Saldo: saldo})
+	}
+	sort.Slice(result.Saldos, func(i, k int) bool {
+		return result.Saldos[i].Moneda < result.Saldos[k].Moneda
+	})
+
+	return result, nil
+}
+
+func (s *creditoService) GetMovimientos(ctx context.Context, userID, moneda string, limit, offset int) ([]*dto.MovimientoCreditoDTO, int64, error) {
+	usuarioID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, errors.New("ID de usuario inválido")
+	}
+
+	filters := map[string]interface{}{"usuario_id": usuarioID}
+	if moneda != "" {
+		filters["moneda"] = strings.ToUpper(moneda)
+	}
+
+	movimientos, err := s.creditoRepo.GetAll(ctx, filters, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.creditoRepo.Count(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dtos := make([]*dto.MovimientoCreditoDTO, 0, len(movimientos))
+	for _, movimiento := range movimientos {
+		dtos = append(dtos, movimientoCreditoToDTO(movimiento))
+	}
+
+	return dtos, total, nil
+}
+
+// OtorgarCredito suma crédito a favor del usuario por decisión de un
+// administrador, que queda como actor del movimiento.
+func (s *creditoService) OtorgarCredito(ctx context.Context, userID string, req *dto.OtorgarCreditoRequest) (*dto.MovimientoCreditoDTO, error) {
+	usuarioID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, usuarioID); err != nil {
+		return nil, errors.New("usuario no encontrado")
+	}
+
+	moneda, err := normalizarMoneda(req.Moneda, s.cfg.Suscripciones.MonedaDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	movimiento := &entity.MovimientoCredito{
+		UsuarioID: usuarioID,
+		Moneda:    moneda,
+		Monto:     req.Monto,
+		Motivo:    entity.MotivoCreditoAjuste,
+		Nota:      strings.TrimSpace(req.Nota),
+	}
+
+	if req.SuscripcionID != "" {
+		suscripcionID, err := primitive.ObjectIDFromHex(req.SuscripcionID)
+		if err != nil {
+			return nil, errors.New("ID de suscripción inválido")
+		}
+		movimiento.SuscripcionID = &suscripcionID
+	}
+	if req.FacturaID != "" {
+		facturaID, err := primitive.ObjectIDFromHex(req.FacturaID)
+		if err != nil {
+			return nil, errors.New("ID de factura inválido")
+		}
+		movimiento.FacturaID = &facturaID
+	}
+
+	if err := registrarCredito(ctx, s.creditoRepo, movimiento); err != nil {
+		return nil, err
+	}
+
+	return movimientoCreditoToDTO(movimiento), nil
+}
+
+// Reembolsar devuelve como dinero parte del crédito a favor del usuario,
+// reembolsando el cobro de una de sus facturas pagadas. Lo reembolsado se suma
+// a la factura y el crédito se descuenta antes de llamar a la pasarela, así dos
+// reembolsos simultáneos no pasan del total; si el reembolso falla, la factura
+// se restablece y un movimiento compensatorio devuelve el crédito al saldo.
+// Para reembolsar un cobro sin crédito previo primero se otorga el crédito con
+// su justificación.
+func (s *creditoService) Reembolsar(ctx context.Context, userID string, req *dto.ReembolsarCreditoRequest) (*dto.MovimientoCreditoDTO, error) {
+	usuarioID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	facturaID, err := primitive.ObjectIDFromHex(req.FacturaID)
+	if err != nil {
+		return nil, errors.New("ID de factura inválido")
+	}
+
+	factura, err := s.facturaRepo.GetByID(ctx, facturaID)
+	if err != nil {
+		return nil, err
+	}
+	if factura.UsuarioID != usuarioID {
+		return nil, errors.New("factura no encontrada")
+	}
+	if factura.Estado != entity.EstadoFacturaPagada || factura.CobroID == "" || req.Monto > factura.Total-factura.Reembolsado {
+		return nil, ErrFacturaNoReembolsable
+	}
+
+	if err := s.facturaRepo.SumarReembolso(ctx, factura.ID, req.Monto); err != nil {
+		if errors.Is(err, repositories.ErrReembolsoExcedido) {
+			return nil, ErrFacturaNoReembolsable
+		}
+		return nil, err
+	}
+
+	movimiento := &entity.MovimientoCredito{
+		UsuarioID: usuarioID,
+		Moneda:    factura.Moneda,
+		Monto:     -req.Monto,
+		Motivo:    entity.MotivoCreditoReembolso,
+		FacturaID: &factura.ID,
+		Nota:      strings.TrimSpace(req.Nota),
+	}
+	if !factura.SuscripcionID.IsZero() {
+		movimiento.SuscripcionID = &factura.SuscripcionID
+	}
+	if err := registrarCredito(ctx, s.creditoRepo, movimiento); err != nil {
+		return nil, errors.Join(err, s.facturaRepo.SumarReembolso(ctx, factura.ID, -req.Monto))
+	}
+
+	reembolso, err := s.gateway.Reembolsar(ctx, factura.CobroID, req.Monto)
+	if err != nil {
+		reversion := &entity.MovimientoCredito{
+			UsuarioID:     usuarioID,
+			Moneda:        factura.Moneda,
+			Monto:         req.Monto,
+			Motivo:        entity.MotivoCreditoReembolsoRevertido,
+			SuscripcionID: movimiento.SuscripcionID,
+			FacturaID:     &factura.ID,
+			Nota:          err.Error(),
+		}
+		return nil, errors.Join(err, registrarCredito(ctx, s.creditoRepo, reversion), s.facturaRepo.SumarReembolso(ctx, factura.ID, -req.Monto))
+	}
+
+	if err := s.creditoRepo.AsociarReembolso(ctx, movimiento.ID, reembolso.ID); err != nil {
+		return nil, err
+	}
+	movimiento.ReembolsoID = reembolso.ID
+
+	return movimientoCreditoToDTO(movimiento), nil
+}
+
+// registrarCredito agrega el movimiento al libro con el actor de la operación.
+func registrarCredito(ctx context.Context, creditoRepo repositories.CreditoRepository, movimiento *entity.MovimientoCredito) error {
+	movimiento.Actor = actor.FromContext(ctx)
+	return creditoRepo.Registrar(ctx, movimiento)
+}
+
+// restituirCredito devuelve al saldo el crédito aplicado a una factura que se
+// anuló.
+func restituirCredito(ctx context.Context, creditoRepo repositories.CreditoRepository, factura *entity.Factura) error {
+	if factura.CreditoAplicado <= 0 {
+		return nil
+	}
+
+	movimiento := &entity.MovimientoCredito{
+		UsuarioID: factura.UsuarioID,
+		Moneda:    factura.Moneda,
+		Monto:     factura.CreditoAplicado,
+		Motivo:    entity.MotivoCreditoFacturaAnulada,
+		FacturaID: &factura.ID,
+	}
+	if !factura.SuscripcionID.IsZero() {
+		movimiento.SuscripcionID = &factura.SuscripcionID
+	}
+
+	return registrarCredito(ctx, creditoRepo, movimiento)
+}
+
+func movimientoCreditoToDTO(movimiento *entity.MovimientoCredito) *dto.MovimientoCreditoDTO {
+	result := &dto.MovimientoCreditoDTO{
+		ID:          movimiento.ID,
+		UsuarioID:   movimiento.UsuarioID.Hex(),
+		Moneda:      movimiento.Moneda,
+		Monto:       movimiento.Monto,
+		Saldo:       movimiento.Saldo,
+		Motivo:      movimiento.Motivo,
+		ReembolsoID: movimiento.ReembolsoID,
+		Nota:        movimiento.Nota,
+		Actor:       movimiento.Actor,
+		CreadoEn:    movimiento.CreadoEn,
+	}
+
+	if movimiento.SuscripcionID != nil {
+		result.SuscripcionID = movimiento.SuscripcionID.Hex()
+	}
+	if movimiento.FacturaID != nil {
+		result.FacturaID = movimiento.FacturaID.Hex()
+	}
+
+	return result
+}
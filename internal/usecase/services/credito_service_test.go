@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/payment"
+	"sw2p2go/internal/usecase/repositories"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReembolsar_SaldoDelLibro(t *testing.T) {
+	tests := []struct {
+		name            string
+		saldo           int64
+		monto           int64
+		wantErr         error
+		wantSaldo       int64
+		wantReembolsado int64
+		wantCobro       string
+		movimientos     int
+	}{
+		{"saldo suficiente", 600, 400, nil, 200, 400, payment.EstadoCobroReembolsado, 2},
+		{"saldo insuficiente", 300, 400, repositories.ErrSaldoInsuficiente, 300, 0, payment.EstadoCobroPagado, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usuarioID := primitive.NewObjectID()
+			factura := &entity.Factura{
+				ID:        primitive.NewObjectID(),
+				UsuarioID: usuarioID,
+				Estado:    entity.EstadoFacturaPagada,
+				Moneda:    "USD",
+				Total:     1000,
+			}
+
+			gateway := payment.NewFakeGateway(true)
+			cobro, err := gateway.CrearCobro(context.Background(), payment.CobroRequest{Referencia: factura.ID.Hex(), Monto: factura.Total, Moneda: factura.Moneda})
+			if err != nil {
+				t.Fatal(err)
+			}
+			factura.CobroID = cobro.ID
+
+			facturas := newFakeFacturaRepo(factura)
+			creditos := &fakeCreditoRepo{}
+			if err := creditos.Registrar(context.Background(), &entity.MovimientoCredito{
+				UsuarioID: usuarioID,
+				Moneda:    "USD",
+				Monto:     tt.saldo,
+				Motivo:    entity.MotivoCreditoAjuste,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			service := &creditoService{creditoRepo: creditos, facturaRepo: facturas, gateway: gateway}
+			_, err = service.Reembolsar(context.Background(), usuarioID.Hex(), &dto.ReembolsarCreditoRequest{
+				FacturaID: factura.ID.Hex(),
+				Monto:     tt.monto,
+				Nota:      "reembolso pedido por el usuario",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Reembolsar() error = %v, want %v", err, tt.wantErr)
+			}
+
+			ultimo, _ := creditos.GetUltimo(context.Background(), usuarioID, "USD")
+			if ultimo.Saldo != tt.wantSaldo {
+				t.Errorf("saldo = %d, want %d", ultimo.Saldo, tt.wantSaldo)
+			}
+			if n := len(creditos.movimientos); n != tt.movimientos {
+				t.Errorf("movimientos = %d, want %d", n, tt.movimientos)
+			}
+			guardada, _ := facturas.GetByID(context.Background(), factura.ID)
+			if guardada.Reembolsado != tt.wantReembolsado {
+				t.Errorf("reembolsado = %d, want %d", guardada.Reembolsado, tt.wantReembolsado)
+			}
+			cobro, _ = gateway.ObtenerCobro(context.Background(), factura.CobroID)
+			if cobro.Estado != tt.wantCobro {
+				t.Errorf("estado del cobro = %q, want %q", cobro.Estado, tt.wantCobro)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sw2p2go/config"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrCuponInactivo        = errors.New("cupón inactivo")
+	ErrCuponNoVigente       = errors.New("el cupón no está vigente")
+	ErrCuponNoAplica        = errors.New("el cupón no aplica a este plan")
+	ErrCuponMonedaDistinta  = errors.New("el cupón no aplica a esta moneda")
+	ErrCuponLimiteUsuario   = repositories.ErrCuponLimiteUsuario
+	ErrCodigoCuponExistente = errors.New("el código de cupón ya existe")
+)
+
+type cuponService struct {
+	cuponRepo repositories.CuponRepository
+	canjeRepo repositories.CanjeCuponRepository
+	planRepo  repositories.PlanRepository
+	cfg       *config.Config
+}
+
+func NewCuponService(
+	cuponRepo repositories.CuponRepository,
+	canjeRepo repositories.CanjeCuponRepository,
+	planRepo repositories.PlanRepository,
+	cfg *config.Config,
+) CuponService {
+	return &cuponService{
+		cuponRepo: cuponRepo,
+		canjeRepo: canjeRepo,
+		planRepo:  planRepo,
+		cfg:       cfg,
+	}
+}
+
+func (s *cuponService) CreateCupon(ctx context.Context, req *dto.CreateCuponRequest) (*dto.CuponDTO, error) {
+	codigo := normalizarCodigo(req.Codigo)
+
+	if req.Tipo == entity.TipoCuponPorcentaje && req.Valor > 100 {
+		return nil, errors.New("el porcentaje de descuento no puede superar 100")
+	}
+	if req.Duracion == entity.DuracionCuponRepetido && req.DuracionPeriodos == 0 {
+		return nil, errors.New("duracion_periodos es requerido para cupones repetidos")
+	}
+
+	// Un porcentaje sirve en cualquier moneda; un monto fijo solo en la suya
+	moneda := ""
+	if req.Tipo == entity.TipoCuponMontoFijo {
+		if req.Moneda == "" {
+			return nil, errors.New("moneda es requerida para cupones de monto fijo")
+		}
+		var err error
+		if moneda, err = normalizarMoneda(req.Moneda, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	cupon := &entity.Cupon{
+		Codigo:              codigo,
+		Descripcion:         strings.TrimSpace(req.Descripcion),
+		Tipo:                req.Tipo,
+		Valor:               req.Valor,
+		Moneda:              moneda,
+		Duracion:            req.Duracion,
+		MaxCanjes:           req.MaxCanjes,
+		MaxCanjesPorUsuario: req.MaxCanjesPorUsuario,
+		Activo:              true,
+		CreadoEn:            time.Now(),
+	}
+
+	if req.Duracion == entity.DuracionCuponRepetido {
+		cupon.DuracionPeriodos = req.DuracionPeriodos
+	}
+
+	var err error
+	if req.ValidoDesde != "" {
+		if cupon.ValidoDesde, err = parseFecha(req.ValidoDesde); err != nil {
+			return nil, err
+		}
+	}
+	if req.ValidoHasta != "" {
+		if cupon.ValidoHasta, err = parseFecha(req.ValidoHasta); err != nil {
+			return nil, err
+		}
+		if !cupon.ValidoDesde.IsZero() && !cupon.ValidoHasta.After(cupon.ValidoDesde) {
+			return nil, ErrRangoFechas
+		}
+	}
+
+	if cupon.PlanesIDs, err = s.parsePlanesIDs(ctx, req.PlanesIDs); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.cuponRepo.CodigoExists(ctx, codigo)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrCodigoCuponExistente
+	}
+
+	if err := s.cuponRepo.Create(ctx, cupon); err != nil {
+		return nil, err
+	}
+
+	return cuponToDTO(cupon), nil
+}
+
+func (s *cuponService) GetAllCupones(ctx context.Context, showInactive bool, limit, offset int) ([]*dto.CuponDTO, int64, error) {
+	filters := make(map[string]interface{})
+	if !showInactive {
+		filters["activo"] = true
+	}
+
+	cupones, err := s.cuponRepo.GetAll(ctx, filters, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.cuponRepo.Count(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var dtos []*dto.CuponDTO
+	for _, cupon := range cupones {
+		dtos = append(dtos, cuponToDTO(cupon))
+	}
+
+	return dtos, total, nil
+}
+
+func (s *cuponService) GetCuponByID(ctx context.Context, id string) (*dto.CuponDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de cupón inválido")
+	}
+
+	cupon, err := s.cuponRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cuponToDTO(cupon), nil
+}
+
+// UpdateCupon no permite cambiar el tipo, el valor ni la duración: los canjes
+// ya hechos guardan una copia y el cupón dejaría de describirlos.
+func (s *cuponService) UpdateCupon(ctx context.Context, id string, req *dto.UpdateCuponRequest) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID de cupón inválido")
+	}
+
+	cupon, err := s.cuponRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Descripcion != nil {
+		updates["descripcion"] = strings.TrimSpace(*req.Descripcion)
+	}
+
+	if req.ValidoHasta != nil {
+		validoHasta := time.Time{}
+		if *req.ValidoHasta != "" {
+			if validoHasta, err = parseFecha(*req.ValidoHasta); err != nil {
+				return err
+			}
+			if !cupon.ValidoDesde.IsZero() && !validoHasta.After(cupon.ValidoDesde) {
+				return ErrRangoFechas
+			}
+		}
+		updates["valido_hasta"] = validoHasta
+	}
+
+	if req.MaxCanjes != nil {
+		updates["max_canjes"] = *req.MaxCanjes
+	}
+
+	if req.MaxCanjesPorUsuario != nil {
+		updates["max_canjes_por_usuario"] = *req.MaxCanjesPorUsuario
+	}
+
+	if req.PlanesIDs != nil {
+		planesIDs, err := s.parsePlanesIDs(ctx, *req.PlanesIDs)
+		if err != nil {
+			return err
+		}
+		updates["planes_ids"] = planesIDs
+	}
+
+	if req.Activo != nil {
+		updates["activo"] = *req.Activo
+	}
+
+	if len(updates) == 0 {
+		return errors.New("no hay campos para actualizar")
+	}
+
+	return s.cuponRepo.Update(ctx, objectID, updates)
+}
+
+func (s *cuponService) DeleteCupon(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID de cupón inválido")
+	}
+
+	return s.cuponRepo.Delete(ctx, objectID)
+}
+
+// ValidarCupon le dice al checkout si el usuario puede usar el código en el
+// plan y cuánto pagaría el primer periodo. No reserva el canje.
+func (s *cuponService) ValidarCupon(ctx context.Context, userID string, req *dto.ValidarCuponRequest) (*dto.ValidacionCuponDTO, error) {
+	usuarioID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	planID, err := primitive.ObjectIDFromHex(req.PlanID)
+	if err != nil {
+		return nil, errors.New("ID de plan inválido")
+	}
+
+	moneda, err := normalizarMoneda(req.Moneda, s.cfg.Suscripciones.MonedaDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	precio, ok := plan.PrecioEn(moneda)
+	if !ok {
+		return nil, ErrMonedaNoDisponible
+	}
+
+	cupon, err := comprobarCupon(ctx, s.cuponRepo, s.canjeRepo, req.Codigo, usuarioID, planID, moneda, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	descuento := calcularDescuento(cupon.Tipo, cupon.Valor, precio)
+
+	return &dto.ValidacionCuponDTO{
+		Cupon:          *cuponToDTO(cupon),
+		PlanID:         plan.ID.Hex(),
+		Moneda:         moneda,
+		PrecioOriginal: precio,
+		Descuento:      descuento,
+		PrecioFinal:    precio - descuento,
+	}, nil
+}
+
+func (s *cuponService) parsePlanesIDs(ctx context.Context, ids []string) ([]primitive.ObjectID, error) {
+	planesIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		planID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, errors.New("ID de plan inválido")
+		}
+		if _, err := s.planRepo.GetByID(ctx, planID); err != nil {
+			return nil, err
+		}
+		planesIDs = append(planesIDs, planID)
+	}
+	return planesIDs, nil
+}
+
+// comprobarCupon busca el cupón por código y verifica que el usuario pueda
+// canjearlo en el plan y la moneda. Los límites se vuelven a comprobar de forma atómica
+// al canjear, esto solo evita el trabajo cuando ya se sabe que fallará.
+func comprobarCupon(ctx context.Context, cuponRepo repositories.CuponRepository, canjeRepo repositories.CanjeCuponRepository, codigo string, usuarioID, planID primitive.ObjectID, moneda string, now time.Time) (*entity.Cupon, error) {
+	cupon, err := cuponRepo.GetByCodigo(ctx, normalizarCodigo(codigo))
+	if err != nil {
+		return nil, err
+	}
+
+	if !cupon.Activo {
+		return nil, ErrCuponInactivo
+	}
+	if !cupon.IsVigente(now) {
+		return nil, ErrCuponNoVigente
+	}
+	if !cupon.AplicaAPlan(planID) {
+		return nil, ErrCuponNoAplica
+	}
+	if !cupon.AplicaAMoneda(moneda) {
+		return nil, ErrCuponMonedaDistinta
+	}
+	if cupon.MaxCanjes > 0 && cupon.Canjes >= cupon.MaxCanjes {
+		return nil, repositories.ErrCuponAgotado
+	}
+
+	if cupon.MaxCanjesPorUsuario > 0 {
+		usados, err := canjeRepo.CountByUsuario(ctx, cupon.ID, usuarioID)
+		if err != nil {
+			return nil, err
+		}
+		if usados >= int64(cupon.MaxCanjesPorUsuario) {
+			return nil, ErrCuponLimiteUsuario
+		}
+	}
+
+	return cupon, nil
+}
+
+// calcularDescuento devuelve el monto a descontar de precio, ambos en unidad
+// menor. El porcentaje se redondea a la unidad más cercana y un monto fijo
+// nunca deja el precio en negativo.
+func calcularDescuento(tipo string, valor, precio int64) int64 {
+	if tipo == entity.TipoCuponPorcentaje {
+		return (precio*valor + 50) / 100
+	}
+	return min(valor, precio)
+}
+
+func normalizarCodigo(codigo string) string {
+	return strings.ToUpper(strings.TrimSpace(codigo))
+}
+
+func cuponToDTO(cupon *entity.Cupon) *dto.CuponDTO {
+	result := &dto.CuponDTO{
+		ID:                  cupon.ID.Hex(),
+		Codigo:              cupon.Codigo,
+		Descripcion:         cupon.Descripcion,
+		Tipo:                cupon.Tipo,
+		Valor:               cupon.Valor,
+		Moneda:              cupon.Moneda,
+		Duracion:            cupon.Duracion,
+		DuracionPeriodos:    cupon.DuracionPeriodos,
+		MaxCanjes:           cupon.MaxCanjes,
+		MaxCanjesPorUsuario: cupon.MaxCanjesPorUsuario,
+		Canjes:              cupon.Canjes,
+		Activo:              cupon.Activo,
+		CreadoEn:            cupon.CreadoEn,
+	}
+
+	if !cupon.ValidoDesde.IsZero() {
+		validoDesde := cupon.ValidoDesde
+		result.ValidoDesde = &validoDesde
+	}
+	if !cupon.ValidoHasta.IsZero() {
+		validoHasta := cupon.ValidoHasta
+		result.ValidoHasta = &validoHasta
+	}
+
+	for _, planID := range cupon.PlanesIDs {
+		result.PlanesIDs = append(result.PlanesIDs, planID.Hex())
+	}
+
+	return result
+}
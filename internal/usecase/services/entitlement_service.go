@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/cache"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EntitlementsCache guarda los derechos resueltos por ID de usuario. Los
+// servicios que cambian suscripciones o planes lo invalidan.
+type EntitlementsCache = cache.TTL[string, *dto.EntitlementsDTO]
+
+func NewEntitlementsCache(ttl time.Duration) *EntitlementsCache {
+	return cache.NewTTL[string, *dto.EntitlementsDTO](ttl)
+}
+
+type entitlementService struct {
+	suscripcionRepo repositories.SuscripcionRepository
+	planRepo        repositories.PlanRepository
+	versionRepo     repositories.VersionPlanRepository
+	cache           *EntitlementsCache
+}
+
+func NewEntitlementService(
+	suscripcionRepo repositories.SuscripcionRepository,
+	planRepo repositories.PlanRepository,
+	versionRepo repositories.VersionPlanRepository,
+	cache *EntitlementsCache,
+) EntitlementService {
+	return &entitlementService{
+		suscripcionRepo: suscripcionRepo,
+		planRepo:        planRepo,
+		versionRepo:     versionRepo,
+		cache:           cache,
+	}
+}
+
+// GetEntitlements resuelve los derechos de la versión del plan en la que está
+// la suscripción vigente del usuario o, si no tiene una propia, la suscripción
+// en la que ocupa un asiento, sumados a los de sus complementos. Una
+// suscripción pendiente de pago o pausada no da derechos.
+func (s *entitlementService) GetEntitlements(ctx context.Context, userID string) (*dto.EntitlementsDTO, error) {
+	if resultado, ok := s.cache.Get(userID); ok {
+		return resultado, nil
+	}
+
+	usuarioID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	generacion := s.cache.Generacion()
+
+	resultado := &dto.EntitlementsDTO{
+		UsuarioID:    userID,
+		Entitlements: map[string]interface{}{},
+		ResueltoEn:   time.Now(),
+	}
+
+	suscripcion, err := s.suscripcionRepo.GetActiveSuscripcionByUserID(ctx, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	titular := true
+	if suscripcion == nil || !suscripcion.IsActive() {
+		conAsiento, err := s.suscripcionRepo.GetActiveByAsiento(ctx, usuarioID)
+		if err != nil {
+			return nil, err
+		}
+		if conAsiento != nil && conAsiento.IsActive() {
+			suscripcion = conAsiento
+			titular = false
+		}
+	}
+
+	// La entrada no debe sobrevivir al fin del periodo aunque nadie la invalide
+	var limite time.Time
+	if suscripcion != nil && suscripcion.IsActive() {
+		plan, err := planDeSuscripcion(ctx, s.planRepo, s.versionRepo, suscripcion)
+		if err != nil {
+			return nil, err
+		}
+		plan = plan.ConComplementos(suscripcion.Complementos)
+
+		for _, derecho := range plan.Derechos {
+			resultado.Entitlements[derecho.Clave] = derecho.Valor()
+		}
+
+		fechaFin := suscripcion.FechaFin
+		resultado.SuscripcionID = suscripcion.ID.Hex()
+		resultado.PlanID = plan.ID.Hex()
+		resultado.Estado = suscripcion.Estado
+		resultado.Titular = titular
+		resultado.Asiento = !titular || !plan.PorAsiento || suscripcion.TieneAsiento(usuarioID)
+		resultado.VigenteHasta = &fechaFin
+		limite = fechaFin
+	}
+
+	s.cache.Set(userID, resultado, limite, generacion)
+
+	return resultado, nil
+}
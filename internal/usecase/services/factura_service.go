@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type facturaService struct {
+	facturaRepo  repositories.FacturaRepository
+	contadorRepo repositories.ContadorRepository
+	creditoRepo  repositories.CreditoRepository
+}
+
+func NewFacturaService(facturaRepo repositories.FacturaRepository, contadorRepo repositories.ContadorRepository, creditoRepo repositories.CreditoRepository) FacturaService {
+	return &facturaService{
+		facturaRepo:  facturaRepo,
+		contadorRepo: contadorRepo,
+		creditoRepo:  creditoRepo,
+	}
+}
+
+// GetMisFacturas lista las facturas emitidas del usuario; los borradores son
+// internos y no se muestran.
+func (s *facturaService) GetMisFacturas(ctx context.Context, userID string, limit, offset int) ([]*dto.FacturaDTO, int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, errors.New("ID de usuario inválido")
+	}
+
+	filters := map[string]interface{}{
+		"usuario_id": objectID,
+		"estado":     map[string]interface{}{"$ne": entity.EstadoFacturaBorrador},
+	}
+
+	return s.list(ctx, filters, limit, offset)
+}
+
+func (s *facturaService) GetAllFacturas(ctx context.Context, estado, userID string, limit, offset int) ([]*dto.FacturaDTO, int64, error) {
+	filters := make(map[string]interface{})
+	if estado != "" {
+		filters["estado"] = estado
+	}
+	if userID != "" {
+		objectID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return nil, 0, errors.New("ID de usuario inválido")
+		}
+		filters["usuario_id"] = objectID
+	}
+
+	return s.list(ctx, filters, limit, offset)
+}
+
+func (s *facturaService) GetFacturaByID(ctx context.Context, id string) (*dto.FacturaDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de factura inválido")
+	}
+
+	factura, err := s.facturaRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return facturaToDTO(factura), nil
+}
+
+// EmitirFactura reintenta la emisión de un borrador que quedó sin número.
+func (s *facturaService) EmitirFactura(ctx context.Context, id string) (*dto.FacturaDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de factura inválido")
+	}
+
+	factura, err := s.facturaRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := emitirFactura(ctx, s.facturaRepo, s.contadorRepo, factura); err != nil {
+		return nil, err
+	}
+
+	return facturaToDTO(factura), nil
+}
+
+// AnularFactura deja la factura sin efecto conservando su número, de modo que
+// la secuencia sigue sin huecos. El crédito que se le había aplicado vuelve al
+// saldo del usuario.
+func (s *facturaService) AnularFactura(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID de factura inválido")
+	}
+
+	factura, err := s.facturaRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	if factura.Estado == entity.EstadoFacturaAnulada || factura.Estado == entity.EstadoFacturaPagada {
+		return errors.New("la factura no se puede anular en su estado actual")
+	}
+
+	expected := map[string]interface{}{"estado": factura.Estado}
+	updates := map[string]interface{}{
+		"estado":     entity.EstadoFacturaAnulada,
+		"anulada_en": time.Now(),
+	}
+
+	if err := s.facturaRepo.UpdateIfMatch(ctx, objectID, expected, updates); err != nil {
+		return err
+	}
+
+	return restituirCredito(ctx, s.creditoRepo, factura)
+}
+
+func (s *facturaService) list(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*dto.FacturaDTO, int64, error) {
+	facturas, err := s.facturaRepo.GetAll(ctx, filters, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.facturaRepo.Count(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var dtos []*dto.FacturaDTO
+	for _, factura := range facturas {
+		dtos = append(dtos, facturaToDTO(factura))
+	}
+
+	return dtos, total, nil
+}
+
+func facturaToDTO(factura *entity.Factura) *dto.FacturaDTO {
+	result := &dto.FacturaDTO{
+		ID:              factura.ID.Hex(),
+		Numero:          factura.Numero,
+		Codigo:          factura.Codigo(),
+		UsuarioID:       factura.UsuarioID.Hex(),
+		Motivo:          factura.Motivo,
+		Estado:          factura.Estado,
+		Moneda:          factura.Moneda,
+		Subtotal:        factura.Subtotal,
+		Descuento:       factura.Descuento,
+		Impuesto:        factura.Impuesto,
+		Desglose:        desgloseImpuestoToDTO(factura.Desglose),
+		CreditoAplicado: factura.CreditoAplicado,
+		Total:           factura.Total,
+		Reembolsado:     factura.Reembolsado,
+		PeriodoInicio:   factura.PeriodoInicio,
+		PeriodoFin:      factura.PeriodoFin,
+		CreadoEn:        factura.CreadoEn,
+	}
+
+	if !factura.SuscripcionID.IsZero() {
+		result.SuscripcionID = factura.SuscripcionID.Hex()
+	}
+	if factura.RegaloID != nil {
+		result.RegaloID = factura.RegaloID.Hex()
+	}
+
+	for _, linea := range factura.Lineas {
+		result.Lineas = append(result.Lineas, dto.LineaFacturaDTO{
+			Tipo:           linea.Tipo,
+			Descripcion:    linea.Descripcion,
+			Cantidad:       linea.Cantidad,
+			PrecioUnitario: linea.PrecioUnitario,
+			Monto:          linea.Monto,
+		})
+	}
+
+	if !factura.EmitidaEn.IsZero() {
+		emitidaEn := factura.EmitidaEn
+		result.EmitidaEn = &emitidaEn
+	}
+	if !factura.PagadaEn.IsZero() {
+		pagadaEn := factura.PagadaEn
+		result.PagadaEn = &pagadaEn
+	}
+	if !factura.AnuladaEn.IsZero() {
+		anuladaEn := factura.AnuladaEn
+		result.AnuladaEn = &anuladaEn
+	}
+
+	return result
+}
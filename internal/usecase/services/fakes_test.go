@@ -0,0 +1,680 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Los fakes guardan los documentos como bson.M para aplicar los updates por
+// nombre de campo igual que Mongo. Embeben la interfaz del repositorio: un
+// método que el test no implementa entra en pánico si se llama.
+
+func aDocumento(v interface{}) bson.M {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+func desdeDocumento(doc bson.M, v interface{}) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	if err := bson.Unmarshal(data, v); err != nil {
+		panic(err)
+	}
+}
+
+// coincide compara los valores ya serializados, así un time.Time y el
+// primitive.DateTime guardado son iguales. De los operadores solo entiende $in.
+func coincide(doc bson.M, expected map[string]interface{}) bool {
+	for campo, valor := range expected {
+		if operador, ok := valor.(map[string]interface{}); ok {
+			if !coincideAlguno(doc[campo], operador["$in"]) {
+				return false
+			}
+			continue
+		}
+		if !igual(doc[campo], valor) {
+			return false
+		}
+	}
+	return true
+}
+
+func igual(a, b interface{}) bool {
+	x, _ := bson.Marshal(bson.M{"v": a})
+	y, _ := bson.Marshal(bson.M{"v": b})
+	return bytes.Equal(x, y)
+}
+
+func coincideAlguno(valor interface{}, opciones interface{}) bool {
+	lista, ok := opciones.([]string)
+	if !ok {
+		return false
+	}
+	for _, opcion := range lista {
+		if igual(valor, opcion) {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeUsuarioRepo struct {
+	repositories.UsuarioRepository
+
+	usuarios map[primitive.ObjectID]*entity.Usuario
+}
+
+func newFakeUsuarioRepo(usuarios ...*entity.Usuario) *fakeUsuarioRepo {
+	r := &fakeUsuarioRepo{usuarios: make(map[primitive.ObjectID]*entity.Usuario)}
+	for _, usuario := range usuarios {
+		r.usuarios[usuario.ID] = usuario
+	}
+	return r
+}
+
+func (r *fakeUsuarioRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Usuario, error) {
+	usuario, ok := r.usuarios[id]
+	if !ok {
+		return nil, errors.New("usuario no encontrado")
+	}
+	return usuario, nil
+}
+
+type fakePlanRepo struct {
+	repositories.PlanRepository
+
+	planes map[primitive.ObjectID]*entity.PlanSuscripcion
+}
+
+func newFakePlanRepo(planes ...*entity.PlanSuscripcion) *fakePlanRepo {
+	r := &fakePlanRepo{planes: make(map[primitive.ObjectID]*entity.PlanSuscripcion)}
+	for _, plan := range planes {
+		r.planes[plan.ID] = plan
+	}
+	return r
+}
+
+func (r *fakePlanRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.PlanSuscripcion, error) {
+	plan, ok := r.planes[id]
+	if !ok {
+		return nil, errors.New("plan no encontrado")
+	}
+	return plan, nil
+}
+
+type fakeSuscripcionRepo struct {
+	repositories.SuscripcionRepository
+
+	mu   sync.Mutex
+	docs map[primitive.ObjectID]bson.M
+}
+
+func newFakeSuscripcionRepo(suscripciones ...*entity.Suscripcion) *fakeSuscripcionRepo {
+	r := &fakeSuscripcionRepo{docs: make(map[primitive.ObjectID]bson.M)}
+	for _, suscripcion := range suscripciones {
+		r.docs[suscripcion.ID] = aDocumento(suscripcion)
+	}
+	return r
+}
+
+func (r *fakeSuscripcionRepo) Create(ctx context.Context, suscripcion *entity.Suscripcion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if suscripcion.ID.IsZero() {
+		suscripcion.ID = primitive.NewObjectID()
+	}
+	r.docs[suscripcion.ID] = aDocumento(suscripcion)
+	return nil
+}
+
+// GetActiveSuscripcionByUserID no distingue pausadas: los tests no las usan.
+func (r *fakeSuscripcionRepo) GetActiveSuscripcionByUserID(ctx context.Context, userID primitive.ObjectID) (*entity.Suscripcion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, doc := range r.docs {
+		var suscripcion entity.Suscripcion
+		desdeDocumento(doc, &suscripcion)
+		if suscripcion.UsuarioID == userID && suscripcion.FechaFin.After(time.Now()) &&
+			(suscripcion.Estado == entity.EstadoSuscripcionPendientePago ||
+				suscripcion.Estado == entity.EstadoSuscripcionActiva ||
+				suscripcion.Estado == entity.EstadoSuscripcionEnPrueba) {
+			return &suscripcion, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeSuscripcionRepo) HasUsedTrial(ctx context.Context, userID primitive.ObjectID, planID *primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, doc := range r.docs {
+		var suscripcion entity.Suscripcion
+		desdeDocumento(doc, &suscripcion)
+		if suscripcion.UsuarioID == userID && suscripcion.UsoPrueba && (planID == nil || suscripcion.PlanID == *planID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeSuscripcionRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Suscripcion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, ok := r.docs[id]
+	if !ok {
+		return nil, errors.New("suscripción no encontrada")
+	}
+	var suscripcion entity.Suscripcion
+	desdeDocumento(doc, &suscripcion)
+	return &suscripcion, nil
+}
+
+// UpdateIfMatch guarda el evento pendiente junto con el cambio, como el
+// repositorio real.
+func (r *fakeSuscripcionRepo) UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}, evento *entity.EventoSuscripcion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, ok := r.docs[id]
+	if !ok || !coincide(doc, expected) {
+		return repositories.ErrSuscripcionModificada
+	}
+	for campo, valor := range updates {
+		doc[campo] = valor
+	}
+	if evento != nil {
+		pendientes, _ := doc["eventos_pendientes"].(bson.M)
+		if pendientes == nil {
+			pendientes = bson.M{}
+		}
+		pendientes[evento.ID.Hex()] = evento
+		doc["eventos_pendientes"] = pendientes
+	}
+	r.docs[id] = aDocumento(doc)
+	return nil
+}
+
+func (r *fakeSuscripcionRepo) QuitarEventoPendiente(ctx context.Context, id, eventoID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pendientes, ok := r.docs[id]["eventos_pendientes"].(bson.M); ok {
+		delete(pendientes, eventoID.Hex())
+	}
+	return nil
+}
+
+func (r *fakeSuscripcionRepo) ApplyTransition(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}, transicion entity.TransicionEstado, evento *entity.EventoSuscripcion) error {
+	return r.UpdateIfMatch(ctx, id, expected, updates, evento)
+}
+
+type fakeFacturaRepo struct {
+	repositories.FacturaRepository
+
+	mu   sync.Mutex
+	docs map[primitive.ObjectID]bson.M
+}
+
+func newFakeFacturaRepo(facturas ...*entity.Factura) *fakeFacturaRepo {
+	r := &fakeFacturaRepo{docs: make(map[primitive.ObjectID]bson.M)}
+	for _, factura := range facturas {
+		r.docs[factura.ID] = aDocumento(factura)
+	}
+	return r
+}
+
+func (r *fakeFacturaRepo) Create(ctx context.Context, factura *entity.Factura) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if factura.ID.IsZero() {
+		factura.ID = primitive.NewObjectID()
+	}
+	r.docs[factura.ID] = aDocumento(factura)
+	return nil
+}
+
+func (r *fakeFacturaRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*entity.Factura, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, ok := r.docs[id]
+	if !ok {
+		return nil, errors.New("factura no encontrada")
+	}
+	var factura entity.Factura
+	desdeDocumento(doc, &factura)
+	return &factura, nil
+}
+
+func (r *fakeFacturaRepo) GetByCobroID(ctx context.Context, cobroID string) (*entity.Factura, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, doc := range r.docs {
+		if doc["cobro_id"] == cobroID {
+			var factura entity.Factura
+			desdeDocumento(doc, &factura)
+			return &factura, nil
+		}
+	}
+	return nil, errors.New("factura no encontrada")
+}
+
+func (r *fakeFacturaRepo) GetAll(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*entity.Factura, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*entity.Factura
+	for _, doc := range r.docs {
+		if coincide(doc, filters) {
+			var factura entity.Factura
+			desdeDocumento(doc, &factura)
+			result = append(result, &factura)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeFacturaRepo) Count(ctx context.Context, filters map[string]interface{}) (int64, error) {
+	facturas, err := r.GetAll(ctx, filters, 0, 0)
+	return int64(len(facturas)), err
+}
+
+func (r *fakeFacturaRepo) SumarReembolso(ctx context.Context, id primitive.ObjectID, monto int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var factura entity.Factura
+	desdeDocumento(r.docs[id], &factura)
+	if factura.Reembolsado+monto > factura.Total {
+		return repositories.ErrReembolsoExcedido
+	}
+	r.docs[id]["reembolsado"] = factura.Reembolsado + monto
+	return nil
+}
+
+func (r *fakeFacturaRepo) UpdateIfMatch(ctx context.Context, id primitive.ObjectID, expected map[string]interface{}, updates map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, ok := r.docs[id]
+	if !ok || !coincide(doc, expected) {
+		return repositories.ErrFacturaModificada
+	}
+	for campo, valor := range updates {
+		doc[campo] = valor
+	}
+	r.docs[id] = aDocumento(doc)
+	return nil
+}
+
+type fakeCuponRepo struct {
+	repositories.CuponRepository
+
+	mu      sync.Mutex
+	cupones map[primitive.ObjectID]*entity.Cupon
+}
+
+func newFakeCuponRepo(cupones ...*entity.Cupon) *fakeCuponRepo {
+	r := &fakeCuponRepo{cupones: make(map[primitive.ObjectID]*entity.Cupon)}
+	for _, cupon := range cupones {
+		r.cupones[cupon.ID] = cupon
+	}
+	return r
+}
+
+func (r *fakeCuponRepo) GetByCodigo(ctx context.Context, codigo string) (*entity.Cupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cupon := range r.cupones {
+		if cupon.Codigo == codigo {
+			copia := *cupon
+			return &copia, nil
+		}
+	}
+	return nil, errors.New("cupón no encontrado")
+}
+
+func (r *fakeCuponRepo) IncrementCanjes(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cupon := r.cupones[id]
+	if cupon.MaxCanjes > 0 && cupon.Canjes >= cupon.MaxCanjes {
+		return repositories.ErrCuponAgotado
+	}
+	cupon.Canjes++
+	return nil
+}
+
+func (r *fakeCuponRepo) DecrementCanjes(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cupon := r.cupones[id]; cupon.Canjes > 0 {
+		cupon.Canjes--
+	}
+	return nil
+}
+
+// fakeCanjeCuponRepo aplica el límite por usuario al registrar el canje, como
+// el contador del repositorio real.
+type fakeCanjeCuponRepo struct {
+	mu     sync.Mutex
+	canjes []*entity.CanjeCupon
+}
+
+func (r *fakeCanjeCuponRepo) Create(ctx context.Context, canje *entity.CanjeCupon, maxPorUsuario int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if maxPorUsuario > 0 && r.contar(canje.CuponID, canje.UsuarioID) >= int64(maxPorUsuario) {
+		return repositories.ErrCuponLimiteUsuario
+	}
+	if canje.ID == "" {
+		canje.ID = primitive.NewObjectID().Hex()
+	}
+	r.canjes = append(r.canjes, canje)
+	return nil
+}
+
+func (r *fakeCanjeCuponRepo) Delete(ctx context.Context, canje *entity.CanjeCupon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existente := range r.canjes {
+		if existente.ID == canje.ID {
+			r.canjes = append(r.canjes[:i], r.canjes[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *fakeCanjeCuponRepo) CountByUsuario(ctx context.Context, cuponID, usuarioID primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.contar(cuponID, usuarioID), nil
+}
+
+func (r *fakeCanjeCuponRepo) contar(cuponID, usuarioID primitive.ObjectID) int64 {
+	var n int64
+	for _, canje := range r.canjes {
+		if canje.CuponID == cuponID && canje.UsuarioID == usuarioID {
+			n++
+		}
+	}
+	return n
+}
+
+// fakeContadorRepo sigue las mismas reglas que el repositorio real: una sola
+// asignación pendiente por secuencia hasta que se confirme.
+type fakeContadorRepo struct {
+	mu         sync.Mutex
+	contadores map[string]*entity.Contador
+}
+
+func newFakeContadorRepo(contadores ...*entity.Contador) *fakeContadorRepo {
+	r := &fakeContadorRepo{contadores: make(map[string]*entity.Contador)}
+	for _, contador := range contadores {
+		r.contadores[contador.Nombre] = contador
+	}
+	return r
+}
+
+func (r *fakeContadorRepo) Get(ctx context.Context, nombre string) (*entity.Contador, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copia := entity.Contador{Nombre: nombre}
+	if contador, ok := r.contadores[nombre]; ok {
+		copia = *contador
+		if contador.Pendiente != nil {
+			pendiente := *contador.Pendiente
+			copia.Pendiente = &pendiente
+		}
+	}
+	return &copia, nil
+}
+
+func (r *fakeContadorRepo) Asignar(ctx context.Context, nombre string, documentoID primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	contador, ok := r.contadores[nombre]
+	if !ok {
+		contador = &entity.Contador{Nombre: nombre}
+		r.contadores[nombre] = contador
+	}
+	if contador.Pendiente != nil {
+		return 0, repositories.ErrContadorOcupado
+	}
+	contador.Valor++
+	contador.Pendiente = &entity.AsignacionContador{Valor: contador.Valor, DocumentoID: documentoID}
+	return contador.Valor, nil
+}
+
+func (r *fakeContadorRepo) Reasignar(ctx context.Context, nombre string, pendiente entity.AsignacionContador, documentoID primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	contador, ok := r.contadores[nombre]
+	if !ok || contador.Pendiente == nil || *contador.Pendiente != pendiente {
+		return false, nil
+	}
+	contador.Pendiente.DocumentoID = documentoID
+	return true, nil
+}
+
+func (r *fakeContadorRepo) Confirmar(ctx context.Context, nombre string, documentoID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if contador, ok := r.contadores[nombre]; ok && contador.Pendiente != nil && contador.Pendiente.DocumentoID == documentoID {
+		contador.Pendiente = nil
+	}
+	return nil
+}
+
+// fakeCreditoRepo lleva el libro en memoria y, como el real, rechaza los
+// movimientos que dejarían el saldo negativo.
+type fakeCreditoRepo struct {
+	repositories.CreditoRepository
+
+	mu          sync.Mutex
+	movimientos []*entity.MovimientoCredito
+}
+
+func (r *fakeCreditoRepo) Registrar(ctx context.Context, movimiento *entity.MovimientoCredito) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var secuencia, saldo int64
+	if ultimo := r.ultimo(movimiento.UsuarioID, movimiento.Moneda); ultimo != nil {
+		secuencia, saldo = ultimo.Secuencia, ultimo.Saldo
+	}
+	if saldo+movimiento.Monto < 0 {
+		return repositories.ErrSaldoInsuficiente
+	}
+
+	movimiento.Secuencia = secuencia + 1
+	movimiento.Saldo = saldo + movimiento.Monto
+	movimiento.ID = entity.MovimientoCreditoID(movimiento.UsuarioID, movimiento.Moneda, movimiento.Secuencia)
+	r.movimientos = append(r.movimientos, movimiento)
+	return nil
+}
+
+func (r *fakeCreditoRepo) GetUltimo(ctx context.Context, usuarioID primitive.ObjectID, moneda string) (*entity.MovimientoCredito, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ultimo(usuarioID, moneda), nil
+}
+
+func (r *fakeCreditoRepo) AsociarReembolso(ctx context.Context, id, reembolsoID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, movimiento := range r.movimientos {
+		if movimiento.ID == id {
+			movimiento.ReembolsoID = reembolsoID
+		}
+	}
+	return nil
+}
+
+func (r *fakeCreditoRepo) ultimo(usuarioID primitive.ObjectID, moneda string) *entity.MovimientoCredito {
+	for i := len(r.movimientos) - 1; i >= 0; i-- {
+		if r.movimientos[i].UsuarioID == usuarioID && r.movimientos[i].Moneda == moneda {
+			return r.movimientos[i]
+		}
+	}
+	return nil
+}
+
+type fakeCambioPlanRepo struct {
+	mu      sync.Mutex
+	cambios []*entity.CambioPlan
+}
+
+func (r *fakeCambioPlanRepo) Create(ctx context.Context, cambio *entity.CambioPlan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cambios = append(r.cambios, cambio)
+	return nil
+}
+
+func (r *fakeCambioPlanRepo) GetByFacturaID(ctx context.Context, facturaID primitive.ObjectID) (*entity.CambioPlan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cambio := range r.cambios {
+		if cambio.FacturaID != nil && *cambio.FacturaID == facturaID {
+			return cambio, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeCambioAsientosRepo struct {
+	mu      sync.Mutex
+	cambios []*entity.CambioAsientos
+}
+
+func (r *fakeCambioAsientosRepo) Create(ctx context.Context, cambio *entity.CambioAsientos) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cambios = append(r.cambios, cambio)
+	return nil
+}
+
+func (r *fakeCambioAsientosRepo) GetByFacturaID(ctx context.Context, facturaID primitive.ObjectID) (*entity.CambioAsientos, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cambio := range r.cambios {
+		if cambio.FacturaID != nil && *cambio.FacturaID == facturaID {
+			return cambio, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeEventoSuscripcionRepo struct {
+	repositories.EventoSuscripcionRepository
+
+	mu      sync.Mutex
+	eventos []*entity.EventoSuscripcion
+}
+
+func (r *fakeEventoSuscripcionRepo) Create(ctx context.Context, evento *entity.EventoSuscripcion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.eventos = append(r.eventos, evento)
+	return nil
+}
+
+func (r *fakeEventoSuscripcionRepo) tipos() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tipos := make([]string, 0, len(r.eventos))
+	for _, evento := range r.eventos {
+		tipos = append(tipos, evento.Tipo)
+	}
+	return tipos
+}
+
+type fakeEventoPagoRepo struct {
+	mu      sync.Mutex
+	eventos map[string]*entity.EventoPago
+}
+
+func newFakeEventoPagoRepo() *fakeEventoPagoRepo {
+	return &fakeEventoPagoRepo{eventos: make(map[string]*entity.EventoPago)}
+}
+
+func (r *fakeEventoPagoRepo) Reservar(ctx context.Context, evento *entity.EventoPago, reserva time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if existente, ok := r.eventos[evento.ID]; ok {
+		if existente.Estado != entity.EstadoEventoPagoProcesando || existente.ReservadoHasta.After(now) {
+			return false, nil
+		}
+		existente.ReservadoHasta = now.Add(reserva)
+		return true, nil
+	}
+
+	copia := *evento
+	copia.Estado = entity.EstadoEventoPagoProcesando
+	copia.ReservadoHasta = now.Add(reserva)
+	r.eventos[evento.ID] = &copia
+	return true, nil
+}
+
+func (r *fakeEventoPagoRepo) MarcarProcesado(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.eventos[id].Estado = entity.EstadoEventoPagoProcesado
+	return nil
+}
+
+func (r *fakeEventoPagoRepo) Liberar(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if evento, ok := r.eventos[id]; ok && evento.Estado == entity.EstadoEventoPagoProcesando {
+		delete(r.eventos, id)
+	}
+	return nil
+}
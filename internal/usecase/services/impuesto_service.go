@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sw2p2go/config"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrTasaImpuestoExistente = errors.New("ya existe una tasa de impuesto activa para ese país y región")
+
+type impuestoService struct {
+	tasaRepo repositories.TasaImpuestoRepository
+	userRepo repositories.UsuarioRepository
+	planRepo repositories.PlanRepository
+	cfg      *config.Config
+}
+
+func NewImpuestoService(
+	tasaRepo repositories.TasaImpuestoRepository,
+	userRepo repositories.UsuarioRepository,
+	planRepo repositories.PlanRepository,
+	cfg *config.Config,
+) ImpuestoService {
+	return &impuestoService{
+		tasaRepo: tasaRepo,
+		userRepo: userRepo,
+		planRepo: planRepo,
+		cfg:      cfg,
+	}
+}
+
+func (s *impuestoService) CreateTasa(ctx context.Context, req *dto.CreateTasaImpuestoRequest) (*dto.TasaImpuestoDTO, error) {
+	tasa := &entity.TasaImpuesto{
+		Pais:       strings.ToUpper(strings.TrimSpace(req.Pais)),
+		Region:     normalizarRegion(req.Region),
+		Nombre:     strings.TrimSpace(req.Nombre),
+		Porcentaje: req.Porcentaje,
+		Activo:     true,
+		CreadoEn:   time.Now(),
+	}
+
+	exists, err := s.tasaRepo.Exists(ctx, tasa.Pais, tasa.Region, primitive.NilObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrTasaImpuestoExistente
+	}
+
+	if err := s.tasaRepo.Create(ctx, tasa); err != nil {
+		return nil, err
+	}
+
+	return tasaImpuestoToDTO(tasa), nil
+}
+
+func (s *impuestoService) GetAllTasas(ctx context.Context, pais string, showInactive bool, limit, offset int) ([]*dto.TasaImpuestoDTO, int64, error) {
+	filters := make(map[string]interface{})
+	if !showInactive {
+		filters["activo"] = true
+	}
+	if pais != "" {
+		filters["pais"] = strings.ToUpper(pais)
+	}
+
+	tasas, err := s.tasaRepo.GetAll(ctx, filters, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.tasaRepo.Count(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var dtos []*dto.TasaImpuestoDTO
+	for _, tasa := range tasas {
+		dtos = append(dtos, tasaImpuestoToDTO(tasa))
+	}
+
+	return dtos, total, nil
+}
+
+func (s *impuestoService) GetTasaByID(ctx context.Context, id string) (*dto.TasaImpuestoDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de tasa inválido")
+	}
+
+	tasa, err := s.tasaRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return tasaImpuestoToDTO(tasa), nil
+}
+
+// UpdateTasa no cambia país ni región: para otro lugar se crea otra tasa. Las
+// facturas ya generadas guardan su copia del desglose y no se ven afectadas.
+func (s *impuestoService) UpdateTasa(ctx context.Context, id string, req *dto.UpdateTasaImpuestoRequest) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID de tasa inválido")
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Nombre != nil {
+		updates["nombre"] = strings.TrimSpace(*req.Nombre)
+	}
+
+	if req.Porcentaje != nil {
+		updates["porcentaje"] = *req.Porcentaje
+	}
+
+	if req.Activo != nil {
+		if *req.Activo {
+			tasa, err := s.tasaRepo.GetByID(ctx, objectID)
+			if err != nil {
+				return err
+			}
+			exists, err := s.tasaRepo.Exists(ctx, tasa.Pais, tasa.Region, tasa.ID)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrTasaImpuestoExistente
+			}
+		}
+		updates["activo"] = *req.Activo
+	}
+
+	if len(updates) == 0 {
+		return errors.New("no hay campos para actualizar")
+	}
+
+	return s.tasaRepo.Update(ctx, objectID, updates)
+}
+
+func (s *impuestoService) DeleteTasa(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID de tasa inválido")
+	}
+
+	return s.tasaRepo.Delete(ctx, objectID)
+}
+
+// CalcularImpuesto muestra en el checkout cuánto impuesto pagaría el usuario
+// por el primer periodo del plan según su país de facturación.
+func (s *impuestoService) CalcularImpuesto(ctx context.Context, userID string, req *dto.CalcularImpuestoRequest) (*dto.CalculoImpuestoDTO, error) {
+	usuarioID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	planID, err := primitive.ObjectIDFromHex(req.PlanID)
+	if err != nil {
+		return nil, errors.New("ID de plan inválido")
+	}
+
+	moneda, err := normalizarMoneda(req.Moneda, s.cfg.Suscripciones.MonedaDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	usuario, err := s.userRepo.GetByID(ctx, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	precio, ok := plan.PrecioEn(moneda)
+	if !ok {
+		return nil, ErrMonedaNoDisponible
+	}
+
+	impuesto, err := calcularImpuesto(ctx, s.tasaRepo, usuario, precio, plan.ImpuestoIncluido)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.CalculoImpuestoDTO{
+		PlanID: plan.ID.Hex(),
+		Moneda: moneda,
+		Precio: precio,
+		Total:  precio,
+	}
+	if impuesto != nil {
+		result.Impuesto = desgloseImpuestoToDTO(impuesto)
+		result.Total = impuesto.Total()
+	}
+
+	return result, nil
+}
+
+// calcularImpuesto busca la tasa del lugar de facturación del usuario y la
+// aplica sobre monto. Devuelve nil si el usuario no tiene país o ese lugar no
+// tiene tasa configurada.
+func calcularImpuesto(ctx context.Context, tasaRepo repositories.TasaImpuestoRepository, usuario *entity.Usuario, monto int64, incluido bool) (*entity.DesgloseImpuesto, error) {
+	if usuario.PaisFacturacion == "" {
+		return nil, nil
+	}
+
+	tasa, err := tasaRepo.GetAplicable(ctx, usuario.PaisFacturacion, usuario.RegionFacturacion)
+	if err != nil || tasa == nil {
+		return nil, err
+	}
+
+	desglose := tasa.Calcular(monto, incluido)
+	return &desglose, nil
+}
+
+func normalizarRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+func tasaImpuestoToDTO(tasa *entity.TasaImpuesto) *dto.TasaImpuestoDTO {
+	return &dto.TasaImpuestoDTO{
+		ID:         tasa.ID.Hex(),
+		Pais:       tasa.Pais,
+		Region:     tasa.Region,
+		Nombre:     tasa.Nombre,
+		Porcentaje: tasa.Porcentaje,
+		Activo:     tasa.Activo,
+		CreadoEn:   tasa.CreadoEn,
+	}
+}
+
+func desgloseImpuestoToDTO(desglose *entity.DesgloseImpuesto) *dto.DesgloseImpuestoDTO {
+	if desglose == nil {
+		return nil
+	}
+
+	return &dto.DesgloseImpuestoDTO{
+		Nombre:     desglose.Nombre,
+		Pais:       desglose.Pais,
+		Region:     desglose.Region,
+		Porcentaje: desglose.Porcentaje,
+		Incluido:   desglose.Incluido,
+		Base:       desglose.Base,
+		Monto:      desglose.Monto,
+		Total:      desglose.Total(),
+	}
+}
@@ -15,6 +15,7 @@ type UsuarioService interface {
 	DeleteUser(ctx context.Context, id string) error
 	SearchUsers(ctx context.Context, query string, limit, offset int) ([]*dto.UsuarioDTO, error)
 	ChangePassword(ctx context.Context, userID string, req *dto.ChangePasswordRequest) error
+	ExportarDatos(ctx context.Context, userID string) (*dto.ExportacionUsuarioDTO, error)
 }
 
 type PlanService interface {
@@ -24,6 +25,7 @@ type PlanService interface {
 	UpdatePlan(ctx context.Context, id string, req *dto.UpdatePlanRequest) error
 	DeletePlan(ctx context.Context, id string) error
 	GetActivePlans(ctx context.Context, limit, offset int) ([]*dto.PlanSuscripcionDTO, error)
+	GetVersiones(ctx context.Context, id string) ([]*dto.VersionPlanDTO, error)
 }
 
 type SuscripcionService interface {
@@ -33,6 +35,99 @@ type SuscripcionService interface {
 	GetSuscripcionesByUser(ctx context.Context, userID string, limit, offset int) ([]*dto.SuscripcionDTO, error)
 	GetMySuscripciones(ctx context.Context, userID string, limit, offset int) ([]*dto.SuscripcionDTO, error)
 	UpdateSuscripcion(ctx context.Context, id string, req *dto.UpdateSuscripcionRequest) error
-	CancelSuscripcion(ctx context.Context, id string) error
+	CancelSuscripcion(ctx context.Context, id, userID string, esAdmin bool, req *dto.CancelarSuscripcionRequest) error
+	DeshacerCancelacion(ctx context.Context, id, userID string) error
 	GetSuscripcionesWithDetails(ctx context.Context, limit, offset int) ([]map[string]interface{}, int64, error)
+	SetAutoRenovar(ctx context.Context, id, userID string, autoRenovar bool) error
+	PreviewCambioPlan(ctx context.Context, id, userID string, req *dto.CambiarPlanRequest) (*dto.CambioPlanDTO, error)
+	CambiarPlan(ctx context.Context, id, userID string, req *dto.CambiarPlanRequest) (*dto.CambioPlanDTO, error)
+	AgregarAsientos(ctx context.Context, id, userID string, req *dto.CambiarAsientosRequest) (*dto.CambioAsientosDTO, error)
+	QuitarAsientos(ctx context.Context, id, userID string, req *dto.CambiarAsientosRequest) (*dto.CambioAsientosDTO, error)
+	AsignarAsiento(ctx context.Context, id, userID string, req *dto.AsignarAsientoRequest) error
+	LiberarAsiento(ctx context.Context, id, userID, asignadoID string) error
+	GetAsientos(ctx context.Context, id, userID string, esAdmin bool) (*dto.AsientosDTO, error)
+	AgregarComplemento(ctx context.Context, id, userID string, req *dto.AgregarComplementoRequest) (*dto.AltaComplementoDTO, error)
+	QuitarComplemento(ctx context.Context, id, userID, complementoID string) error
+	ProgramarCambioPlan(ctx context.Context, id string, req *dto.ProgramarCambioPlanRequest) (*dto.CambioProgramadoDTO, error)
+	GetCambiosProgramados(ctx context.Context, id, estado string, limit, offset int) ([]*dto.CambioProgramadoDTO, int64, error)
+	CancelarCambioProgramado(ctx context.Context, id, cambioID string) error
+	ComprarRegalo(ctx context.Context, userID string, req *dto.ComprarRegaloRequest) (*dto.RegaloDTO, error)
+	CanjearRegalo(ctx context.Context, userID string, req *dto.CanjearRegaloRequest) (*dto.CanjeRegaloDTO, error)
+	GetMisRegalos(ctx context.Context, userID string, limit, offset int) ([]*dto.RegaloDTO, int64, error)
+	GetAllRegalos(ctx context.Context, estado string, limit, offset int) ([]*dto.RegaloDTO, int64, error)
+	RevocarRegalo(ctx context.Context, id string) error
+	ExpirarRegalo(ctx context.Context, id string, req *dto.ExpirarRegaloRequest) error
+	PausarSuscripcion(ctx context.Context, id, userID string, req *dto.PausarSuscripcionRequest) error
+	ReanudarSuscripcion(ctx context.Context, id, userID string) error
+	GetRenovaciones(ctx context.Context, id, userID string, esAdmin bool, limit, offset int) ([]*dto.RenovacionDTO, error)
+	GetHistorial(ctx context.Context, id, userID string, esAdmin bool, limit, offset int) ([]*dto.EventoSuscripcionDTO, int64, error)
+	MigrarVersionPlan(ctx context.Context, planID string, req *dto.MigrarVersionPlanRequest) (*dto.MigracionVersionPlanDTO, error)
+	ExpireSuscripciones(ctx context.Context) (int64, error)
+	RenewSuscripciones(ctx context.Context) (int64, error)
+	ProcessTrials(ctx context.Context) (int64, error)
+	ResumePausedSuscripciones(ctx context.Context) (int64, error)
+	AplicarCambiosProgramados(ctx context.Context) (int64, error)
+	CancelarPagosPendientes(ctx context.Context) (int64, error)
+	PublicarEventosPendientes(ctx context.Context) (int64, error)
+	ProcesarWebhookPago(ctx context.Context, payload []byte, firma string) error
+}
+
+type CuponService interface {
+	CreateCupon(ctx context.Context, req *dto.CreateCuponRequest) (*dto.CuponDTO, error)
+	GetAllCupones(ctx context.Context, showInactive bool, limit, offset int) ([]*dto.CuponDTO, int64, error)
+	GetCuponByID(ctx context.Context, id string) (*dto.CuponDTO, error)
+	UpdateCupon(ctx context.Context, id string, req *dto.UpdateCuponRequest) error
+	DeleteCupon(ctx context.Context, id string) error
+	ValidarCupon(ctx context.Context, userID string, req *dto.ValidarCuponRequest) (*dto.ValidacionCuponDTO, error)
+}
+
+type ComplementoService interface {
+	CreateComplemento(ctx context.Context, req *dto.CreateComplementoRequest) (*dto.ComplementoDTO, error)
+	GetAllComplementos(ctx context.Context, planID string, showInactive bool, limit, offset int) ([]*dto.ComplementoDTO, int64, error)
+	GetComplementoByID(ctx context.Context, id string) (*dto.ComplementoDTO, error)
+	UpdateComplemento(ctx context.Context, id string, req *dto.UpdateComplementoRequest) error
+	DeleteComplemento(ctx context.Context, id string) error
+}
+
+type CreditoService interface {
+	GetSaldo(ctx context.Context, userID string) (*dto.SaldoCreditoDTO, error)
+	GetMovimientos(ctx context.Context, userID, moneda string, limit, offset int) ([]*dto.MovimientoCreditoDTO, int64, error)
+	OtorgarCredito(ctx context.Context, userID string, req *dto.OtorgarCreditoRequest) (*dto.MovimientoCreditoDTO, error)
+	Reembolsar(ctx context.Context, userID string, req *dto.ReembolsarCreditoRequest) (*dto.MovimientoCreditoDTO, error)
+}
+
+type RecordatorioService interface {
+	EnviarRecordatoriosVencimiento(ctx context.Context) (int64, error)
+}
+
+type ReporteService interface {
+	GetMRR(ctx context.Context, desde, hasta, moneda string) (*dto.ReporteMRRDTO, error)
+	GetCohortes(ctx context.Context, agrupacion, desde, hasta string) (*dto.ReporteCohortesDTO, error)
+}
+
+type ImpuestoService interface {
+	CreateTasa(ctx context.Context, req *dto.CreateTasaImpuestoRequest) (*dto.TasaImpuestoDTO, error)
+	GetAllTasas(ctx context.Context, pais string, showInactive bool, limit, offset int) ([]*dto.TasaImpuestoDTO, int64, error)
+	GetTasaByID(ctx context.Context, id string) (*dto.TasaImpuestoDTO, error)
+	UpdateTasa(ctx context.Context, id string, req *dto.UpdateTasaImpuestoRequest) error
+	DeleteTasa(ctx context.Context, id string) error
+	CalcularImpuesto(ctx context.Context, userID string, req *dto.CalcularImpuestoRequest) (*dto.CalculoImpuestoDTO, error)
+}
+
+type EntitlementService interface {
+	GetEntitlements(ctx context.Context, userID string) (*dto.EntitlementsDTO, error)
+}
+
+type UsoService interface {
+	RegistrarUso(ctx context.Context, userID string, esAdmin bool, req *dto.RegistrarUsoRequest) (*dto.EventoUsoDTO, error)
+	VerificarUso(ctx context.Context, userID string, esAdmin bool, req *dto.VerificarUsoRequest) (*dto.VerificacionUsoDTO, error)
+	GetUsoSuscripcion(ctx context.Context, id, userID string, esAdmin bool) (*dto.UsoSuscripcionDTO, error)
+}
+
+type FacturaService interface {
+	GetMisFacturas(ctx context.Context, userID string, limit, offset int) ([]*dto.FacturaDTO, int64, error)
+	GetAllFacturas(ctx context.Context, estado, userID string, limit, offset int) ([]*dto.FacturaDTO, int64, error)
+	GetFacturaByID(ctx context.Context, id string) (*dto.FacturaDTO, error)
+	EmitirFactura(ctx context.Context, id string) (*dto.FacturaDTO, error)
+	AnularFactura(ctx context.Context, id string) error
 }
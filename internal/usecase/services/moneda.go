@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+)
+
+var (
+	ErrMonedaNoSoportada  = errors.New("moneda no soportada")
+	ErrMonedaDuplicada    = errors.New("el plan tiene más de un precio en la misma moneda")
+	ErrMonedaNoDisponible = errors.New("el plan no tiene precio en esa moneda")
+)
+
+// normalizarMoneda pasa el código a mayúsculas, usa porDefecto si viene vacío
+// y rechaza monedas que no están en la lista soportada.
+func normalizarMoneda(moneda, porDefecto string) (string, error) {
+	moneda = strings.ToUpper(strings.TrimSpace(moneda))
+	if moneda == "" {
+		moneda = strings.ToUpper(porDefecto)
+	}
+	if !entity.MonedaValida(moneda) {
+		return "", ErrMonedaNoSoportada
+	}
+	return moneda, nil
+}
+
+func preciosDesdeDTO(precios []dto.PrecioDTO) ([]entity.PrecioPlan, error) {
+	result := make([]entity.PrecioPlan, 0, len(precios))
+	vistas := make(map[string]bool, len(precios))
+
+	for _, precio := range precios {
+		moneda, err := normalizarMoneda(precio.Moneda, "")
+		if err != nil {
+			return nil, err
+		}
+		if vistas[moneda] {
+			return nil, ErrMonedaDuplicada
+		}
+		vistas[moneda] = true
+
+		result = append(result, entity.PrecioPlan{Moneda: moneda, Monto: precio.Monto})
+	}
+
+	return result, nil
+}
+
+func preciosToDTO(precios []entity.PrecioPlan) []dto.PrecioDTO {
+	result := make([]dto.PrecioDTO, 0, len(precios))
+	for _, precio := range precios {
+		result = append(result, dto.PrecioDTO{Moneda: precio.Moneda, Monto: precio.Monto})
+	}
+	return result
+}
+
+// precioVigente es lo que paga la suscripción por periodo. Las suscripciones
+// anteriores a multi-moneda no guardaron el precio y se lee del plan.
+func precioVigente(suscripcion *entity.Suscripcion, plan *entity.PlanSuscripcion) (int64, error) {
+	if suscripcion.Precio > 0 {
+		return suscripcion.Precio, nil
+	}
+	precio, ok := plan.PrecioEn(suscripcion.GetMoneda())
+	if !ok {
+		return 0, ErrMonedaNoDisponible
+	}
+	return precio, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"regexp"
 	"strings"
 	"sw2p2go/internal/dto"
 	"sw2p2go/internal/entity"
@@ -12,15 +13,27 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrClaveDerechoInvalida = errors.New("la clave de un derecho solo admite minúsculas, números y guiones bajos")
+	ErrDerechoDuplicado     = errors.New("el plan declara dos veces el mismo derecho")
+	ErrLimitesAsientos      = errors.New("el mínimo de asientos supera al máximo")
+)
+
+var claveDerechoRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
 type planService struct {
 	planRepo        repositories.PlanRepository
+	versionRepo     repositories.VersionPlanRepository
 	suscripcionRepo repositories.SuscripcionRepository
+	entitlements    *EntitlementsCache
 }
 
-func NewPlanService(planRepo repositories.PlanRepository, suscripcionRepo repositories.SuscripcionRepository) PlanService {
+func NewPlanService(planRepo repositories.PlanRepository, versionRepo repositories.VersionPlanRepository, suscripcionRepo repositories.SuscripcionRepository, entitlements *EntitlementsCache) PlanService {
 	return &planService{
 		planRepo:        planRepo,
+		versionRepo:     versionRepo,
 		suscripcionRepo: suscripcionRepo,
+		entitlements:    entitlements,
 	}
 }
 
@@ -28,18 +41,56 @@ func (s *planService) CreatePlan(ctx context.Context, req *dto.CreatePlanRequest
 	req.Nombre = strings.TrimSpace(req.Nombre)
 	req.Descripcion = strings.TrimSpace(req.Descripcion)
 
+	intervalo := req.Intervalo
+	if intervalo == "" {
+		intervalo = entity.IntervaloMes
+	}
+
+	intervaloCantidad := req.IntervaloCantidad
+	if intervaloCantidad == 0 {
+		intervaloCantidad = 1
+	}
+
+	precios, err := preciosDesdeDTO(req.Precios)
+	if err != nil {
+		return nil, err
+	}
+
+	derechos, err := derechosDesdeDTO(req.Derechos)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MaxAsientos > 0 && req.MinAsientos > req.MaxAsientos {
+		return nil, ErrLimitesAsientos
+	}
+
 	plan := &entity.PlanSuscripcion{
-		Nombre:      req.Nombre,
-		Descripcion: req.Descripcion,
-		Precio:      req.Precio,
-		Activo:      true,
-		CreadoEn:    time.Now(),
+		Nombre:            req.Nombre,
+		Descripcion:       req.Descripcion,
+		Precios:           precios,
+		ImpuestoIncluido:  req.ImpuestoIncluido,
+		Intervalo:         intervalo,
+		IntervaloCantidad: intervaloCantidad,
+		DiasPrueba:        req.DiasPrueba,
+		MaxDiasPausa:      req.MaxDiasPausa,
+		PorAsiento:        req.PorAsiento,
+		MinAsientos:       req.MinAsientos,
+		MaxAsientos:       req.MaxAsientos,
+		Derechos:          derechos,
+		Version:           1,
+		Activo:            true,
+		CreadoEn:          time.Now(),
 	}
 
 	if err := s.planRepo.Create(ctx, plan); err != nil {
 		return nil, err
 	}
 
+	if err := s.versionRepo.Create(ctx, entity.NuevaVersionPlan(plan, 1)); err != nil {
+		return nil, err
+	}
+
 	return s.entityToDTO(plan), nil
 }
 
@@ -99,8 +150,55 @@ func (s *planService) UpdatePlan(ctx context.Context, id string, req *dto.Update
 		updates["descripcion"] = *req.Descripcion
 	}
 
-	if req.Precio != nil {
-		updates["precio"] = *req.Precio
+	if req.Precios != nil {
+		precios, err := preciosDesdeDTO(*req.Precios)
+		if err != nil {
+			return err
+		}
+		updates["precios"] = precios
+	}
+
+	if req.ImpuestoIncluido != nil {
+		updates["impuesto_incluido"] = *req.ImpuestoIncluido
+	}
+
+	if req.Intervalo != nil {
+		updates["intervalo"] = *req.Intervalo
+	}
+
+	if req.IntervaloCantidad != nil {
+		updates["intervalo_cantidad"] = *req.IntervaloCantidad
+	}
+
+	if req.DiasPrueba != nil {
+		updates["dias_prueba"] = *req.DiasPrueba
+	}
+
+	if req.MaxDiasPausa != nil {
+		updates["max_dias_pausa"] = *req.MaxDiasPausa
+	}
+
+	if req.PorAsiento != nil || req.MinAsientos != nil || req.MaxAsientos != nil {
+		if err := s.validarAsientos(ctx, objectID, req); err != nil {
+			return err
+		}
+		if req.PorAsiento != nil {
+			updates["por_asiento"] = *req.PorAsiento
+		}
+		if req.MinAsientos != nil {
+			updates["min_asientos"] = *req.MinAsientos
+		}
+		if req.MaxAsientos != nil {
+			updates["max_asientos"] = *req.MaxAsientos
+		}
+	}
+
+	if req.Derechos != nil {
+		derechos, err := derechosDesdeDTO(*req.Derechos)
+		if err != nil {
+			return err
+		}
+		updates["derechos"] = derechos
 	}
 
 	if req.Activo != nil {
@@ -111,7 +209,116 @@ func (s *planService) UpdatePlan(ctx context.Context, id string, req *dto.Update
 		return errors.New("no hay campos para actualizar")
 	}
 
-	return s.planRepo.Update(ctx, objectID, updates)
+	version := 0
+	if req.Precios != nil || req.ImpuestoIncluido != nil || req.Derechos != nil {
+		if version, err = s.crearVersion(ctx, objectID, updates); err != nil {
+			return err
+		}
+		updates["version"] = version
+	}
+
+	if err := s.planRepo.Update(ctx, objectID, updates); err != nil {
+		if version > 0 {
+			// Sin borrarla, la próxima edición chocaría con esta versión huérfana
+			return errors.Join(err, s.versionRepo.Delete(ctx, objectID, version))
+		}
+		return err
+	}
+
+	// No se guarda qué usuarios tienen el plan, así que se descarta todo
+	s.entitlements.Clear()
+	return nil
+}
+
+// validarAsientos compara los límites pedidos con los que el plan ya tiene,
+// porque la edición puede cambiar solo uno de los dos. Las suscripciones que
+// quedan fuera de los límites nuevos conservan sus asientos hasta cambiarlos.
+func (s *planService) validarAsientos(ctx context.Context, planID primitive.ObjectID, req *dto.UpdatePlanRequest) error {
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return err
+	}
+
+	minimo, maximo := plan.MinAsientos, plan.MaxAsientos
+	if req.MinAsientos != nil {
+		minimo = *req.MinAsientos
+	}
+	if req.MaxAsientos != nil {
+		maximo = *req.MaxAsientos
+	}
+
+	if maximo > 0 && minimo > maximo {
+		return ErrLimitesAsientos
+	}
+	return nil
+}
+
+// crearVersion guarda los términos que quedarán vigentes tras la edición como
+// una versión nueva. Las suscripciones existentes siguen en la suya. Un plan
+// anterior al versionado guarda primero sus términos actuales como versión 1,
+// que es en la que están sus suscriptores.
+func (s *planService) crearVersion(ctx context.Context, planID primitive.ObjectID, updates map[string]interface{}) (int, error) {
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return 0, err
+	}
+
+	if plan.Version == 0 {
+		err := s.versionRepo.Create(ctx, entity.NuevaVersionPlan(plan, 1))
+		if err != nil && !errors.Is(err, repositories.ErrVersionPlanExistente) {
+			return 0, err
+		}
+	}
+
+	nuevo := *plan
+	if precios, ok := updates["precios"].([]entity.PrecioPlan); ok {
+		nuevo.Precios = precios
+		nuevo.PrecioLegacy = 0
+	}
+	if impuestoIncluido, ok := updates["impuesto_incluido"].(bool); ok {
+		nuevo.ImpuestoIncluido = impuestoIncluido
+	}
+	if derechos, ok := updates["derechos"].([]entity.DerechoPlan); ok {
+		nuevo.Derechos = derechos
+	}
+
+	numero := plan.VersionActual() + 1
+	if err := s.versionRepo.Create(ctx, entity.NuevaVersionPlan(&nuevo, numero)); err != nil {
+		return 0, err
+	}
+
+	return numero, nil
+}
+
+// GetVersiones devuelve las versiones del plan, la más reciente primero.
+func (s *planService) GetVersiones(ctx context.Context, id string) ([]*dto.VersionPlanDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de plan inválido")
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	versiones, err := s.versionRepo.GetByPlan(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un plan que no se editó desde que existe el versionado no tiene ninguna guardada
+	if len(versiones) == 0 {
+		versiones = append(versiones, entity.NuevaVersionPlan(plan, plan.VersionActual()))
+		versiones[0].CreadoEn = plan.CreadoEn
+	}
+
+	result := make([]*dto.VersionPlanDTO, 0, len(versiones))
+	for _, version := range versiones {
+		result = append(result, versionPlanToDTO(version, version.Numero == plan.VersionActual()))
+	}
+
+	return result, nil
 }
 
 func (s *planService) DeletePlan(ctx context.Context, id string) error {
@@ -129,7 +336,12 @@ func (s *planService) DeletePlan(ctx context.Context, id string) error {
 		return errors.New("no se puede eliminar un plan con suscripciones activas")
 	}
 
-	return s.planRepo.Delete(ctx, objectID)
+	if err := s.planRepo.Delete(ctx, objectID); err != nil {
+		return err
+	}
+
+	s.entitlements.Clear()
+	return nil
 }
 
 func (s *planService) GetActivePlans(ctx context.Context, limit, offset int) ([]*dto.PlanSuscripcionDTO, error) {
@@ -147,12 +359,69 @@ func (s *planService) GetActivePlans(ctx context.Context, limit, offset int) ([]
 }
 
 func (s *planService) entityToDTO(plan *entity.PlanSuscripcion) *dto.PlanSuscripcionDTO {
+	return planToDTO(plan)
+}
+
+func planToDTO(plan *entity.PlanSuscripcion) *dto.PlanSuscripcionDTO {
+	intervalo, intervaloCantidad := plan.PeriodoFacturacion()
+
 	return &dto.PlanSuscripcionDTO{
-		ID:          plan.ID.Hex(),
-		Nombre:      plan.Nombre,
-		Descripcion: plan.Descripcion,
-		Precio:      plan.Precio,
-		Activo:      plan.Activo,
-		CreadoEn:    plan.CreadoEn,
+		ID:                plan.ID.Hex(),
+		Nombre:            plan.Nombre,
+		Descripcion:       plan.Descripcion,
+		Precios:           preciosToDTO(plan.ListaPrecios()),
+		ImpuestoIncluido:  plan.ImpuestoIncluido,
+		Intervalo:         intervalo,
+		IntervaloCantidad: intervaloCantidad,
+		DiasPrueba:        plan.DiasPrueba,
+		MaxDiasPausa:      plan.MaxDiasPausa,
+		PorAsiento:        plan.PorAsiento,
+		MinAsientos:       plan.MinAsientos,
+		MaxAsientos:       plan.MaxAsientos,
+		Derechos:          derechosToDTO(plan.Derechos),
+		Version:           plan.VersionActual(),
+		Activo:            plan.Activo,
+		CreadoEn:          plan.CreadoEn,
+	}
+}
+
+// derechosDesdeDTO valida las claves y normaliza cada derecho: un booleano no
+// lleva límite y un límite no lleva habilitado.
+func derechosDesdeDTO(derechos []dto.DerechoDTO) ([]entity.DerechoPlan, error) {
+	result := make([]entity.DerechoPlan, 0, len(derechos))
+	vistas := make(map[string]bool, len(derechos))
+
+	for _, derecho := range derechos {
+		clave := strings.TrimSpace(derecho.Clave)
+		if !claveDerechoRegex.MatchString(clave) {
+			return nil, ErrClaveDerechoInvalida
+		}
+		if vistas[clave] {
+			return nil, ErrDerechoDuplicado
+		}
+		vistas[clave] = true
+
+		d := entity.DerechoPlan{Clave: clave, Tipo: derecho.Tipo}
+		if derecho.Tipo == entity.TipoDerechoLimite {
+			d.Limite = derecho.Limite
+		} else {
+			d.Habilitado = derecho.Habilitado
+		}
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+func derechosToDTO(derechos []entity.DerechoPlan) []dto.DerechoDTO {
+	result := make([]dto.DerechoDTO, 0, len(derechos))
+	for _, derecho := range derechos {
+		result = append(result, dto.DerechoDTO{
+			Clave:      derecho.Clave,
+			Tipo:       derecho.Tipo,
+			Habilitado: derecho.Habilitado,
+			Limite:     derecho.Limite,
+		})
 	}
+	return result
 }
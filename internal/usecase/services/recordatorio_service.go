@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sw2p2go/config"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/notification"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type recordatorioService struct {
+	suscripcionRepo  repositories.SuscripcionRepository
+	userRepo         repositories.UsuarioRepository
+	planRepo         repositories.PlanRepository
+	recordatorioRepo repositories.RecordatorioRepository
+	canal            notification.Canal
+	cfg              *config.Config
+}
+
+func NewRecordatorioService(
+	suscripcionRepo repositories.SuscripcionRepository,
+	userRepo repositories.UsuarioRepository,
+	planRepo repositories.PlanRepository,
+	recordatorioRepo repositories.RecordatorioRepository,
+	canal notification.Canal,
+	cfg *config.Config,
+) RecordatorioService {
+	return &recordatorioService{
+		suscripcionRepo:  suscripcionRepo,
+		userRepo:         userRepo,
+		planRepo:         planRepo,
+		recordatorioRepo: recordatorioRepo,
+		canal:            canal,
+		cfg:              cfg,
+	}
+}
+
+// EnviarRecordatoriosVencimiento avisa a los usuarios cuyas suscripciones no
+// se renuevan solas y vencen dentro de alguna de las ventanas configuradas.
+// Cada suscripción recibe solo el aviso de la ventana más chica en la que
+// está: una que se creó a dos días de vencer no recibe el de siete.
+func (s *recordatorioService) EnviarRecordatoriosVencimiento(ctx context.Context) (int64, error) {
+	now := time.Now()
+	batchSize := s.cfg.Scheduler.RenewalBatchSize
+
+	dias := append([]int(nil), s.cfg.Suscripciones.DiasRecordatorio...)
+	sort.Ints(dias)
+
+	var (
+		total    int64
+		errs     []error
+		anterior int
+	)
+	for _, ventana := range dias {
+		if ventana == anterior {
+			continue
+		}
+		desde := now.AddDate(0, 0, anterior)
+		hasta := now.AddDate(0, 0, ventana)
+		anterior = ventana
+
+		var afterID primitive.ObjectID
+		for {
+			suscripciones, err := s.suscripcionRepo.GetPorVencer(ctx, desde, hasta, afterID, batchSize)
+			if err != nil {
+				return total, err
+			}
+
+			for _, suscripcion := range suscripciones {
+				afterID = suscripcion.ID
+
+				enviado, err := s.enviarRecordatorio(ctx, suscripcion, ventana, now)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("suscripción %s: %w", suscripcion.ID.Hex(), err))
+					continue
+				}
+				if enviado {
+					total++
+				}
+			}
+
+			if len(suscripciones) < batchSize {
+				break
+			}
+		}
+	}
+
+	return total, errors.Join(errs...)
+}
+
+// enviarRecordatorio registra el aviso antes de enviarlo, así una réplica que
+// corre la misma ventana no lo repite. Si el canal falla se borra el registro
+// para reintentar en la próxima ejecución; si el proceso se cae a mitad, el
+// aviso queda "enviando" y no se reenvía, porque es preferible perder un aviso
+// a mandarlo dos veces.
+func (s *recordatorioService) enviarRecordatorio(ctx context.Context, suscripcion *entity.Suscripcion, ventana int, now time.Time) (bool, error) {
+	usuario, err := s.userRepo.GetByID(ctx, suscripcion.UsuarioID)
+	if err != nil {
+		return false, err
+	}
+	if !usuario.IsActive() {
+		return false, nil
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, suscripcion.PlanID)
+	if err != nil {
+		return false, err
+	}
+
+	recordatorio := &entity.RecordatorioVencimiento{
+		ID:            entity.RecordatorioVencimientoID(suscripcion.ID, suscripcion.FechaFin, ventana),
+		SuscripcionID: suscripcion.ID,
+		UsuarioID:     suscripcion.UsuarioID,
+		FechaFin:      suscripcion.FechaFin,
+		Dias:          ventana,
+		Estado:        entity.EstadoRecordatorioEnviando,
+		Canal:         s.canal.Nombre(),
+	}
+	if err := s.recordatorioRepo.Create(ctx, recordatorio); err != nil {
+		if errors.Is(err, repositories.ErrRecordatorioRegistrado) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := s.canal.Enviar(ctx, mensajeVencimiento(recordatorio.ID, usuario, plan, suscripcion, now)); err != nil {
+		if delErr := s.recordatorioRepo.Delete(ctx, recordatorio.ID); delErr != nil {
+			return false, errors.Join(err, delErr)
+		}
+		return false, err
+	}
+
+	return true, s.recordatorioRepo.MarcarEnviado(ctx, recordatorio.ID, time.Now())
+}
+
+// mensajeVencimiento arma el aviso según por qué no se renueva: una
+// cancelación programada se conserva deshaciéndola, y sin renovación
+// automática, activándola.
+func mensajeVencimiento(referencia string, usuario *entity.Usuario, plan *entity.PlanSuscripcion, suscripcion *entity.Suscripcion, now time.Time) notification.Mensaje {
+	fin := suscripcion.FechaFin.In(now.Location())
+
+	// Días de calendario, no de 24 horas: lo que vence esta noche vence hoy
+	hoy := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dia := time.Date(fin.Year(), fin.Month(), fin.Day(), 0, 0, 0, 0, now.Location())
+	var cuando string
+	switch restantes := int(math.Round(dia.Sub(hoy).Hours() / 24)); {
+	case restantes <= 0:
+		cuando = "hoy"
+	case restantes == 1:
+		cuando = "mañana"
+	default:
+		cuando = fmt.Sprintf("en %d días", restantes)
+	}
+
+	motivo := "no se va a renovar automáticamente"
+	accion := "activa la renovación automática o renuévala"
+	if suscripcion.CancelarAlFinal {
+		motivo = "se va a cancelar como pediste"
+		accion = "deshaz la cancelación"
+	}
+
+	return notification.Mensaje{
+		Referencia: referencia,
+		UsuarioID:  usuario.ID.Hex(),
+		Nombre:     usuario.Nombre,
+		Email:      usuario.Email,
+		Telefono:   usuario.Telefono,
+		Asunto:     fmt.Sprintf("Tu suscripción al plan %s vence %s", plan.Nombre, cuando),
+		Cuerpo: fmt.Sprintf(
+			"Hola %s, tu suscripción al plan %s termina el %s y %s. "+
+				"Si quieres conservarla, %s antes de esa fecha.",
+			usuario.Nombre, plan.Nombre, fin.Format("2006-01-02"), motivo, accion,
+		),
+	}
+}
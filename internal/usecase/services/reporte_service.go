@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sw2p2go/config"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxMesesCohortes limita cuántas cohortes entran en un reporte.
+const maxMesesCohortes = 36
+
+var (
+	ErrFormatoMes          = errors.New("formato de mes inválido (use YYYY-MM)")
+	ErrAgrupacionInvalida  = errors.New("agrupación inválida (use registro o primera_suscripcion)")
+	ErrRangoCohortesAmplio = errors.New("el reporte de cohortes admite hasta 36 meses")
+)
+
+type reporteService struct {
+	reporteRepo repositories.ReporteRepository
+	cfg         *config.Config
+}
+
+func NewReporteService(reporteRepo repositories.ReporteRepository, cfg *config.Config) ReporteService {
+	return &reporteService{
+		reporteRepo: reporteRepo,
+		cfg:         cfg,
+	}
+}
+
+// GetMRR calcula el MRR al inicio de desde y al cierre de hasta, y de dónde
+// viene la diferencia. El MRR de una fecha pasada se reconstruye partiendo del
+// precio actual de cada suscripción y deshaciendo los cambios de precio que
+// registró el historial desde esa fecha. Una suscripción que estaba al inicio
+// y no al cierre cuenta como cancelada, y una que está al cierre y no al
+// inicio como nueva, aunque el usuario haya pasado de una a otra.
+func (s *reporteService) GetMRR(ctx context.Context, desde, hasta, moneda string) (*dto.ReporteMRRDTO, error) {
+	moneda, err := normalizarMoneda(moneda, s.cfg.Suscripciones.MonedaDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	inicio := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if desde != "" {
+		if inicio, err = parseFecha(desde); err != nil {
+			return nil, err
+		}
+	}
+	ultimoDia := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if hasta != "" {
+		if ultimoDia, err = parseFecha(hasta); err != nil {
+			return nil, err
+		}
+	}
+	if ultimoDia.Before(inicio) {
+		return nil, ErrRangoFechas
+	}
+
+	// hasta incluye el día completo, pero no se puede medir más allá de ahora
+	fin := ultimoDia.AddDate(0, 0, 1)
+	if fin.After(now) {
+		fin = now
+	}
+	if inicio.After(fin) {
+		inicio = fin
+	}
+
+	mrrInicial, err := s.mrrEn(ctx, moneda, inicio)
+	if err != nil {
+		return nil, err
+	}
+	mrrFinal, err := s.mrrEn(ctx, moneda, fin)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalInicial, totalFinal, nuevo, expansion, contraccion, cancelado float64
+	clientesIniciales := make(map[primitive.ObjectID]bool)
+	clientes := make(map[primitive.ObjectID]bool)
+
+	for id, antes := range mrrInicial {
+		totalInicial += antes.MRR
+		clientesIniciales[antes.UsuarioID] = true
+
+		despues, sigue := mrrFinal[id]
+		if !sigue {
+			cancelado += antes.MRR
+			continue
+		}
+		if cambio := despues.MRR - antes.MRR; cambio > 0 {
+			expansion += cambio
+		} else {
+			contraccion -= cambio
+		}
+	}
+	for id, despues := range mrrFinal {
+		totalFinal += despues.MRR
+		clientes[despues.UsuarioID] = true
+
+		if _, estaba := mrrInicial[id]; !estaba {
+			nuevo += despues.MRR
+		}
+	}
+
+	perdidos := 0
+	for usuarioID := range clientesIniciales {
+		if !clientes[usuarioID] {
+			perdidos++
+		}
+	}
+
+	result := &dto.ReporteMRRDTO{
+		Moneda:            moneda,
+		Desde:             inicio.Format("2006-01-02"),
+		Hasta:             ultimoDia.Format("2006-01-02"),
+		MRRInicial:        int64(math.Round(totalInicial)),
+		MRR:               int64(math.Round(totalFinal)),
+		ARR:               int64(math.Round(totalFinal * 12)),
+		MRRNuevo:          int64(math.Round(nuevo)),
+		MRRExpansion:      int64(math.Round(expansion)),
+		MRRContraccion:    int64(math.Round(contraccion)),
+		MRRCancelado:      int64(math.Round(cancelado)),
+		ClientesIniciales: len(clientesIniciales),
+		Clientes:          len(clientes),
+		ClientesPerdidos:  perdidos,
+	}
+	if len(clientesIniciales) > 0 {
+		result.TasaChurnClientes = math.Round(float64(perdidos)*10000/float64(len(clientesIniciales))) / 100
+	}
+	if len(clientes) > 0 {
+		result.ARPU = int64(math.Round(totalFinal / float64(len(clientes))))
+	}
+
+	return result, nil
+}
+
+// mrrEn devuelve el MRR que aportaba cada suscripción en el instante en.
+func (s *reporteService) mrrEn(ctx context.Context, moneda string, en time.Time) (map[primitive.ObjectID]*entity.MRRSuscripcion, error) {
+	activas, err := s.reporteRepo.GetMRRSuscripciones(ctx, moneda, en)
+	if err != nil {
+		return nil, err
+	}
+	cambios, err := s.reporteRepo.GetCambiosMRR(ctx, moneda, en, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[primitive.ObjectID]*entity.MRRSuscripcion, len(activas))
+	for _, activa := range activas {
+		activa.MRR = max(activa.MRR-cambios[activa.SuscripcionID], 0)
+		result[activa.SuscripcionID] = activa
+	}
+	return result, nil
+}
+
+// GetCohortes arma la matriz de retención de las cohortes de desde a hasta,
+// ambos meses incluidos; por defecto los últimos 12. Un usuario sigue en un
+// mes si al cierre del mes (o ahora, en el mes en curso) tiene una suscripción
+// vigente, aunque esté en prueba o pausada.
+func (s *reporteService) GetCohortes(ctx context.Context, agrupacion, desde, hasta string) (*dto.ReporteCohortesDTO, error) {
+	switch agrupacion {
+	case "":
+		agrupacion = entity.CohortePorRegistro
+	case entity.CohortePorRegistro, entity.CohortePorPrimeraSuscripcion:
+	default:
+		return nil, ErrAgrupacionInvalida
+	}
+
+	now := time.Now()
+	mesActual := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	ultimo := mesActual
+	if hasta != "" {
+		parsed, err := time.Parse("2006-01", hasta)
+		if err != nil {
+			return nil, ErrFormatoMes
+		}
+		ultimo = parsed
+	}
+	primero := ultimo.AddDate(0, -11, 0)
+	if desde != "" {
+		parsed, err := time.Parse("2006-01", desde)
+		if err != nil {
+			return nil, ErrFormatoMes
+		}
+		primero = parsed
+	}
+	if ultimo.Before(primero) {
+		return nil, ErrRangoFechas
+	}
+	if mesesEntre(primero, ultimo) >= maxMesesCohortes {
+		return nil, ErrRangoCohortesAmplio
+	}
+
+	usuarios, err := s.reporteRepo.GetCohorteUsuarios(ctx, agrupacion, primero, ultimo.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	porCohorte := make(map[int][]*entity.CohorteUsuario)
+	for _, usuario := range usuarios {
+		inicio := usuario.Inicio.UTC()
+		mes := time.Date(inicio.Year(), inicio.Month(), 1, 0, 0, 0, 0, time.UTC)
+		i := mesesEntre(primero, mes)
+		porCohorte[i] = append(porCohorte[i], usuario)
+	}
+
+	result := &dto.ReporteCohortesDTO{
+		Agrupacion: agrupacion,
+		Desde:      primero.Format("2006-01"),
+		Hasta:      ultimo.Format("2006-01"),
+		Cohortes:   []dto.CohorteDTO{},
+	}
+	for i := 0; i <= mesesEntre(primero, ultimo); i++ {
+		mes := primero.AddDate(0, i, 0)
+		miembros := porCohorte[i]
+		cohorte := dto.CohorteDTO{
+			Mes:       mes.Format("2006-01"),
+			Usuarios:  len(miembros),
+			Activos:   []int{},
+			Retencion: []float64{},
+		}
+
+		for siguiente := mes; !siguiente.After(mesActual); siguiente = siguiente.AddDate(0, 1, 0) {
+			en := siguiente.AddDate(0, 1, 0).Add(-time.Nanosecond)
+			if en.After(now) {
+				en = now
+			}
+
+			activos := 0
+			for _, miembro := range miembros {
+				if miembro.Activo(en) {
+					activos++
+				}
+			}
+
+			retencion := 0.0
+			if len(miembros) > 0 {
+				retencion = math.Round(float64(activos)*10000/float64(len(miembros))) / 100
+			}
+			cohorte.Activos = append(cohorte.Activos, activos)
+			cohorte.Retencion = append(cohorte.Retencion, retencion)
+		}
+
+		result.Cohortes = append(result.Cohortes, cohorte)
+	}
+
+	return result, nil
+}
+
+// mesesEntre cuenta los meses de calendario de desde a hasta.
+func mesesEntre(desde, hasta time.Time) int {
+	return (hasta.Year()-desde.Year())*12 + int(hasta.Month()) - int(desde.Month())
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// esperaReservaAsiento es lo que puede tardar una asignación entre reservar el
+// asiento y agregarlo a la suscripción.
+const esperaReservaAsiento = time.Minute
+
+var (
+	ErrCantidadAsientosInvalida = errors.New("la cantidad de asientos no está dentro de los límites del plan")
+	ErrPlanSinAsientos          = errors.New("el plan no es por asiento")
+	ErrAsientosOcupados         = errors.New("hay más asientos asignados que la cantidad pedida")
+	ErrSinAsientosLibres        = errors.New("no quedan asientos libres")
+	ErrUsuarioConAsiento        = errors.New("el usuario ya ocupa un asiento en una suscripción vigente")
+	ErrAsientoNoAsignado        = errors.New("el usuario no ocupa un asiento en esta suscripción")
+	ErrCargoPendiente           = errors.New("la suscripción tiene un cargo pendiente de pago")
+)
+
+func (s *suscripcionService) AgregarAsientos(ctx context.Context, id, userID string, req *dto.CambiarAsientosRequest) (*dto.CambioAsientosDTO, error) {
+	return s.cambiarAsientos(ctx, id, userID, req.Cantidad)
+}
+
+func (s *suscripcionService) QuitarAsientos(ctx context.Context, id, userID string, req *dto.CambiarAsientosRequest) (*dto.CambioAsientosDTO, error) {
+	return s.cambiarAsientos(ctx, id, userID, -req.Cantidad)
+}
+
+// cambiarAsientos suma delta asientos a la suscripción a mitad de periodo. Los
+// asientos agregados se prorratean por lo que queda del periodo y se facturan
+// de inmediato; los quitados suman crédito a favor del usuario. El descuento del
+// cupón no se aplica al prorrateo, sí al precio nuevo desde la renovación.
+func (s *suscripcionService) cambiarAsientos(ctx context.Context, id, userID string, delta int) (*dto.CambioAsientosDTO, error) {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !suscripcion.IsActive() {
+		return nil, errors.New("la suscripción no está activa")
+	}
+
+	plan, err := planDeSuscripcion(ctx, s.planRepo, s.versionRepo, suscripcion)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.PorAsiento {
+		return nil, ErrPlanSinAsientos
+	}
+
+	if err := s.verificarSinCargoPendiente(ctx, suscripcion); err != nil {
+		return nil, err
+	}
+
+	anterior := suscripcion.GetCantidad()
+	nueva := anterior + delta
+	if !plan.AdmiteAsientos(nueva) {
+		return nil, ErrCantidadAsientosInvalida
+	}
+	if nueva < len(suscripcion.Asientos) {
+		return nil, ErrAsientosOcupados
+	}
+
+	// El plan ya trae los términos de la versión de la suscripción
+	unitario, ok := plan.PrecioEn(suscripcion.GetMoneda())
+	if !ok {
+		return nil, ErrMonedaNoDisponible
+	}
+
+	now := time.Now()
+	cambio := &entity.CambioAsientos{
+		SuscripcionID:    suscripcion.ID,
+		UsuarioID:        suscripcion.UsuarioID,
+		CantidadAnterior: anterior,
+		CantidadNueva:    nueva,
+		Moneda:           suscripcion.GetMoneda(),
+		PrecioUnitario:   unitario,
+		CreadoEn:         now,
+	}
+
+	// Durante la prueba gratuita no hay nada que prorratear
+	var prorrateoUnitario int64
+	if !suscripcion.IsTrial() {
+		restante := suscripcion.FechaFin.Sub(now)
+		duracion := suscripcion.FechaFin.Sub(suscripcion.InicioPeriodoActual())
+		prorrateoUnitario = prorratear(unitario, fraccion(restante, duracion))
+		cambio.DiasRestantes = int(math.Ceil(restante.Hours() / 24))
+	}
+	if delta > 0 {
+		cambio.Cargo = prorrateoUnitario * int64(delta)
+	} else {
+		cambio.Credito = prorrateoUnitario * int64(-delta)
+	}
+
+	expected := map[string]interface{}{
+		"estado":    suscripcion.Estado,
+		"plan_id":   suscripcion.PlanID,
+		"fecha_fin": suscripcion.FechaFin,
+	}
+	updates := map[string]interface{}{
+		"cantidad": nueva,
+		"precio":   unitario * int64(nueva),
+	}
+
+	err = s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionAsientosCambiados, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.CambiarCantidad(ctx, suscripcion.ID, expected, anterior, nueva, updates, evento)
+	}, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	if cambio.Cargo > 0 {
+		factura, err := s.facturarCargo(ctx, suscripcion, plan, entity.MotivoFacturaAsientos, entity.LineaFactura{
+			Tipo:           entity.LineaFacturaAsientos,
+			Descripcion:    fmt.Sprintf("Asientos adicionales del plan %s (%s - %s)", plan.Nombre, now.Format("2006-01-02"), suscripcion.FechaFin.Format("2006-01-02")),
+			Cantidad:       delta,
+			PrecioUnitario: prorrateoUnitario,
+			Monto:          cambio.Cargo,
+		}, now)
+		if factura != nil {
+			cambio.FacturaID = &factura.ID
+		}
+		if err != nil {
+			return nil, errors.Join(err, s.cambioAsientosRepo.Create(ctx, cambio))
+		}
+	}
+
+	if err := s.cambioAsientosRepo.Create(ctx, cambio); err != nil {
+		return nil, err
+	}
+
+	if cambio.Credito > 0 {
+		err := registrarCredito(ctx, s.creditoRepo, &entity.MovimientoCredito{
+			UsuarioID:     suscripcion.UsuarioID,
+			Moneda:        cambio.Moneda,
+			Monto:         cambio.Credito,
+			Motivo:        entity.MotivoCreditoAsientos,
+			SuscripcionID: &suscripcion.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cambioAsientosToDTO(cambio), nil
+}
+
+// deshacerCambioAsientos vuelve a la cantidad y el precio de antes de un
+// cambio de asientos cuyo cargo no se pagó. Los usuarios que ya ocupaban los
+// asientos quitados los conservan hasta que se liberen, pero no se pueden
+// asignar otros.
+func (s *suscripcionService) deshacerCambioAsientos(ctx context.Context, suscripcion *entity.Suscripcion, cambio *entity.CambioAsientos) error {
+	expected := map[string]interface{}{"cantidad": cambio.CantidadNueva}
+	updates := map[string]interface{}{
+		"cantidad": cambio.CantidadAnterior,
+		"precio":   cambio.PrecioUnitario * int64(cambio.CantidadAnterior),
+	}
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionAsientosCambiados, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	}, updates)
+}
+
+// verificarSinCargoPendiente impide cambiar asientos o plan mientras un cargo
+// anterior a mitad de periodo no se pagó: si se rechaza se deshace, y lo que
+// se calcule encima daría crédito por algo que no se pagó.
+func (s *suscripcionService) verificarSinCargoPendiente(ctx context.Context, suscripcion *entity.Suscripcion) error {
+	n, err := s.facturaRepo.Count(ctx, map[string]interface{}{
+		"suscripcion_id": suscripcion.ID,
+		"estado":         entity.EstadoFacturaAbierta,
+		"motivo": map[string]interface{}{"$in": []string{
+			entity.MotivoFacturaAsientos,
+			entity.MotivoFacturaComplemento,
+			entity.MotivoFacturaCambioPlan,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrCargoPendiente
+	}
+	return nil
+}
+
+// AsignarAsiento le da a otro usuario un asiento libre de la suscripción. Un
+// usuario ocupa como mucho un asiento entre todas las suscripciones vigentes,
+// para que sus derechos salgan de una sola.
+func (s *suscripcionService) AsignarAsiento(ctx context.Context, id, userID string, req *dto.AsignarAsientoRequest) error {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !suscripcion.IsActive() {
+		return errors.New("la suscripción no está activa")
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, suscripcion.PlanID)
+	if err != nil {
+		return err
+	}
+	if !plan.PorAsiento {
+		return ErrPlanSinAsientos
+	}
+
+	asignadoID, err := primitive.ObjectIDFromHex(req.UsuarioID)
+	if err != nil {
+		return errors.New("ID de usuario inválido")
+	}
+
+	usuario, err := s.userRepo.GetByID(ctx, asignadoID)
+	if err != nil {
+		return errors.New("usuario no encontrado")
+	}
+	if !usuario.Estado {
+		return errors.New("usuario inactivo")
+	}
+
+	if suscripcion.TieneAsiento(asignadoID) {
+		return ErrUsuarioConAsiento
+	}
+	otra, err := s.suscripcionRepo.GetActiveByAsiento(ctx, asignadoID)
+	if err != nil {
+		return err
+	}
+	if otra != nil {
+		return ErrUsuarioConAsiento
+	}
+
+	if len(suscripcion.Asientos) >= suscripcion.GetCantidad() {
+		return ErrSinAsientosLibres
+	}
+
+	now := time.Now()
+	if err := s.ocuparAsiento(ctx, &entity.AsientoOcupado{UsuarioID: asignadoID, SuscripcionID: suscripcion.ID, OcupadoEn: now}); err != nil {
+		return err
+	}
+
+	asignacion := entity.AsignacionAsiento{UsuarioID: asignadoID, AsignadoEn: now}
+	updates := map[string]interface{}{"asiento": asignadoID}
+
+	err = s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionAsientoAsignado, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.AsignarAsiento(ctx, suscripcion.ID, asignacion, evento)
+	}, updates)
+	if err != nil {
+		return errors.Join(err, s.asientoRepo.Liberar(ctx, asignadoID, suscripcion.ID))
+	}
+
+	s.entitlements.Delete(asignadoID.Hex())
+	return nil
+}
+
+func (s *suscripcionService) LiberarAsiento(ctx context.Context, id, userID, asignadoID string) error {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(asignadoID)
+	if err != nil {
+		return errors.New("ID de usuario inválido")
+	}
+
+	if !suscripcion.TieneAsiento(objectID) {
+		return ErrAsientoNoAsignado
+	}
+
+	// El registro del evento invalida los derechos de quienes ocupaban asientos,
+	// incluido el que se libera
+	updates := map[string]interface{}{"asiento": objectID}
+	err = s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionAsientoLiberado, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.LiberarAsiento(ctx, suscripcion.ID, objectID, evento)
+	}, updates)
+	if err != nil {
+		return err
+	}
+
+	return s.asientoRepo.Liberar(ctx, objectID, suscripcion.ID)
+}
+
+// ocuparAsiento reserva el asiento del usuario para la suscripción. Una reserva
+// anterior se retoma si su suscripción ya terminó, o si no llegó a tener el
+// asiento y pasó esperaReservaAsiento: la asignación que la hizo falló.
+func (s *suscripcionService) ocuparAsiento(ctx context.Context, asiento *entity.AsientoOcupado) error {
+	err := s.asientoRepo.Ocupar(ctx, asiento)
+	if !errors.Is(err, repositories.ErrAsientoOcupado) {
+		return err
+	}
+
+	anterior, err := s.asientoRepo.GetByUsuario(ctx, asiento.UsuarioID)
+	if err != nil {
+		return err
+	}
+	if anterior == nil {
+		// Se liberó entre las dos lecturas
+		err = s.asientoRepo.Ocupar(ctx, asiento)
+	} else {
+		err = s.retomarAsiento(ctx, asiento, anterior)
+	}
+	if errors.Is(err, repositories.ErrAsientoOcupado) {
+		return ErrUsuarioConAsiento
+	}
+	return err
+}
+
+func (s *suscripcionService) retomarAsiento(ctx context.Context, asiento, anterior *entity.AsientoOcupado) error {
+	otra, err := s.suscripcionRepo.GetByID(ctx, anterior.SuscripcionID)
+	if err != nil {
+		return err
+	}
+	if otra.IsVigente() && (otra.TieneAsiento(asiento.UsuarioID) || time.Since(anterior.OcupadoEn) < esperaReservaAsiento) {
+		return ErrUsuarioConAsiento
+	}
+	return s.asientoRepo.Reasignar(ctx, asiento, anterior)
+}
+
+// GetAsientos lista quién ocupa los asientos. Solo el dueño o un administrador
+// pueden verlos.
+func (s *suscripcionService) GetAsientos(ctx context.Context, id, userID string, esAdmin bool) (*dto.AsientosDTO, error) {
+	var (
+		suscripcion *entity.Suscripcion
+		err         error
+	)
+	if esAdmin {
+		objectID, parseErr := primitive.ObjectIDFromHex(id)
+		if parseErr != nil {
+			return nil, errors.New("ID de suscripción inválido")
+		}
+		suscripcion, err = s.suscripcionRepo.GetByID(ctx, objectID)
+	} else {
+		suscripcion, err = s.getOwnedSuscripcion(ctx, id, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.AsientosDTO{
+		SuscripcionID: suscripcion.ID.Hex(),
+		Cantidad:      suscripcion.GetCantidad(),
+		Libres:        max(suscripcion.GetCantidad()-len(suscripcion.Asientos), 0),
+		Asientos:      make([]dto.AsientoDTO, 0, len(suscripcion.Asientos)),
+	}
+	for _, asiento := range suscripcion.Asientos {
+		result.Asientos = append(result.Asientos, dto.AsientoDTO{
+			UsuarioID:  asiento.UsuarioID.Hex(),
+			AsignadoEn: asiento.AsignadoEn,
+		})
+	}
+
+	return result, nil
+}
+
+func cambioAsientosToDTO(cambio *entity.CambioAsientos) *dto.CambioAsientosDTO {
+	result := &dto.CambioAsientosDTO{
+		SuscripcionID:    cambio.SuscripcionID.Hex(),
+		CantidadAnterior: cambio.CantidadAnterior,
+		CantidadNueva:    cambio.CantidadNueva,
+		Moneda:           cambio.Moneda,
+		PrecioUnitario:   cambio.PrecioUnitario,
+		PrecioNuevo:      cambio.PrecioUnitario * int64(cambio.CantidadNueva),
+		DiasRestantes:    cambio.DiasRestantes,
+		Credito:          cambio.Credito,
+		Cargo:            cambio.Cargo,
+		CreadoEn:         cambio.CreadoEn,
+	}
+	if cambio.FacturaID != nil {
+		result.FacturaID = cambio.FacturaID.Hex()
+	}
+	return result
+}
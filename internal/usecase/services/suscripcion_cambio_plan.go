@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *suscripcionService) PreviewCambioPlan(ctx context.Context, id, userID string, req *dto.CambiarPlanRequest) (*dto.CambioPlanDTO, error) {
+	_, cambio, err := s.prepareCambioPlan(ctx, id, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return cambioPlanToDTO(cambio), nil
+}
+
+// CambiarPlan aplica el cambio de plan de inmediato, manteniendo la fecha_fin
+// y registrando el prorrateo, o lo deja pendiente para la próxima renovación.
+func (s *suscripcionService) CambiarPlan(ctx context.Context, id, userID string, req *dto.CambiarPlanRequest) (*dto.CambioPlanDTO, error) {
+	suscripcion, cambio, err := s.prepareCambioPlan(ctx, id, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cambio.Modo == entity.ModoCambioInmediato {
+		if err := s.verificarSinCargoPendiente(ctx, suscripcion); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.aplicarCambioPlan(ctx, suscripcion, cambio, nil); err != nil {
+		return nil, err
+	}
+
+	return cambioPlanToDTO(cambio), nil
+}
+
+// aplicarCambioPlan guarda el cambio en la suscripción y lo registra en el
+// historial y en cambios_plan. detalle se agrega al evento sin guardarse en la
+// suscripción. Un cambio inmediato a un plan más caro factura y cobra la
+// diferencia prorrateada, y se deshace si no se puede facturar; a uno más
+// barato la deja como crédito a favor.
+func (s *suscripcionService) aplicarCambioPlan(ctx context.Context, suscripcion *entity.Suscripcion, cambio *entity.CambioPlan, detalle map[string]interface{}) error {
+	expected := map[string]interface{}{
+		"estado":    suscripcion.Estado,
+		"plan_id":   suscripcion.PlanID,
+		"fecha_fin": suscripcion.FechaFin,
+	}
+
+	tipo := entity.EventoSuscripcionCambioProgramado
+	updates := map[string]interface{}{
+		"plan_pendiente_id": cambio.PlanNuevoID,
+	}
+	if cambio.Modo == entity.ModoCambioInmediato {
+		tipo = entity.EventoSuscripcionPlanCambiado
+		updates = map[string]interface{}{
+			"plan_id":           cambio.PlanNuevoID,
+			"plan_version":      cambio.PlanVersionNueva,
+			"precio":            cambio.PrecioNuevo,
+			"plan_pendiente_id": nil,
+		}
+	}
+
+	despues := make(map[string]interface{}, len(updates)+len(detalle))
+	for campo, valor := range updates {
+		despues[campo] = valor
+	}
+	for campo, valor := range detalle {
+		despues[campo] = valor
+	}
+
+	evento := nuevoEvento(ctx, suscripcion, tipo, valoresAnteriores(suscripcion, updates), despues)
+	err := s.aplicarEvento(ctx, suscripcion, evento, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Si la diferencia no se pudo facturar, vuelve al plan que estaba pagando
+	if cambio.Modo == entity.ModoCambioInmediato && cambio.MontoNeto > 0 {
+		factura, err := s.facturarCambioPlan(ctx, suscripcion, cambio)
+		if err != nil {
+			return errors.Join(err, s.anularFacturaAbierta(ctx, factura, time.Now()), s.deshacerCambioPlan(ctx, suscripcion, cambio))
+		}
+	}
+
+	if err := s.cambioPlanRepo.Create(ctx, cambio); err != nil {
+		return err
+	}
+
+	if cambio.MontoNeto >= 0 {
+		return nil
+	}
+	return registrarCredito(ctx, s.creditoRepo, &entity.MovimientoCredito{
+		UsuarioID:     suscripcion.UsuarioID,
+		Moneda:        cambio.Moneda,
+		Monto:         -cambio.MontoNeto,
+		Motivo:        entity.MotivoCreditoCambioPlan,
+		SuscripcionID: &suscripcion.ID,
+	})
+}
+
+// deshacerCambioPlan devuelve la suscripción al plan, la versión y el precio
+// que tenía antes de un cambio inmediato, si sigue en el plan nuevo.
+func (s *suscripcionService) deshacerCambioPlan(ctx context.Context, suscripcion *entity.Suscripcion, cambio *entity.CambioPlan) error {
+	cambiada := *suscripcion
+	cambiada.PlanID = cambio.PlanNuevoID
+	cambiada.PlanVersion = cambio.PlanVersionNueva
+	cambiada.Precio = cambio.PrecioNuevo
+
+	expected := map[string]interface{}{
+		"plan_id":      cambio.PlanNuevoID,
+		"plan_version": cambio.PlanVersionNueva,
+	}
+	updates := map[string]interface{}{
+		"plan_id":      cambio.PlanAnteriorID,
+		"plan_version": cambio.PlanVersionPrevia,
+		"precio":       cambio.PrecioPrevio,
+	}
+	return s.aplicarConEvento(ctx, &cambiada, entity.EventoSuscripcionPlanCambiado, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	}, updates)
+}
+
+// facturarCambioPlan factura el cargo neto del cambio con el plan y la versión
+// nuevos, que son los que definen el impuesto.
+func (s *suscripcionService) facturarCambioPlan(ctx context.Context, suscripcion *entity.Suscripcion, cambio *entity.CambioPlan) (*entity.Factura, error) {
+	actualizada := *suscripcion
+	actualizada.PlanID = cambio.PlanNuevoID
+	actualizada.PlanVersion = cambio.PlanVersionNueva
+	actualizada.Precio = cambio.PrecioNuevo
+
+	plan, err := planDeSuscripcion(ctx, s.planRepo, s.versionRepo, &actualizada)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	factura, err := s.facturarCargo(ctx, &actualizada, plan, entity.MotivoFacturaCambioPlan, entity.LineaFactura{
+		Tipo:           entity.LineaFacturaPlan,
+		Descripcion:    fmt.Sprintf("Cambio al plan %s (%s - %s)", plan.Nombre, now.Format("2006-01-02"), suscripcion.FechaFin.Format("2006-01-02")),
+		Cantidad:       1,
+		PrecioUnitario: cambio.MontoNeto,
+		Monto:          cambio.MontoNeto,
+	}, now)
+	if factura != nil {
+		cambio.FacturaID = &factura.ID
+	}
+	return factura, err
+}
+
+func (s *suscripcionService) prepareCambioPlan(ctx context.Context, id, userID string, req *dto.CambiarPlanRequest) (*entity.Suscripcion, *entity.CambioPlan, error) {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.cotizarCambioPlan(ctx, suscripcion, req)
+}
+
+// cotizarCambioPlan valida el cambio de plan y calcula su prorrateo sin
+// aplicarlo.
+func (s *suscripcionService) cotizarCambioPlan(ctx context.Context, suscripcion *entity.Suscripcion, req *dto.CambiarPlanRequest) (*entity.Suscripcion, *entity.CambioPlan, error) {
+	if !suscripcion.IsActive() {
+		return nil, nil, errors.New("la suscripción no está activa")
+	}
+
+	planNuevoID, err := primitive.ObjectIDFromHex(req.PlanID)
+	if err != nil {
+		return nil, nil, errors.New("ID de plan inválido")
+	}
+
+	if planNuevoID == suscripcion.PlanID {
+		return nil, nil, errors.New("la suscripción ya tiene ese plan")
+	}
+
+	planNuevo, err := s.planRepo.GetByID(ctx, planNuevoID)
+	if err != nil {
+		return nil, nil, errors.New("plan no encontrado")
+	}
+	if !planNuevo.Activo {
+		return nil, nil, errors.New("plan inactivo")
+	}
+
+	// La suscripción mantiene su moneda al cambiar de plan
+	moneda := suscripcion.GetMoneda()
+	precioNuevo, ok := planNuevo.PrecioEn(moneda)
+	if !ok {
+		return nil, nil, ErrMonedaNoDisponible
+	}
+
+	// También mantiene sus asientos, así que el plan nuevo tiene que admitirlos
+	cantidad := suscripcion.GetCantidad()
+	if !planNuevo.AdmiteAsientos(cantidad) {
+		return nil, nil, ErrCantidadAsientosInvalida
+	}
+	precioNuevo *= int64(cantidad)
+
+	// Los complementos no se quitan solos: el usuario los tiene que quitar antes
+	compatibles, err := s.complementosCompatibles(ctx, suscripcion, planNuevoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !compatibles {
+		return nil, nil, ErrComplementosIncompatibles
+	}
+
+	planActual, err := s.planRepo.GetByID(ctx, suscripcion.PlanID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modo := req.Modo
+	if modo == "" {
+		modo = entity.ModoCambioInmediato
+	}
+
+	now := time.Now()
+	cambio := &entity.CambioPlan{
+		SuscripcionID:     suscripcion.ID,
+		UsuarioID:         suscripcion.UsuarioID,
+		PlanAnteriorID:    suscripcion.PlanID,
+		PlanVersionPrevia: suscripcion.PlanVersion,
+		PrecioPrevio:      suscripcion.Precio,
+		PlanNuevoID:       planNuevoID,
+		PlanVersionNueva:  planNuevo.VersionActual(),
+		Modo:              modo,
+		Moneda:            moneda,
+		PrecioNuevo:       precioNuevo,
+		FechaEfectiva:     now,
+	}
+
+	if modo == entity.ModoCambioFinPeriodo {
+		// El plan pendiente solo se aplica si la suscripción llega a renovarse
+		if !suscripcion.AutoRenovar {
+			return nil, nil, errors.New("la renovación automática está desactivada")
+		}
+		cambio.FechaEfectiva = suscripcion.FechaFin
+		return suscripcion, cambio, nil
+	}
+
+	// Durante la prueba gratuita no hay nada que prorratear
+	if suscripcion.IsTrial() {
+		return suscripcion, cambio, nil
+	}
+
+	precioActual, err := precioVigente(suscripcion, planActual)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cambio.DiasRestantes, cambio.Credito, cambio.Cargo = calcularProrrateo(suscripcion, precioActual, planNuevo, precioNuevo, now)
+	cambio.MontoNeto = cambio.Cargo - cambio.Credito
+
+	return suscripcion, cambio, nil
+}
+
+// calcularProrrateo devuelve los días que faltan del periodo, el crédito por la
+// parte no usada del plan actual y el cargo por ese mismo tiempo en el plan
+// nuevo, en unidad menor. Cada precio se reparte sobre la duración de su propio
+// intervalo, así que se pueden comparar planes mensuales con anuales.
+func calcularProrrateo(suscripcion *entity.Suscripcion, precioActual int64, planNuevo *entity.PlanSuscripcion, precioNuevo int64, now time.Time) (int, int64, int64) {
+	restante := suscripcion.FechaFin.Sub(now)
+	if restante <= 0 {
+		return 0, 0, 0
+	}
+
+	inicio := suscripcion.InicioPeriodoActual()
+	duracionActual := suscripcion.FechaFin.Sub(inicio)
+	duracionNueva := planNuevo.CalcularFechaFin(inicio, suscripcion.DiaAncla()).Sub(inicio)
+
+	dias := int(math.Ceil(restante.Hours() / 24))
+	credito := prorratear(precioActual, fraccion(restante, duracionActual))
+	cargo := prorratear(precioNuevo, fraccion(restante, duracionNueva))
+
+	return dias, credito, cargo
+}
+
+func fraccion(parte, total time.Duration) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Min(1, float64(parte)/float64(total))
+}
+
+func prorratear(monto int64, fraccion float64) int64 {
+	return int64(math.Round(float64(monto) * fraccion))
+}
+
+func cambioPlanToDTO(cambio *entity.CambioPlan) *dto.CambioPlanDTO {
+	result := &dto.CambioPlanDTO{
+		SuscripcionID:  cambio.SuscripcionID.Hex(),
+		PlanAnteriorID: cambio.PlanAnteriorID.Hex(),
+		PlanNuevoID:    cambio.PlanNuevoID.Hex(),
+		Modo:           cambio.Modo,
+		Moneda:         cambio.Moneda,
+		PrecioNuevo:    cambio.PrecioNuevo,
+		DiasRestantes:  cambio.DiasRestantes,
+		Credito:        cambio.Credito,
+		Cargo:          cambio.Cargo,
+		MontoNeto:      cambio.MontoNeto,
+		FechaEfectiva:  cambio.FechaEfectiva,
+	}
+	if cambio.FacturaID != nil {
+		result.FacturaID = cambio.FacturaID.Hex()
+	}
+
+	return result
+}
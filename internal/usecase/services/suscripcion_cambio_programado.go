@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sw2p2go/internal/actor"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrFechaEfectivaPasada         = errors.New("la fecha efectiva debe ser posterior a hoy")
+	ErrCambioProgramadoExistente   = repositories.ErrCambioProgramadoPendiente
+	ErrCambioProgramadoNoPendiente = errors.New("el cambio programado ya no está pendiente")
+)
+
+// ProgramarCambioPlan agenda el paso de la suscripción a otro plan en una
+// fecha. Las reglas del cambio inmediato se validan ahora y de nuevo al
+// aplicarlo, porque el plan o la suscripción pueden cambiar mientras tanto.
+func (s *suscripcionService) ProgramarCambioPlan(ctx context.Context, id string, req *dto.ProgramarCambioPlanRequest) (*dto.CambioProgramadoDTO, error) {
+	suscripcion, err := s.getSuscripcion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fechaEfectiva, err := parseFecha(req.FechaEfectiva)
+	if err != nil {
+		return nil, err
+	}
+	if !fechaEfectiva.After(time.Now()) {
+		return nil, ErrFechaEfectivaPasada
+	}
+
+	_, cambioPlan, err := s.cotizarCambioPlan(ctx, suscripcion, &dto.CambiarPlanRequest{PlanID: req.PlanID})
+	if err != nil {
+		return nil, err
+	}
+
+	pendiente, err := s.cambioProgramadoRepo.ExistsPendiente(ctx, suscripcion.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pendiente {
+		return nil, ErrCambioProgramadoExistente
+	}
+
+	cambio := &entity.CambioProgramado{
+		SuscripcionID: suscripcion.ID,
+		UsuarioID:     suscripcion.UsuarioID,
+		PlanNuevoID:   cambioPlan.PlanNuevoID,
+		FechaEfectiva: fechaEfectiva,
+		Estado:        entity.EstadoCambioProgramadoPendiente,
+		CreadoPor:     actor.FromContext(ctx),
+		CreadoEn:      time.Now(),
+	}
+	if err := s.cambioProgramadoRepo.Create(ctx, cambio); err != nil {
+		return nil, err
+	}
+
+	despues := map[string]interface{}{
+		"cambio_programado_id": cambio.ID,
+		"plan_nuevo_id":        cambio.PlanNuevoID,
+		"fecha_efectiva":       cambio.FechaEfectiva,
+	}
+	if err := s.registrarEvento(ctx, suscripcion, entity.EventoSuscripcionCambioAgendado, nil, despues); err != nil {
+		return nil, errors.Join(err, s.cambioProgramadoRepo.Delete(ctx, cambio.ID))
+	}
+
+	return cambioProgramadoToDTO(cambio), nil
+}
+
+func (s *suscripcionService) GetCambiosProgramados(ctx context.Context, id, estado string, limit, offset int) ([]*dto.CambioProgramadoDTO, int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, 0, errors.New("ID de suscripción inválido")
+	}
+
+	filters := map[string]interface{}{"suscripcion_id": objectID}
+	if estado != "" {
+		filters["estado"] = estado
+	}
+
+	cambios, err := s.cambioProgramadoRepo.GetAll(ctx, filters, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.cambioProgramadoRepo.Count(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dtos := make([]*dto.CambioProgramadoDTO, 0, len(cambios))
+	for _, cambio := range cambios {
+		dtos = append(dtos, cambioProgramadoToDTO(cambio))
+	}
+
+	return dtos, total, nil
+}
+
+func (s *suscripcionService) CancelarCambioProgramado(ctx context.Context, id, cambioID string) error {
+	suscripcion, err := s.getSuscripcion(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(cambioID)
+	if err != nil {
+		return errors.New("ID de cambio programado inválido")
+	}
+
+	cambio, err := s.cambioProgramadoRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+	if cambio.SuscripcionID != suscripcion.ID {
+		return errors.New("cambio programado no encontrado")
+	}
+
+	expected := map[string]interface{}{"estado": entity.EstadoCambioProgramadoPendiente}
+	updates := map[string]interface{}{
+		"estado":        entity.EstadoCambioProgramadoCancelado,
+		"cancelado_por": actor.FromContext(ctx),
+		"cancelado_en":  time.Now(),
+	}
+	if err := s.cambioProgramadoRepo.UpdateIfMatch(ctx, cambio.ID, expected, updates); err != nil {
+		if errors.Is(err, repositories.ErrCambioProgramadoModificado) {
+			return ErrCambioProgramadoNoPendiente
+		}
+		return err
+	}
+
+	antes := map[string]interface{}{
+		"cambio_programado_id": cambio.ID,
+		"plan_nuevo_id":        cambio.PlanNuevoID,
+		"fecha_efectiva":       cambio.FechaEfectiva,
+	}
+	if err := s.registrarEvento(ctx, suscripcion, entity.EventoSuscripcionCambioDesagendado, antes, nil); err != nil {
+		// Sin el evento, el cambio vuelve a quedar pendiente
+		revertir := map[string]interface{}{
+			"estado":        entity.EstadoCambioProgramadoPendiente,
+			"cancelado_por": "",
+			"cancelado_en":  time.Time{},
+		}
+		return errors.Join(err, s.cambioProgramadoRepo.UpdateIfMatch(ctx, cambio.ID, map[string]interface{}{"estado": entity.EstadoCambioProgramadoCancelado}, revertir))
+	}
+
+	return nil
+}
+
+// AplicarCambiosProgramados aplica los cambios de plan cuya fecha ya llegó.
+// Los que ya no cumplen las reglas del cambio quedan como fallidos con el
+// motivo, para que no se reintenten en cada ejecución.
+func (s *suscripcionService) AplicarCambiosProgramados(ctx context.Context) (int64, error) {
+	now := time.Now()
+	batchSize := s.cfg.Scheduler.RenewalBatchSize
+
+	var (
+		total   int64
+		errs    []error
+		afterID primitive.ObjectID
+	)
+	for {
+		cambios, err := s.cambioProgramadoRepo.GetDue(ctx, now, afterID, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, cambio := range cambios {
+			afterID = cambio.ID
+
+			if err := s.aplicarCambioProgramado(ctx, cambio); err != nil {
+				if !errors.Is(err, repositories.ErrSuscripcionModificada) && !errors.Is(err, repositories.ErrCambioProgramadoModificado) {
+					errs = append(errs, fmt.Errorf("cambio programado %s: %w", cambio.ID.Hex(), err))
+				}
+				continue
+			}
+			total++
+		}
+
+		if len(cambios) < batchSize {
+			return total, errors.Join(errs...)
+		}
+	}
+}
+
+func (s *suscripcionService) aplicarCambioProgramado(ctx context.Context, cambio *entity.CambioProgramado) error {
+	expected := map[string]interface{}{"estado": entity.EstadoCambioProgramadoPendiente}
+
+	suscripcion, err := s.suscripcionRepo.GetByID(ctx, cambio.SuscripcionID)
+	if err != nil {
+		return err
+	}
+
+	// Si una ejecución anterior cambió el plan pero no llegó a marcar el
+	// cambio, solo falta marcarlo
+	if suscripcion.PlanID == cambio.PlanNuevoID {
+		return s.cambioProgramadoRepo.UpdateIfMatch(ctx, cambio.ID, expected, map[string]interface{}{
+			"estado":      entity.EstadoCambioProgramadoAplicado,
+			"aplicado_en": time.Now(),
+		})
+	}
+
+	_, cambioPlan, err := s.cotizarCambioPlan(ctx, suscripcion, &dto.CambiarPlanRequest{PlanID: cambio.PlanNuevoID.Hex()})
+	if err != nil {
+		return s.cambioProgramadoRepo.UpdateIfMatch(ctx, cambio.ID, expected, map[string]interface{}{
+			"estado": entity.EstadoCambioProgramadoFallido,
+			"error":  err.Error(),
+		})
+	}
+
+	detalle := map[string]interface{}{"cambio_programado_id": cambio.ID}
+	if err := s.aplicarCambioPlan(ctx, suscripcion, cambioPlan, detalle); err != nil {
+		return err
+	}
+
+	return s.cambioProgramadoRepo.UpdateIfMatch(ctx, cambio.ID, expected, map[string]interface{}{
+		"estado":         entity.EstadoCambioProgramadoAplicado,
+		"aplicado_en":    time.Now(),
+		"cambio_plan_id": cambioPlan.ID,
+	})
+}
+
+func cambioProgramadoToDTO(cambio *entity.CambioProgramado) *dto.CambioProgramadoDTO {
+	result := &dto.CambioProgramadoDTO{
+		ID:            cambio.ID.Hex(),
+		SuscripcionID: cambio.SuscripcionID.Hex(),
+		UsuarioID:     cambio.UsuarioID.Hex(),
+		PlanNuevoID:   cambio.PlanNuevoID.Hex(),
+		FechaEfectiva: cambio.FechaEfectiva,
+		Estado:        cambio.Estado,
+		CreadoPor:     cambio.CreadoPor,
+		CanceladoPor:  cambio.CanceladoPor,
+		Error:         cambio.Error,
+		CreadoEn:      cambio.CreadoEn,
+	}
+
+	if cambio.CambioPlanID != nil {
+		result.CambioPlanID = cambio.CambioPlanID.Hex()
+	}
+	if !cambio.AplicadoEn.IsZero() {
+		aplicadoEn := cambio.AplicadoEn
+		result.AplicadoEn = &aplicadoEn
+	}
+	if !cambio.CanceladoEn.IsZero() {
+		canceladoEn := cambio.CanceladoEn
+		result.CanceladoEn = &canceladoEn
+	}
+
+	return result
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrComplementoInactivo       = errors.New("complemento inactivo")
+	ErrComplementoIncompatible   = errors.New("el complemento no es compatible con el plan de la suscripción")
+	ErrComplementoSinPrecio      = errors.New("el complemento no tiene precio en la moneda de la suscripción")
+	ErrComplementoYaAgregado     = errors.New("la suscripción ya tiene ese complemento")
+	ErrComplementoNoAgregado     = errors.New("la suscripción no tiene ese complemento")
+	ErrComplementosIncompatibles = errors.New("la suscripción tiene complementos que no son compatibles con el plan nuevo")
+)
+
+// AgregarComplemento suma un complemento a la suscripción. Lo que queda del
+// periodo en curso se prorratea y se factura de inmediato; durante la prueba
+// gratuita no se cobra. Sus derechos aplican desde ya.
+func (s *suscripcionService) AgregarComplemento(ctx context.Context, id, userID string, req *dto.AgregarComplementoRequest) (*dto.AltaComplementoDTO, error) {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !suscripcion.IsActive() {
+		return nil, errors.New("la suscripción no está activa")
+	}
+
+	complementoID, err := primitive.ObjectIDFromHex(req.ComplementoID)
+	if err != nil {
+		return nil, errors.New("ID de complemento inválido")
+	}
+
+	if suscripcion.TieneComplemento(complementoID) {
+		return nil, ErrComplementoYaAgregado
+	}
+
+	complemento, err := s.complementoRepo.GetByID(ctx, complementoID)
+	if err != nil {
+		return nil, err
+	}
+	if !complemento.Activo {
+		return nil, ErrComplementoInactivo
+	}
+	if !complemento.CompatibleCon(suscripcion.PlanID) {
+		return nil, ErrComplementoIncompatible
+	}
+
+	moneda := suscripcion.GetMoneda()
+	precio, ok := complemento.PrecioEn(moneda)
+	if !ok {
+		return nil, ErrComplementoSinPrecio
+	}
+
+	now := time.Now()
+	contratado := entity.ComplementoSuscripcion{
+		ComplementoID: complemento.ID,
+		Nombre:        complemento.Nombre,
+		Precio:        precio,
+		Derechos:      complemento.Derechos,
+		AgregadoEn:    now,
+	}
+
+	result := &dto.AltaComplementoDTO{
+		SuscripcionID: suscripcion.ID.Hex(),
+		Complemento:   complementoSuscripcionToDTO(contratado),
+		Moneda:        moneda,
+	}
+
+	if !suscripcion.IsTrial() {
+		restante := suscripcion.FechaFin.Sub(now)
+		duracion := suscripcion.FechaFin.Sub(suscripcion.InicioPeriodoActual())
+		result.Cargo = prorratear(precio, fraccion(restante, duracion))
+		result.DiasRestantes = int(math.Ceil(restante.Hours() / 24))
+	}
+
+	expected := map[string]interface{}{
+		"estado":    suscripcion.Estado,
+		"plan_id":   suscripcion.PlanID,
+		"fecha_fin": suscripcion.FechaFin,
+	}
+	updates := map[string]interface{}{"complemento": contratado}
+
+	err = s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionComplementoAgregado, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.AgregarComplemento(ctx, suscripcion.ID, expected, contratado, evento)
+	}, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Cargo > 0 {
+		plan, err := planDeSuscripcion(ctx, s.planRepo, s.versionRepo, suscripcion)
+		if err != nil {
+			return nil, err
+		}
+
+		factura, err := s.facturarCargo(ctx, suscripcion, plan, entity.MotivoFacturaComplemento, entity.LineaFactura{
+			Tipo:           entity.LineaFacturaComplemento,
+			Descripcion:    fmt.Sprintf("Complemento %s (%s - %s)", complemento.Nombre, now.Format("2006-01-02"), suscripcion.FechaFin.Format("2006-01-02")),
+			Cantidad:       1,
+			PrecioUnitario: result.Cargo,
+			Monto:          result.Cargo,
+			ComplementoID:  &complemento.ID,
+		}, now)
+		if factura != nil {
+			result.FacturaID = factura.ID.Hex()
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// QuitarComplemento lo quita de inmediato, junto con sus derechos. Lo pagado
+// del periodo en curso no se devuelve.
+func (s *suscripcionService) QuitarComplemento(ctx context.Context, id, userID, complementoID string) error {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(complementoID)
+	if err != nil {
+		return errors.New("ID de complemento inválido")
+	}
+
+	if !suscripcion.TieneComplemento(objectID) {
+		return ErrComplementoNoAgregado
+	}
+
+	updates := map[string]interface{}{"complemento": objectID}
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionComplementoQuitado, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.QuitarComplemento(ctx, suscripcion.ID, objectID, evento)
+	}, updates)
+}
+
+// complementosRenovados devuelve los complementos con los que sigue la
+// suscripción en el periodo nuevo, con el precio y los derechos vigentes del
+// catálogo. Un complemento inactivo, que no es compatible con el plan de la
+// renovación o que no tiene precio en la moneda se deja de renovar.
+func (s *suscripcionService) complementosRenovados(ctx context.Context, suscripcion *entity.Suscripcion, plan *entity.PlanSuscripcion) ([]entity.ComplementoSuscripcion, error) {
+	moneda := suscripcion.GetMoneda()
+
+	var result []entity.ComplementoSuscripcion
+	for _, contratado := range suscripcion.Complementos {
+		complemento, err := s.complementoRepo.GetByID(ctx, contratado.ComplementoID)
+		if err != nil {
+			return nil, err
+		}
+		if !complemento.Activo || !complemento.CompatibleCon(plan.ID) {
+			continue
+		}
+
+		precio, ok := complemento.PrecioEn(moneda)
+		if !ok {
+			continue
+		}
+
+		contratado.Nombre = complemento.Nombre
+		contratado.Precio = precio
+		contratado.Derechos = complemento.Derechos
+		result = append(result, contratado)
+	}
+
+	return result, nil
+}
+
+// complementosCompatibles indica si todos los complementos de la suscripción
+// se pueden agregar al plan.
+func (s *suscripcionService) complementosCompatibles(ctx context.Context, suscripcion *entity.Suscripcion, planID primitive.ObjectID) (bool, error) {
+	for _, contratado := range suscripcion.Complementos {
+		complemento, err := s.complementoRepo.GetByID(ctx, contratado.ComplementoID)
+		if err != nil {
+			return false, err
+		}
+		if !complemento.CompatibleCon(planID) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func complementosToDTO(complementos []entity.ComplementoSuscripcion) []dto.ComplementoSuscripcionDTO {
+	if len(complementos) == 0 {
+		return nil
+	}
+
+	result := make([]dto.ComplementoSuscripcionDTO, 0, len(complementos))
+	for _, complemento := range complementos {
+		result = append(result, complementoSuscripcionToDTO(complemento))
+	}
+	return result
+}
+
+func complementoSuscripcionToDTO(complemento entity.ComplementoSuscripcion) dto.ComplementoSuscripcionDTO {
+	return dto.ComplementoSuscripcionDTO{
+		ComplementoID: complemento.ComplementoID.Hex(),
+		Nombre:        complemento.Nombre,
+		Precio:        complemento.Precio,
+		Derechos:      derechosToDTO(complemento.Derechos),
+		AgregadoEn:    complemento.AgregadoEn,
+	}
+}
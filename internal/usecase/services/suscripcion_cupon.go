@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/entity"
+)
+
+// canjearCupon suma el canje al cupón y registra el uso del usuario, y deja el
+// descuento copiado en la suscripción. Los dos límites se aplican con
+// incrementos condicionales; si el registro falla, el contador del cupón se
+// revierte para no consumir un canje que no se usó.
+func (s *suscripcionService) canjearCupon(ctx context.Context, cupon *entity.Cupon, suscripcion *entity.Suscripcion) (*entity.CanjeCupon, error) {
+	if err := s.cuponRepo.IncrementCanjes(ctx, cupon.ID); err != nil {
+		return nil, err
+	}
+
+	canje := &entity.CanjeCupon{
+		CuponID:       cupon.ID,
+		UsuarioID:     suscripcion.UsuarioID,
+		SuscripcionID: suscripcion.ID,
+		Descuento:     calcularDescuento(cupon.Tipo, cupon.Valor, suscripcion.Precio),
+		Moneda:        suscripcion.Moneda,
+	}
+
+	if err := s.canjeRepo.Create(ctx, canje, cupon.MaxCanjesPorUsuario); err != nil {
+		return nil, errors.Join(err, s.cuponRepo.DecrementCanjes(ctx, cupon.ID))
+	}
+
+	suscripcion.Descuento = &entity.DescuentoAplicado{
+		CuponID:           cupon.ID,
+		Codigo:            cupon.Codigo,
+		Tipo:              cupon.Tipo,
+		Valor:             cupon.Valor,
+		Moneda:            cupon.Moneda,
+		Duracion:          cupon.Duracion,
+		PeriodosRestantes: cupon.Periodos(),
+	}
+
+	return canje, nil
+}
+
+func (s *suscripcionService) revertirCanje(ctx context.Context, canje *entity.CanjeCupon) error {
+	return errors.Join(
+		s.canjeRepo.Delete(ctx, canje),
+		s.cuponRepo.DecrementCanjes(ctx, canje.CuponID),
+	)
+}
+
+// avanzarDescuento consume un periodo del descuento al renovar y devuelve nil
+// cuando ya no cubre el periodo que empieza. La prueba gratuita no cuenta.
+func avanzarDescuento(descuento *entity.DescuentoAplicado) *entity.DescuentoAplicado {
+	if descuento.Duracion == entity.DuracionCuponSiempre {
+		return descuento
+	}
+
+	siguiente := *descuento
+	siguiente.PeriodosRestantes--
+	if siguiente.PeriodosRestantes <= 0 {
+		return nil
+	}
+
+	return &siguiente
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func nuevoCupon(maxPorUsuario int) *entity.Cupon {
+	return &entity.Cupon{
+		ID:                  primitive.NewObjectID(),
+		Codigo:              "BIENVENIDA",
+		Tipo:                entity.TipoCuponPorcentaje,
+		Valor:               20,
+		Duracion:            entity.DuracionCuponUnaVez,
+		MaxCanjesPorUsuario: maxPorUsuario,
+		Activo:              true,
+	}
+}
+
+func TestCreateSuscripcion_CuponConLimitePorUsuario(t *testing.T) {
+	f := newAltaFixture(0)
+	cupon := nuevoCupon(1)
+	cupones := newFakeCuponRepo(cupon)
+	canjes := &fakeCanjeCuponRepo{}
+	f.service.cuponRepo = cupones
+	f.service.canjeRepo = canjes
+
+	// El usuario ya canjeó el cupón en una suscripción anterior
+	cupon.Canjes = 1
+	canjes.canjes = append(canjes.canjes, &entity.CanjeCupon{
+		ID:            primitive.NewObjectID().Hex(),
+		CuponID:       cupon.ID,
+		UsuarioID:     f.usuario.ID,
+		SuscripcionID: primitive.NewObjectID(),
+	})
+
+	_, err := f.service.CreateSuscripcion(context.Background(), &dto.CreateSuscripcionRequest{
+		UsuarioID: f.usuario.ID.Hex(),
+		PlanID:    f.plan.ID.Hex(),
+		Cupon:     "bienvenida",
+	})
+	if !errors.Is(err, ErrCuponLimiteUsuario) {
+		t.Fatalf("CreateSuscripcion() error = %v, want %v", err, ErrCuponLimiteUsuario)
+	}
+
+	if n := len(f.suscripciones.docs); n != 0 {
+		t.Errorf("suscripciones creadas = %d, want 0", n)
+	}
+	if cupon.Canjes != 1 {
+		t.Errorf("canjes del cupón = %d, want 1", cupon.Canjes)
+	}
+}
+
+func TestCanjearCupon_LimitePorUsuarioEntreCanjesSimultaneos(t *testing.T) {
+	cupon := nuevoCupon(1)
+	cupones := newFakeCuponRepo(cupon)
+	canjes := &fakeCanjeCuponRepo{}
+	service := &suscripcionService{cuponRepo: cupones, canjeRepo: canjes}
+
+	// Las dos solicitudes pasaron la comprobación antes de que alguna canjeara
+	usuarioID := primitive.NewObjectID()
+	primera := &entity.Suscripcion{ID: primitive.NewObjectID(), UsuarioID: usuarioID, Precio: 1000, Moneda: "USD"}
+	segunda := &entity.Suscripcion{ID: primitive.NewObjectID(), UsuarioID: usuarioID, Precio: 1000, Moneda: "USD"}
+
+	if _, err := service.canjearCupon(context.Background(), cupon, primera); err != nil {
+		t.Fatalf("primer canje: %v", err)
+	}
+	_, err := service.canjearCupon(context.Background(), cupon, segunda)
+	if !errors.Is(err, ErrCuponLimiteUsuario) {
+		t.Fatalf("segundo canje error = %v, want %v", err, ErrCuponLimiteUsuario)
+	}
+
+	if cupon.Canjes != 1 {
+		t.Errorf("canjes del cupón = %d, want 1", cupon.Canjes)
+	}
+	if n := len(canjes.canjes); n != 1 {
+		t.Errorf("canjes registrados = %d, want 1", n)
+	}
+	if segunda.Descuento != nil {
+		t.Errorf("descuento de la segunda = %+v, want nil", segunda.Descuento)
+	}
+}
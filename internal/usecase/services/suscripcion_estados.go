@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sw2p2go/internal/actor"
+	"sw2p2go/internal/entity"
+	"time"
+)
+
+// transicionesSuscripcion es la máquina de estados de una suscripción: para
+// cada estado, los estados a los que se puede pasar. cancelada es terminal.
+var transicionesSuscripcion = map[string][]string{
+	entity.EstadoSuscripcionPendientePago: {
+		entity.EstadoSuscripcionActiva,
+		entity.EstadoSuscripcionCancelada,
+	},
+	entity.EstadoSuscripcionEnPrueba: {
+		entity.EstadoSuscripcionActiva,
+		entity.EstadoSuscripcionVencida,
+		entity.EstadoSuscripcionCancelada,
+	},
+	entity.EstadoSuscripcionActiva: {
+		entity.EstadoSuscripcionPausada,
+		entity.EstadoSuscripcionVencida,
+		entity.EstadoSuscripcionCancelada,
+	},
+	entity.EstadoSuscripcionPausada: {
+		entity.EstadoSuscripcionActiva,
+		entity.EstadoSuscripcionCancelada,
+	},
+	entity.EstadoSuscripcionVencida: {
+		entity.EstadoSuscripcionActiva,
+	},
+	entity.EstadoSuscripcionCancelada: {},
+}
+
+var (
+	ErrEstadoInvalido             = errors.New("estado inválido")
+	ErrFormatoFecha               = errors.New("formato de fecha inválido (use YYYY-MM-DD)")
+	ErrRangoFechas                = errors.New("la fecha de fin debe ser posterior a la fecha de inicio")
+	ErrFechaFinPasada             = errors.New("la fecha de fin debe ser futura para activar la suscripción")
+	ErrFechaFinConPrueba          = errors.New("la suscripción empieza con prueba gratuita: su fecha de fin la fija la prueba")
+	ErrSuscripcionActivaExistente = errors.New("el usuario ya tiene una suscripción activa")
+)
+
+// TransicionInvalidaError se devuelve cuando la máquina de estados no permite
+// pasar de Desde a Hasta.
+type TransicionInvalidaError struct {
+	Desde string
+	Hasta string
+}
+
+func (e *TransicionInvalidaError) Error() string {
+	return fmt.Sprintf("no se puede pasar una suscripción de %s a %s", e.Desde, e.Hasta)
+}
+
+func validarTransicion(desde, hasta string) error {
+	if _, ok := transicionesSuscripcion[hasta]; !ok {
+		return ErrEstadoInvalido
+	}
+
+	for _, permitido := range transicionesSuscripcion[desde] {
+		if permitido == hasta {
+			return nil
+		}
+	}
+
+	return &TransicionInvalidaError{Desde: desde, Hasta: hasta}
+}
+
+// transicionar valida el cambio de estado, lo aplica junto con updates solo si
+// la suscripción sigue coincidiendo con expected y lo deja registrado con la
+// fecha y el actor que lo provocó.
+func (s *suscripcionService) transicionar(ctx context.Context, suscripcion *entity.Suscripcion, hasta string, expected, updates map[string]interface{}, evento *entity.EventoSuscripcion) error {
+	if err := validarTransicion(suscripcion.Estado, hasta); err != nil {
+		return err
+	}
+
+	if expected == nil {
+		expected = make(map[string]interface{})
+	}
+	expected["estado"] = suscripcion.Estado
+
+	if updates == nil {
+		updates = make(map[string]interface{})
+	}
+	updates["estado"] = hasta
+
+	// El evento de aplicarConEvento se armó antes de agregar el estado
+	if evento != nil {
+		evento.Antes = valoresAnteriores(suscripcion, updates)
+		evento.Despues = updates
+	}
+
+	transicion := entity.TransicionEstado{
+		Desde: suscripcion.Estado,
+		Hasta: hasta,
+		Fecha: time.Now(),
+		Actor: actor.FromContext(ctx),
+	}
+
+	return s.suscripcionRepo.ApplyTransition(ctx, suscripcion.ID, expected, updates, transicion, evento)
+}
+
+func parseFecha(value string) (time.Time, error) {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, ErrFormatoFecha
+	}
+	return parsed, nil
+}
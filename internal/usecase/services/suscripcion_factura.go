@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/payment"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxIntentosEmision acota cuántas asignaciones ajenas se terminan antes de
+// desistir de emitir una factura.
+const maxIntentosEmision = 5
+
+var ErrNumeracionOcupada = errors.New("la numeración de facturas está ocupada, intente de nuevo")
+
+// facturarPeriodo genera la factura del periodo [inicio, fin) con el plan, el
+// precio en la moneda de la suscripción, los complementos, el descuento y el
+// impuesto que le corresponden, la emite y pide su cobro a la pasarela.
+func (s *suscripcionService) facturarPeriodo(ctx context.Context, suscripcion *entity.Suscripcion, plan *entity.PlanSuscripcion, precio int64, complementos []entity.ComplementoSuscripcion, descuento *entity.DescuentoAplicado, impuesto *entity.DesgloseImpuesto, motivo string, inicio, fin time.Time) (*entity.Factura, *payment.Cobro, error) {
+	factura := construirFactura(suscripcion, plan, precio, complementos, descuento, impuesto, motivo, inicio, fin)
+	return s.emitirYCobrar(ctx, suscripcion.UsuarioID, factura)
+}
+
+// facturarCargo factura un cargo suelto a mitad de periodo, como los asientos
+// o un complemento agregados, con el impuesto que le corresponde.
+func (s *suscripcionService) facturarCargo(ctx context.Context, suscripcion *entity.Suscripcion, plan *entity.PlanSuscripcion, motivo string, linea entity.LineaFactura, inicio time.Time) (*entity.Factura, error) {
+	impuesto, err := s.calcularImpuestoPeriodo(ctx, suscripcion, plan, linea.Monto, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	factura := &entity.Factura{
+		SuscripcionID: suscripcion.ID,
+		UsuarioID:     suscripcion.UsuarioID,
+		Motivo:        motivo,
+		Estado:        entity.EstadoFacturaBorrador,
+		Moneda:        suscripcion.GetMoneda(),
+		Lineas:        []entity.LineaFactura{linea},
+		Subtotal:      linea.Monto,
+		Total:         linea.Monto,
+		PeriodoInicio: inicio,
+		PeriodoFin:    suscripcion.FechaFin,
+		CreadoEn:      time.Now(),
+	}
+	agregarImpuesto(factura, impuesto)
+
+	factura, _, err = s.emitirYCobrar(ctx, suscripcion.UsuarioID, factura)
+	return factura, err
+}
+
+func (s *suscripcionService) emitirYCobrar(ctx context.Context, usuarioID primitive.ObjectID, factura *entity.Factura) (*entity.Factura, *payment.Cobro, error) {
+	if err := s.aplicarCredito(ctx, usuarioID, factura); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.facturaRepo.Create(ctx, factura); err != nil {
+		return nil, nil, errors.Join(err, restituirCredito(ctx, s.creditoRepo, factura))
+	}
+
+	if err := emitirFactura(ctx, s.facturaRepo, s.contadorRepo, factura); err != nil {
+		return factura, nil, errors.Join(err, s.anularBorrador(ctx, factura))
+	}
+
+	cobro, err := s.cobrarFactura(ctx, usuarioID, factura)
+	return factura, cobro, err
+}
+
+// anularBorrador anula un borrador que no se pudo emitir y devuelve el crédito
+// que se le aplicó. Si otro proceso ya lo emitió queda como está. Su número
+// pendiente, si tenía, pasa a la próxima factura.
+func (s *suscripcionService) anularBorrador(ctx context.Context, factura *entity.Factura) error {
+	now := time.Now()
+	expected := map[string]interface{}{"estado": entity.EstadoFacturaBorrador}
+	updates := map[string]interface{}{
+		"estado":     entity.EstadoFacturaAnulada,
+		"anulada_en": now,
+	}
+	err := s.facturaRepo.UpdateIfMatch(ctx, factura.ID, expected, updates)
+	if errors.Is(err, repositories.ErrFacturaModificada) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	factura.Estado = entity.EstadoFacturaAnulada
+	factura.AnuladaEn = now
+	return restituirCredito(ctx, s.creditoRepo, factura)
+}
+
+// aplicarCredito descuenta del total de un borrador el crédito a favor que el
+// usuario tenga en la moneda de la factura, después del impuesto, como si fuera
+// un pago. El crédito se consume antes de guardar la factura; si otro proceso
+// lo consumió primero la factura sale sin crédito.
+func (s *suscripcionService) aplicarCredito(ctx context.Context, usuarioID primitive.ObjectID, factura *entity.Factura) error {
+	if factura.Total <= 0 {
+		return nil
+	}
+
+	ultimo, err := s.creditoRepo.GetUltimo(ctx, usuarioID, factura.Moneda)
+	if err != nil {
+		return err
+	}
+	if ultimo == nil || ultimo.Saldo <= 0 {
+		return nil
+	}
+
+	if factura.ID.IsZero() {
+		factura.ID = primitive.NewObjectID()
+	}
+
+	aplicado := min(ultimo.Saldo, factura.Total)
+	movimiento := &entity.MovimientoCredito{
+		UsuarioID: usuarioID,
+		Moneda:    factura.Moneda,
+		Monto:     -aplicado,
+		Motivo:    entity.MotivoCreditoFactura,
+		FacturaID: &factura.ID,
+	}
+	if !factura.SuscripcionID.IsZero() {
+		movimiento.SuscripcionID = &factura.SuscripcionID
+	}
+
+	err = registrarCredito(ctx, s.creditoRepo, movimiento)
+	if errors.Is(err, repositories.ErrSaldoInsuficiente) || errors.Is(err, repositories.ErrCreditoConcurrente) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	factura.Lineas = append(factura.Lineas, entity.LineaFactura{
+		Tipo:           entity.LineaFacturaCredito,
+		Descripcion:    "Crédito a favor",
+		Cantidad:       1,
+		PrecioUnitario: -aplicado,
+		Monto:          -aplicado,
+	})
+	factura.CreditoAplicado = aplicado
+	factura.Total -= aplicado
+
+	return nil
+}
+
+// calcularImpuestoPeriodo calcula el impuesto del cobro de un periodo según el
+// país de facturación actual del usuario. El cupón solo descuenta del plan; los
+// complementos se suman a la base completos.
+func (s *suscripcionService) calcularImpuestoPeriodo(ctx context.Context, suscripcion *entity.Suscripcion, plan *entity.PlanSuscripcion, precio int64, complementos []entity.ComplementoSuscripcion, descuento *entity.DescuentoAplicado) (*entity.DesgloseImpuesto, error) {
+	usuario, err := s.userRepo.GetByID(ctx, suscripcion.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	base := montoNeto(precio, descuento) + entity.PrecioComplementos(complementos)
+	return calcularImpuesto(ctx, s.tasaRepo, usuario, base, plan.ImpuestoIncluido)
+}
+
+// montoNeto es el precio del periodo menos el descuento del cupón, la base
+// sobre la que se calcula el impuesto.
+func montoNeto(precio int64, descuento *entity.DescuentoAplicado) int64 {
+	if descuento == nil {
+		return precio
+	}
+	return precio - calcularDescuento(descuento.Tipo, descuento.Valor, precio)
+}
+
+func construirFactura(suscripcion *entity.Suscripcion, plan *entity.PlanSuscripcion, precio int64, complementos []entity.ComplementoSuscripcion, descuento *entity.DescuentoAplicado, impuesto *entity.DesgloseImpuesto, motivo string, inicio, fin time.Time) *entity.Factura {
+	factura := &entity.Factura{
+		SuscripcionID: suscripcion.ID,
+		UsuarioID:     suscripcion.UsuarioID,
+		Motivo:        motivo,
+		Estado:        entity.EstadoFacturaBorrador,
+		Moneda:        suscripcion.GetMoneda(),
+		PeriodoInicio: inicio,
+		PeriodoFin:    fin,
+		CreadoEn:      time.Now(),
+	}
+
+	cantidad := suscripcion.GetCantidad()
+	factura.Lineas = append(factura.Lineas, entity.LineaFactura{
+		Tipo:           entity.LineaFacturaPlan,
+		Descripcion:    fmt.Sprintf("Plan %s (%s - %s)", plan.Nombre, inicio.Format("2006-01-02"), fin.Format("2006-01-02")),
+		Cantidad:       cantidad,
+		PrecioUnitario: precio / int64(cantidad),
+		Monto:          precio,
+	})
+	factura.Subtotal = precio
+
+	for _, complemento := range complementos {
+		factura.Lineas = append(factura.Lineas, entity.LineaFactura{
+			Tipo:           entity.LineaFacturaComplemento,
+			Descripcion:    fmt.Sprintf("Complemento %s", complemento.Nombre),
+			Cantidad:       1,
+			PrecioUnitario: complemento.Precio,
+			Monto:          complemento.Precio,
+			ComplementoID:  &complemento.ComplementoID,
+		})
+		factura.Subtotal += complemento.Precio
+	}
+
+	if descuento != nil {
+		monto := calcularDescuento(descuento.Tipo, descuento.Valor, precio)
+		factura.Lineas = append(factura.Lineas, entity.LineaFactura{
+			Tipo:           entity.LineaFacturaDescuento,
+			Descripcion:    fmt.Sprintf("Cupón %s", descuento.Codigo),
+			Cantidad:       1,
+			PrecioUnitario: -monto,
+			Monto:          -monto,
+		})
+		factura.Descuento = monto
+	}
+
+	factura.Total = factura.Subtotal - factura.Descuento
+	agregarImpuesto(factura, impuesto)
+
+	return factura
+}
+
+// agregarImpuesto suma la línea del impuesto. Con precios que incluyen el
+// impuesto la línea solo lo informa; el total ya lo contiene.
+func agregarImpuesto(factura *entity.Factura, impuesto *entity.DesgloseImpuesto) {
+	if impuesto == nil {
+		return
+	}
+
+	descripcion := fmt.Sprintf("%s %g%%", impuesto.Nombre, impuesto.Porcentaje)
+	if impuesto.Incluido {
+		descripcion += " (incluido)"
+	}
+	factura.Lineas = append(factura.Lineas, entity.LineaFactura{
+		Tipo:           entity.LineaFacturaImpuesto,
+		Descripcion:    descripcion,
+		Cantidad:       1,
+		PrecioUnitario: impuesto.Monto,
+		Monto:          impuesto.Monto,
+	})
+	factura.Impuesto = impuesto.Monto
+	factura.Desglose = impuesto
+	if !impuesto.Incluido {
+		factura.Total += impuesto.Monto
+	}
+}
+
+// emitirFactura le asigna a un borrador el siguiente número de la secuencia y
+// lo deja abierto. El número queda pendiente para la factura en la misma
+// actualización que lo entrega, y no se entrega otro hasta que la factura lo
+// guarde. Si el proceso se cae o la factura no se puede actualizar, la
+// siguiente emisión termina esa asignación o, si el borrador ya no puede tomar
+// el número, se lo queda; así la numeración no tiene huecos.
+func emitirFactura(ctx context.Context, facturaRepo repositories.FacturaRepository, contadorRepo repositories.ContadorRepository, factura *entity.Factura) error {
+	if factura.Estado != entity.EstadoFacturaBorrador {
+		return errors.New("solo se pueden emitir facturas en borrador")
+	}
+
+	for intento := 0; intento < maxIntentosEmision; intento++ {
+		contador, err := contadorRepo.Get(ctx, entity.ContadorFacturas)
+		if err != nil {
+			return err
+		}
+
+		pendiente := contador.Pendiente
+		if pendiente == nil {
+			numero, err := contadorRepo.Asignar(ctx, entity.ContadorFacturas, factura.ID)
+			if errors.Is(err, repositories.ErrContadorOcupado) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			return numerarFactura(ctx, facturaRepo, contadorRepo, factura, numero)
+		}
+
+		// Un intento anterior de esta misma factura quedó a mitad
+		if pendiente.DocumentoID == factura.ID {
+			return numerarFactura(ctx, facturaRepo, contadorRepo, factura, pendiente.Valor)
+		}
+
+		otra, err := facturaRepo.GetByID(ctx, pendiente.DocumentoID)
+		if err != nil {
+			return err
+		}
+		if otra.Estado == entity.EstadoFacturaBorrador || otra.Numero == pendiente.Valor {
+			err := numerarFactura(ctx, facturaRepo, contadorRepo, otra, pendiente.Valor)
+			if err != nil && !errors.Is(err, repositories.ErrFacturaModificada) {
+				return err
+			}
+			continue
+		}
+
+		// El borrador que tenía el número se anuló sin emitirse: el número pasa a esta
+		reasignado, err := contadorRepo.Reasignar(ctx, entity.ContadorFacturas, *pendiente, factura.ID)
+		if err != nil {
+			return err
+		}
+		if reasignado {
+			return numerarFactura(ctx, facturaRepo, contadorRepo, factura, pendiente.Valor)
+		}
+	}
+
+	return ErrNumeracionOcupada
+}
+
+// numerarFactura guarda en el borrador el número que tiene asignado y
+// confirma la asignación. Si otro proceso ya la numeró con ese número se toma
+// como emitida.
+func numerarFactura(ctx context.Context, facturaRepo repositories.FacturaRepository, contadorRepo repositories.ContadorRepository, factura *entity.Factura, numero int64) error {
+	now := time.Now()
+	expected := map[string]interface{}{
+		"estado": entity.EstadoFacturaBorrador,
+	}
+	updates := map[string]interface{}{
+		"estado":     entity.EstadoFacturaAbierta,
+		"numero":     numero,
+		"emitida_en": now,
+	}
+
+	err := facturaRepo.UpdateIfMatch(ctx, factura.ID, expected, updates)
+	switch {
+	case errors.Is(err, repositories.ErrFacturaModificada):
+		actual, getErr := facturaRepo.GetByID(ctx, factura.ID)
+		if getErr != nil {
+			return getErr
+		}
+		if actual.Numero != numero {
+			return err
+		}
+		*factura = *actual
+	case err != nil:
+		return err
+	default:
+		factura.Estado = entity.EstadoFacturaAbierta
+		factura.Numero = numero
+		factura.EmitidaEn = now
+	}
+
+	return contadorRepo.Confirmar(ctx, entity.ContadorFacturas, factura.ID)
+}
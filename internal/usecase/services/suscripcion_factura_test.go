@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"sw2p2go/internal/entity"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func nuevoBorrador() *entity.Factura {
+	return &entity.Factura{
+		ID:     primitive.NewObjectID(),
+		Estado: entity.EstadoFacturaBorrador,
+		Moneda: "USD",
+		Total:  1000,
+	}
+}
+
+func TestEmitirFactura_Numeracion(t *testing.T) {
+	type want struct {
+		numero      int64
+		otraEstado  string
+		otraNumero  int64
+		valorActual int64
+	}
+
+	tests := []struct {
+		name string
+		// preparar devuelve la otra factura que tiene la asignación pendiente,
+		// o nil, y la secuencia de la que parte la emisión
+		preparar func(factura *entity.Factura) (*entity.Factura, *entity.Contador)
+		want     want
+	}{
+		{
+			name: "sin asignación pendiente toma el siguiente número",
+			preparar: func(factura *entity.Factura) (*entity.Factura, *entity.Contador) {
+				return nil, &entity.Contador{Nombre: entity.ContadorFacturas, Valor: 7}
+			},
+			want: want{numero: 8, valorActual: 8},
+		},
+		{
+			name: "retoma su propia asignación a medias",
+			preparar: func(factura *entity.Factura) (*entity.Factura, *entity.Contador) {
+				return nil, &entity.Contador{
+					Nombre:    entity.ContadorFacturas,
+					Valor:     7,
+					Pendiente: &entity.AsignacionContador{Valor: 7, DocumentoID: factura.ID},
+				}
+			},
+			want: want{numero: 7, valorActual: 7},
+		},
+		{
+			name: "termina la asignación trabada de otro borrador y sigue con el siguiente",
+			preparar: func(factura *entity.Factura) (*entity.Factura, *entity.Contador) {
+				otra := nuevoBorrador()
+				return otra, &entity.Contador{
+					Nombre:    entity.ContadorFacturas,
+					Valor:     7,
+					Pendiente: &entity.AsignacionContador{Valor: 7, DocumentoID: otra.ID},
+				}
+			},
+			want: want{numero: 8, otraEstado: entity.EstadoFacturaAbierta, otraNumero: 7, valorActual: 8},
+		},
+		{
+			name: "el número de un borrador anulado pasa a la factura",
+			preparar: func(factura *entity.Factura) (*entity.Factura, *entity.Contador) {
+				otra := nuevoBorrador()
+				otra.Estado = entity.EstadoFacturaAnulada
+				return otra, &entity.Contador{
+					Nombre:    entity.ContadorFacturas,
+					Valor:     7,
+					Pendiente: &entity.AsignacionContador{Valor: 7, DocumentoID: otra.ID},
+				}
+			},
+			want: want{numero: 7, otraEstado: entity.EstadoFacturaAnulada, valorActual: 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factura := nuevoBorrador()
+			otra, contador := tt.preparar(factura)
+
+			facturas := newFakeFacturaRepo(factura)
+			if otra != nil {
+				facturas.Create(context.Background(), otra)
+			}
+			contadores := newFakeContadorRepo(contador)
+
+			if err := emitirFactura(context.Background(), facturas, contadores, factura); err != nil {
+				t.Fatalf("emitirFactura: %v", err)
+			}
+
+			guardada, err := facturas.GetByID(context.Background(), factura.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if guardada.Estado != entity.EstadoFacturaAbierta || guardada.Numero != tt.want.numero {
+				t.Errorf("factura = %s #%d, want %s #%d", guardada.Estado, guardada.Numero, entity.EstadoFacturaAbierta, tt.want.numero)
+			}
+
+			if otra != nil {
+				otra, err = facturas.GetByID(context.Background(), otra.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if otra.Estado != tt.want.otraEstado || otra.Numero != tt.want.otraNumero {
+					t.Errorf("otra factura = %s #%d, want %s #%d", otra.Estado, otra.Numero, tt.want.otraEstado, tt.want.otraNumero)
+				}
+			}
+
+			actual, _ := contadores.Get(context.Background(), entity.ContadorFacturas)
+			if actual.Valor != tt.want.valorActual || actual.Pendiente != nil {
+				t.Errorf("contador = %d, pendiente %+v, want %d sin pendiente", actual.Valor, actual.Pendiente, tt.want.valorActual)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sw2p2go/internal/actor"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetHistorial devuelve los eventos de la suscripción, del más reciente al más
+// antiguo. Solo el dueño o un administrador pueden verlos.
+func (s *suscripcionService) GetHistorial(ctx context.Context, id, userID string, esAdmin bool, limit, offset int) ([]*dto.EventoSuscripcionDTO, int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, 0, errors.New("ID de suscripción inválido")
+	}
+
+	if !esAdmin {
+		if _, err := s.getOwnedSuscripcion(ctx, id, userID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	eventos, err := s.eventoRepo.GetBySuscripcionID(ctx, objectID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.eventoRepo.CountBySuscripcionID(ctx, objectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var dtos []*dto.EventoSuscripcionDTO
+	for _, evento := range eventos {
+		dtos = append(dtos, eventoSuscripcionToDTO(evento))
+	}
+
+	return dtos, total, nil
+}
+
+// registrarEvento agrega al historial un cambio que no modifica la
+// suscripción, como agendar un cambio de plan. El evento se guarda en la
+// suscripción igual que con aplicarConEvento; si falla, quien lo llama deshace
+// su cambio. antes lleva los valores que tenían los campos modificados y
+// despues los que quedaron.
+func (s *suscripcionService) registrarEvento(ctx context.Context, suscripcion *entity.Suscripcion, tipo string, antes, despues map[string]interface{}) error {
+	return s.aplicarEvento(ctx, suscripcion, nuevoEvento(ctx, suscripcion, tipo, antes, despues), func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, nil, nil, evento)
+	})
+}
+
+// aplicarConEvento arma el evento con los valores previos de la suscripción y
+// los updates aplicados, y se lo pasa a apply para que la actualización lo
+// guarde en la suscripción en la misma escritura que el cambio. transicionar
+// lo actualiza porque agrega el estado a updates.
+func (s *suscripcionService) aplicarConEvento(ctx context.Context, suscripcion *entity.Suscripcion, tipo string, apply func(evento *entity.EventoSuscripcion) error, updates map[string]interface{}) error {
+	evento := nuevoEvento(ctx, suscripcion, tipo, valoresAnteriores(suscripcion, updates), updates)
+	return s.aplicarEvento(ctx, suscripcion, evento, apply)
+}
+
+// aplicarEvento es aplicarConEvento con un evento ya armado, para los cambios
+// cuyo historial no sale de los updates. Si apply tuvo éxito copia el evento
+// al historial.
+func (s *suscripcionService) aplicarEvento(ctx context.Context, suscripcion *entity.Suscripcion, evento *entity.EventoSuscripcion, apply func(evento *entity.EventoSuscripcion) error) error {
+	evento.ID = primitive.NewObjectID()
+	if err := apply(evento); err != nil {
+		return err
+	}
+
+	s.publicarEvento(ctx, suscripcion, evento)
+	return nil
+}
+
+// crearConEvento inserta la suscripción con su evento de alta pendiente, así
+// que no puede quedar creada sin él.
+func (s *suscripcionService) crearConEvento(ctx context.Context, suscripcion *entity.Suscripcion, despues map[string]interface{}) error {
+	if suscripcion.ID.IsZero() {
+		suscripcion.ID = primitive.NewObjectID()
+	}
+
+	evento := nuevoEvento(ctx, suscripcion, entity.EventoSuscripcionCreada, nil, despues)
+	evento.ID = primitive.NewObjectID()
+	suscripcion.EventosPendientes = map[string]entity.EventoSuscripcion{evento.ID.Hex(): *evento}
+
+	if err := s.suscripcionRepo.Create(ctx, suscripcion); err != nil {
+		return err
+	}
+
+	s.publicarEvento(ctx, suscripcion, evento)
+	return nil
+}
+
+// publicarEvento copia al historial un evento que ya quedó guardado con su
+// cambio. Si falla, lo publica después PublicarEventosPendientes.
+func (s *suscripcionService) publicarEvento(ctx context.Context, suscripcion *entity.Suscripcion, evento *entity.EventoSuscripcion) {
+	s.invalidarDerechos(suscripcion)
+	if err := s.eventoRepo.Create(ctx, evento); err == nil {
+		s.suscripcionRepo.QuitarEventoPendiente(ctx, suscripcion.ID, evento.ID)
+	}
+}
+
+// PublicarEventosPendientes copia al historial los eventos que quedaron
+// guardados en las suscripciones porque el proceso que hizo el cambio se cayó
+// antes de copiarlos o no pudo hacerlo.
+func (s *suscripcionService) PublicarEventosPendientes(ctx context.Context) (int64, error) {
+	batchSize := s.cfg.Scheduler.RenewalBatchSize
+
+	var (
+		total   int64
+		errs    []error
+		afterID primitive.ObjectID
+	)
+	for {
+		suscripciones, err := s.suscripcionRepo.GetConEventosPendientes(ctx, afterID, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, suscripcion := range suscripciones {
+			afterID = suscripcion.ID
+			s.invalidarDerechos(suscripcion)
+
+			for _, evento := range suscripcion.EventosPendientes {
+				err := s.eventoRepo.Create(ctx, &evento)
+				if err == nil {
+					err = s.suscripcionRepo.QuitarEventoPendiente(ctx, suscripcion.ID, evento.ID)
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("suscripción %s: %w", suscripcion.ID.Hex(), err))
+					continue
+				}
+				total++
+			}
+		}
+
+		if len(suscripciones) < batchSize {
+			return total, errors.Join(errs...)
+		}
+	}
+}
+
+func nuevoEvento(ctx context.Context, suscripcion *entity.Suscripcion, tipo string, antes, despues map[string]interface{}) *entity.EventoSuscripcion {
+	return &entity.EventoSuscripcion{
+		SuscripcionID: suscripcion.ID,
+		UsuarioID:     suscripcion.UsuarioID,
+		Tipo:          tipo,
+		Actor:         actor.FromContext(ctx),
+		Antes:         antes,
+		Despues:       despues,
+		CreadoEn:      time.Now(),
+	}
+}
+
+// invalidarDerechos borra de la caché los derechos del titular y de quienes
+// ocupan sus asientos. Todo cambio de la suscripción pasa por el historial, así
+// que se llama desde ahí.
+func (s *suscripcionService) invalidarDerechos(suscripcion *entity.Suscripcion) {
+	s.entitlements.Delete(suscripcion.UsuarioID.Hex())
+	for _, asiento := range suscripcion.Asientos {
+		s.entitlements.Delete(asiento.UsuarioID.Hex())
+	}
+}
+
+// valoresAnteriores toma de la suscripción los valores actuales de los campos
+// que updates va a modificar, para guardarlos como "antes" en el historial.
+func valoresAnteriores(suscripcion *entity.Suscripcion, updates map[string]interface{}) map[string]interface{} {
+	actuales := map[string]interface{}{
+		"estado":                 suscripcion.Estado,
+		"plan_id":                suscripcion.PlanID,
+		"plan_version":           suscripcion.PlanVersion,
+		"plan_pendiente_id":      suscripcion.PlanPendienteID,
+		"fecha_fin":              suscripcion.FechaFin,
+		"inicio_periodo":         suscripcion.InicioPeriodo,
+		"auto_renovar":           suscripcion.AutoRenovar,
+		"pausada_desde":          suscripcion.PausadaDesde,
+		"pausada_hasta":          suscripcion.PausadaHasta,
+		"cancelar_al_final":      suscripcion.CancelarAlFinal,
+		"cancelada_en":           suscripcion.CanceladaEn,
+		"motivo_cancelacion":     suscripcion.MotivoCancelacion,
+		"comentario_cancelacion": suscripcion.ComentarioCancelacion,
+		"descuento":              suscripcion.Descuento,
+		"precio":                 suscripcion.Precio,
+		"cantidad":               suscripcion.Cantidad,
+		"complementos":           suscripcion.Complementos,
+	}
+
+	antes := make(map[string]interface{}, len(updates))
+	for campo := range updates {
+		if valor, ok := actuales[campo]; ok {
+			antes[campo] = valor
+		}
+	}
+
+	return antes
+}
+
+func eventoSuscripcionToDTO(evento *entity.EventoSuscripcion) *dto.EventoSuscripcionDTO {
+	return &dto.EventoSuscripcionDTO{
+		ID:            evento.ID.Hex(),
+		SuscripcionID: evento.SuscripcionID.Hex(),
+		Tipo:          evento.Tipo,
+		Actor:         evento.Actor,
+		Antes:         evento.Antes,
+		Despues:       evento.Despues,
+		CreadoEn:      evento.CreadoEn,
+	}
+}
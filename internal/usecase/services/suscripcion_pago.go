@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/payment"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrFirmaInvalida      = errors.New("firma de webhook inválida")
+	ErrEventoPagoInvalido = errors.New("evento de pago inválido")
+)
+
+// ProcesarWebhookPago verifica la firma del webhook y lo procesa una sola vez
+// por ID de evento. Si el procesamiento falla la reserva se libera, y si el
+// proceso se cae la reserva vence, para que el reintento de la pasarela lo
+// vuelva a intentar.
+func (s *suscripcionService) ProcesarWebhookPago(ctx context.Context, payload []byte, firma string) error {
+	if !payment.VerificarFirma(s.cfg.Pagos.WebhookSecret, payload, firma) {
+		return ErrFirmaInvalida
+	}
+
+	var evento payment.Evento
+	if err := json.Unmarshal(payload, &evento); err != nil || evento.ID == "" || evento.CobroID == "" {
+		return ErrEventoPagoInvalido
+	}
+
+	reservado, err := s.eventoPagoRepo.Reservar(ctx, &entity.EventoPago{
+		ID:      evento.ID,
+		Tipo:    evento.Tipo,
+		CobroID: evento.CobroID,
+	}, s.cfg.Pagos.WebhookReserva)
+	if err != nil {
+		return err
+	}
+	if !reservado {
+		return nil
+	}
+
+	if err := s.procesarEventoPago(ctx, evento); err != nil {
+		return errors.Join(err, s.eventoPagoRepo.Liberar(ctx, evento.ID))
+	}
+
+	return s.eventoPagoRepo.MarcarProcesado(ctx, evento.ID)
+}
+
+// procesarEventoPago toma el estado del cobro de la pasarela y no del cuerpo
+// del webhook, así un evento viejo reenviado no puede revertir un pago.
+func (s *suscripcionService) procesarEventoPago(ctx context.Context, evento payment.Evento) error {
+	cobro, err := s.gateway.ObtenerCobro(ctx, evento.CobroID)
+	if err != nil {
+		return err
+	}
+
+	factura, err := s.facturaRepo.GetByCobroID(ctx, cobro.ID)
+	if err != nil {
+		return err
+	}
+
+	if factura.RegaloID != nil {
+		switch cobro.Estado {
+		case payment.EstadoCobroPagado:
+			return s.confirmarPagoRegalo(ctx, *factura.RegaloID, factura)
+		case payment.EstadoCobroFallido:
+			return s.rechazarPagoRegalo(ctx, *factura.RegaloID, factura)
+		}
+		return nil
+	}
+
+	suscripcion, err := s.suscripcionRepo.GetByID(ctx, factura.SuscripcionID)
+	if err != nil {
+		return err
+	}
+
+	switch cobro.Estado {
+	case payment.EstadoCobroPagado:
+		return s.confirmarPago(ctx, suscripcion, factura)
+	case payment.EstadoCobroFallido:
+		return s.rechazarPago(ctx, suscripcion, factura)
+	}
+
+	return nil
+}
+
+// cobrarFactura pide el cobro de la factura a la pasarela. Las facturas en
+// cero se dan por pagadas sin pasar por ella.
+func (s *suscripcionService) cobrarFactura(ctx context.Context, usuarioID primitive.ObjectID, factura *entity.Factura) (*payment.Cobro, error) {
+	if factura.Total <= 0 {
+		return nil, s.marcarFacturaPagada(ctx, factura)
+	}
+
+	cobro, err := s.gateway.CrearCobro(ctx, payment.CobroRequest{
+		Referencia:  factura.ID.Hex(),
+		UsuarioID:   usuarioID.Hex(),
+		Monto:       factura.Total,
+		Moneda:      factura.Moneda,
+		Descripcion: factura.Codigo(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expected := map[string]interface{}{"estado": entity.EstadoFacturaAbierta}
+	updates := map[string]interface{}{"cobro_id": cobro.ID}
+	if err := s.facturaRepo.UpdateIfMatch(ctx, factura.ID, expected, updates); err != nil {
+		return nil, err
+	}
+	factura.CobroID = cobro.ID
+
+	if cobro.Estado == payment.EstadoCobroPagado {
+		if err := s.marcarFacturaPagada(ctx, factura); err != nil {
+			return nil, err
+		}
+	}
+
+	return cobro, nil
+}
+
+// confirmarPago marca la factura como pagada y activa la suscripción si era
+// el pago del alta. Repetirlo no tiene efecto.
+func (s *suscripcionService) confirmarPago(ctx context.Context, suscripcion *entity.Suscripcion, factura *entity.Factura) error {
+	if factura.Estado == entity.EstadoFacturaAbierta {
+		if err := s.marcarFacturaPagada(ctx, factura); err != nil {
+			return err
+		}
+	}
+	if factura.Estado != entity.EstadoFacturaPagada {
+		return nil
+	}
+
+	if suscripcion.Estado != entity.EstadoSuscripcionPendientePago {
+		return nil
+	}
+
+	updates := map[string]interface{}{}
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionPagoConfirmado, func(evento *entity.EventoSuscripcion) error {
+		return s.transicionar(ctx, suscripcion, entity.EstadoSuscripcionActiva, nil, updates, evento)
+	}, updates)
+}
+
+// rechazarPago anula la factura del cobro rechazado y deshace lo que pagaba.
+// Si era el alta, la suscripción se cancela. Si era una renovación, se quita
+// el periodo nuevo y la suscripción vence al terminar el último que pagó. Si
+// era un cargo a mitad de periodo se quitan los asientos agregados, el
+// complemento o el cambio de plan; si la suscripción ya cambió y no hay nada
+// que deshacer, deja de renovarse sola para no seguir acumulando cargos
+// impagos y el usuario puede volver a activar la renovación.
+func (s *suscripcionService) rechazarPago(ctx context.Context, suscripcion *entity.Suscripcion, factura *entity.Factura) error {
+	if suscripcion.Estado != entity.EstadoSuscripcionPendientePago {
+		return s.rechazarCargo(ctx, suscripcion, factura)
+	}
+
+	return s.cancelarAltaImpaga(ctx, suscripcion, factura, entity.MotivoCancelacionPagoRechazado)
+}
+
+// cancelarAltaImpaga cancela una suscripción cuyo primer pago no llegó y
+// anula la factura del alta.
+func (s *suscripcionService) cancelarAltaImpaga(ctx context.Context, suscripcion *entity.Suscripcion, factura *entity.Factura, motivo string) error {
+	now := time.Now()
+
+	if err := s.anularFacturaAbierta(ctx, factura, now); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"cancelada_en":       now,
+		"motivo_cancelacion": motivo,
+		"auto_renovar":       false,
+	}
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionPagoRechazado, func(evento *entity.EventoSuscripcion) error {
+		return s.transicionar(ctx, suscripcion, entity.EstadoSuscripcionCancelada, nil, updates, evento)
+	}, updates)
+}
+
+func (s *suscripcionService) rechazarCargo(ctx context.Context, suscripcion *entity.Suscripcion, factura *entity.Factura) error {
+	// Un rechazo repetido o tardío no vuelve a tocar la suscripción
+	if factura.Estado != entity.EstadoFacturaAbierta {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.anularFacturaAbierta(ctx, factura, now); err != nil {
+		return err
+	}
+
+	if factura.Motivo == entity.MotivoFacturaRenovacion && suscripcion.FechaFin.Equal(factura.PeriodoFin) {
+		return s.revertirRenovacion(ctx, suscripcion, factura, now)
+	}
+
+	deshecho, err := s.deshacerCargo(ctx, suscripcion, factura)
+	if err != nil || deshecho {
+		return err
+	}
+
+	if !suscripcion.AutoRenovar {
+		return nil
+	}
+
+	expected := map[string]interface{}{"auto_renovar": true}
+	updates := map[string]interface{}{"auto_renovar": false}
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionPagoRechazado, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	}, updates)
+}
+
+// deshacerCargo quita lo que pagaba un cargo a mitad de periodo: los asientos
+// agregados, el complemento o el cambio a un plan más caro. Devuelve false si
+// la suscripción ya no está como la dejó el cargo y no hay nada que deshacer.
+func (s *suscripcionService) deshacerCargo(ctx context.Context, suscripcion *entity.Suscripcion, factura *entity.Factura) (bool, error) {
+	switch factura.Motivo {
+	case entity.MotivoFacturaCambioPlan:
+		cambio, err := s.cambioPlanRepo.GetByFacturaID(ctx, factura.ID)
+		if err != nil || cambio == nil {
+			return false, err
+		}
+		if suscripcion.PlanID != cambio.PlanNuevoID || suscripcion.PlanVersion != cambio.PlanVersionNueva {
+			return false, nil
+		}
+		return ignorarConflicto(s.deshacerCambioPlan(ctx, suscripcion, cambio))
+
+	case entity.MotivoFacturaAsientos:
+		cambio, err := s.cambioAsientosRepo.GetByFacturaID(ctx, factura.ID)
+		if err != nil || cambio == nil {
+			return false, err
+		}
+		if suscripcion.GetCantidad() != cambio.CantidadNueva {
+			return false, nil
+		}
+		return ignorarConflicto(s.deshacerCambioAsientos(ctx, suscripcion, cambio))
+
+	case entity.MotivoFacturaComplemento:
+		for _, linea := range factura.Lineas {
+			if linea.ComplementoID == nil || !suscripcion.TieneComplemento(*linea.ComplementoID) {
+				continue
+			}
+			complementoID := *linea.ComplementoID
+			updates := map[string]interface{}{"complemento": complementoID}
+			return ignorarConflicto(s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionComplementoQuitado, func(evento *entity.EventoSuscripcion) error {
+				return s.suscripcionRepo.QuitarComplemento(ctx, suscripcion.ID, complementoID, evento)
+			}, updates))
+		}
+	}
+	return false, nil
+}
+
+// ignorarConflicto toma como nada deshecho que la suscripción haya cambiado
+// entre la lectura y la escritura.
+func ignorarConflicto(err error) (bool, error) {
+	if errors.Is(err, repositories.ErrSuscripcionModificada) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// revertirRenovacion devuelve la suscripción al último periodo pagado. Si ya
+// terminó vence ahora; si la renovación se cobró por adelantado sigue activa
+// hasta entonces, sin renovarse sola. El plan y el precio que se aplicaron en
+// la renovación se mantienen.
+func (s *suscripcionService) revertirRenovacion(ctx context.Context, suscripcion *entity.Suscripcion, factura *entity.Factura, now time.Time) error {
+	expected := map[string]interface{}{"fecha_fin": factura.PeriodoFin}
+	updates := map[string]interface{}{
+		"fecha_fin":    factura.PeriodoInicio,
+		"auto_renovar": false,
+	}
+
+	filters := map[string]interface{}{
+		"suscripcion_id": suscripcion.ID,
+		"estado":         entity.EstadoFacturaPagada,
+		"periodo_fin":    factura.PeriodoInicio,
+	}
+	anteriores, err := s.facturaRepo.GetAll(ctx, filters, 1, 0)
+	if err != nil {
+		return err
+	}
+	if len(anteriores) > 0 {
+		updates["inicio_periodo"] = anteriores[0].PeriodoInicio
+	}
+
+	if suscripcion.Estado != entity.EstadoSuscripcionActiva || factura.PeriodoInicio.After(now) {
+		return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionPagoRechazado, func(evento *entity.EventoSuscripcion) error {
+			return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+		}, updates)
+	}
+
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionPagoRechazado, func(evento *entity.EventoSuscripcion) error {
+		return s.transicionar(ctx, suscripcion, entity.EstadoSuscripcionVencida, expected, updates, evento)
+	}, updates)
+}
+
+// anularFacturaAbierta anula la factura de un pago rechazado si sigue abierta.
+func (s *suscripcionService) anularFacturaAbierta(ctx context.Context, factura *entity.Factura, now time.Time) error {
+	if factura == nil || factura.Estado != entity.EstadoFacturaAbierta {
+		return nil
+	}
+
+	expected := map[string]interface{}{"estado": entity.EstadoFacturaAbierta}
+	updates := map[string]interface{}{
+		"estado":     entity.EstadoFacturaAnulada,
+		"anulada_en": now,
+	}
+	err := s.facturaRepo.UpdateIfMatch(ctx, factura.ID, expected, updates)
+	if errors.Is(err, repositories.ErrFacturaModificada) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return restituirCredito(ctx, s.creditoRepo, factura)
+}
+
+// marcarFacturaPagada pasa la factura de abierta a pagada. Si otro proceso ya
+// la cambió se toma su estado actual.
+func (s *suscripcionService) marcarFacturaPagada(ctx context.Context, factura *entity.Factura) error {
+	now := time.Now()
+	expected := map[string]interface{}{"estado": entity.EstadoFacturaAbierta}
+	updates := map[string]interface{}{
+		"estado":    entity.EstadoFacturaPagada,
+		"pagada_en": now,
+	}
+
+	err := s.facturaRepo.UpdateIfMatch(ctx, factura.ID, expected, updates)
+	if errors.Is(err, repositories.ErrFacturaModificada) {
+		actual, err := s.facturaRepo.GetByID(ctx, factura.ID)
+		if err != nil {
+			return err
+		}
+		*factura = *actual
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	factura.Estado = entity.EstadoFacturaPagada
+	factura.PagadaEn = now
+	return nil
+}
+
+// CancelarPagosPendientes cancela las altas que siguen esperando su primer
+// pago después de PagoPendienteTTL, para que el usuario pueda volver a
+// suscribirse. Antes consulta el cobro en la pasarela por si el webhook se
+// perdió: un cobro pagado activa la suscripción en vez de cancelarla.
+func (s *suscripcionService) CancelarPagosPendientes(ctx context.Context) (int64, error) {
+	creadoAntes := time.Now().Add(-s.cfg.Suscripciones.PagoPendienteTTL)
+	batchSize := s.cfg.Scheduler.RenewalBatchSize
+
+	var (
+		total   int64
+		errs    []error
+		afterID primitive.ObjectID
+	)
+	for {
+		suscripciones, err := s.suscripcionRepo.GetPendientesPago(ctx, creadoAntes, afterID, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, suscripcion := range suscripciones {
+			afterID = suscripcion.ID
+
+			if err := s.resolverPagoPendiente(ctx, suscripcion); err != nil {
+				if !errors.Is(err, repositories.ErrSuscripcionModificada) {
+					errs = append(errs, fmt.Errorf("suscripción %s: %w", suscripcion.ID.Hex(), err))
+				}
+				continue
+			}
+			total++
+		}
+
+		if len(suscripciones) < batchSize {
+			return total, errors.Join(errs...)
+		}
+	}
+}
+
+func (s *suscripcionService) resolverPagoPendiente(ctx context.Context, suscripcion *entity.Suscripcion) error {
+	filters := map[string]interface{}{
+		"suscripcion_id": suscripcion.ID,
+		"motivo":         entity.MotivoFacturaAlta,
+	}
+	facturas, err := s.facturaRepo.GetAll(ctx, filters, 1, 0)
+	if err != nil {
+		return err
+	}
+
+	var factura *entity.Factura
+	if len(facturas) > 0 {
+		factura = facturas[0]
+	}
+
+	if factura != nil && factura.CobroID != "" {
+		cobro, err := s.gateway.ObtenerCobro(ctx, factura.CobroID)
+		if err != nil && !errors.Is(err, payment.ErrCobroNoEncontrado) {
+			return err
+		}
+		if cobro != nil && cobro.Estado == payment.EstadoCobroPagado {
+			return s.confirmarPago(ctx, suscripcion, factura)
+		}
+	}
+
+	return s.cancelarAltaImpaga(ctx, suscripcion, factura, entity.MotivoCancelacionPagoNoConfirmado)
+}
@@ -0,0 +1,519 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sw2p2go/config"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/payment"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const secretoWebhook = "secreto-de-prueba"
+
+type pagoFixture struct {
+	service         *suscripcionService
+	suscripciones   *fakeSuscripcionRepo
+	facturas        *fakeFacturaRepo
+	eventos         *fakeEventoSuscripcionRepo
+	eventosPago     *fakeEventoPagoRepo
+	cambiosPlan     *fakeCambioPlanRepo
+	cambiosAsientos *fakeCambioAsientosRepo
+	gateway         *payment.FakeGateway
+}
+
+func newPagoFixture(t *testing.T, suscripcion *entity.Suscripcion, facturas ...*entity.Factura) *pagoFixture {
+	t.Helper()
+
+	f := &pagoFixture{
+		suscripciones:   newFakeSuscripcionRepo(suscripcion),
+		facturas:        newFakeFacturaRepo(facturas...),
+		eventos:         &fakeEventoSuscripcionRepo{},
+		eventosPago:     newFakeEventoPagoRepo(),
+		cambiosPlan:     &fakeCambioPlanRepo{},
+		cambiosAsientos: &fakeCambioAsientosRepo{},
+		gateway:         payment.NewFakeGateway(false),
+	}
+
+	cfg := &config.Config{}
+	cfg.Pagos.WebhookSecret = secretoWebhook
+	cfg.Pagos.WebhookReserva = time.Minute
+
+	f.service = &suscripcionService{
+		suscripcionRepo:    f.suscripciones,
+		facturaRepo:        f.facturas,
+		eventoRepo:         f.eventos,
+		eventoPagoRepo:     f.eventosPago,
+		cambioPlanRepo:     f.cambiosPlan,
+		cambioAsientosRepo: f.cambiosAsientos,
+		gateway:            f.gateway,
+		entitlements:       NewEntitlementsCache(time.Minute),
+		cfg:                cfg,
+	}
+	return f
+}
+
+// cobrar crea el cobro de la factura en la pasarela y lo deja en estado.
+func (f *pagoFixture) cobrar(t *testing.T, factura *entity.Factura, estado string) string {
+	t.Helper()
+
+	cobro, err := f.gateway.CrearCobro(context.Background(), payment.CobroRequest{
+		Referencia: factura.ID.Hex(),
+		Monto:      factura.Total,
+		Moneda:     factura.Moneda,
+	})
+	if err != nil {
+		t.Fatalf("CrearCobro: %v", err)
+	}
+	if err := f.gateway.SetEstado(cobro.ID, estado); err != nil {
+		t.Fatalf("SetEstado: %v", err)
+	}
+
+	expected := map[string]interface{}{"estado": factura.Estado}
+	updates := map[string]interface{}{"cobro_id": cobro.ID}
+	if err := f.facturas.UpdateIfMatch(context.Background(), factura.ID, expected, updates); err != nil {
+		t.Fatalf("guardar cobro_id: %v", err)
+	}
+	return cobro.ID
+}
+
+func (f *pagoFixture) suscripcion(t *testing.T, id primitive.ObjectID) *entity.Suscripcion {
+	t.Helper()
+	suscripcion, err := f.suscripciones.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return suscripcion
+}
+
+func (f *pagoFixture) factura(t *testing.T, id primitive.ObjectID) *entity.Factura {
+	t.Helper()
+	factura, err := f.facturas.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return factura
+}
+
+func webhook(t *testing.T, id, tipo, cobroID string) ([]byte, string) {
+	t.Helper()
+	payload, err := json.Marshal(payment.Evento{ID: id, Tipo: tipo, CobroID: cobroID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload, payment.Firmar(secretoWebhook, payload)
+}
+
+// fecha trunca a milisegundos, la precisión con la que Mongo guarda fechas.
+func fecha(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+func nuevaAlta(now time.Time) (*entity.Suscripcion, *entity.Factura) {
+	suscripcion := &entity.Suscripcion{
+		ID:            primitive.NewObjectID(),
+		UsuarioID:     primitive.NewObjectID(),
+		Estado:        entity.EstadoSuscripcionPendientePago,
+		FechaInicio:   fecha(now),
+		InicioPeriodo: fecha(now),
+		FechaFin:      fecha(now.AddDate(0, 1, 0)),
+		Moneda:        "USD",
+		Precio:        1000,
+		AutoRenovar:   true,
+		CreadoEn:      fecha(now),
+	}
+	factura := &entity.Factura{
+		ID:            primitive.NewObjectID(),
+		SuscripcionID: suscripcion.ID,
+		UsuarioID:     suscripcion.UsuarioID,
+		Motivo:        entity.MotivoFacturaAlta,
+		Estado:        entity.EstadoFacturaAbierta,
+		Moneda:        "USD",
+		Total:         1000,
+		PeriodoInicio: suscripcion.InicioPeriodo,
+		PeriodoFin:    suscripcion.FechaFin,
+	}
+	return suscripcion, factura
+}
+
+func TestProcesarWebhookPago_RechazaFirmaOCuerpoInvalido(t *testing.T) {
+	suscripcion, factura := nuevaAlta(time.Now())
+	f := newPagoFixture(t, suscripcion, factura)
+	cobroID := f.cobrar(t, factura, payment.EstadoCobroPagado)
+
+	payload, firma := webhook(t, "evt_1", payment.EventoCobroPagado, cobroID)
+	sinCobro, firmaSinCobro := webhook(t, "evt_2", payment.EventoCobroPagado, "")
+
+	tests := []struct {
+		name    string
+		payload []byte
+		firma   string
+		wantErr error
+	}{
+		{"firma de otro secreto", payload, payment.Firmar("otro", payload), ErrFirmaInvalida},
+		{"sin firma", payload, "", ErrFirmaInvalida},
+		{"cuerpo no JSON", []byte("x"), payment.Firmar(secretoWebhook, []byte("x")), ErrEventoPagoInvalido},
+		{"sin cobro_id", sinCobro, firmaSinCobro, ErrEventoPagoInvalido},
+		{"firma correcta", payload, firma, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.service.ProcesarWebhookPago(context.Background(), tt.payload, tt.firma)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProcesarWebhookPago() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := f.suscripcion(t, suscripcion.ID).Estado; got != entity.EstadoSuscripcionActiva {
+		t.Errorf("estado = %q, want %q", got, entity.EstadoSuscripcionActiva)
+	}
+}
+
+func TestProcesarWebhookPago_EventoDuplicadoSeProcesaUnaVez(t *testing.T) {
+	suscripcion, factura := nuevaAlta(time.Now())
+	f := newPagoFixture(t, suscripcion, factura)
+	cobroID := f.cobrar(t, factura, payment.EstadoCobroPagado)
+
+	payload, firma := webhook(t, "evt_1", payment.EventoCobroPagado, cobroID)
+	for i := 0; i < 3; i++ {
+		if err := f.service.ProcesarWebhookPago(context.Background(), payload, firma); err != nil {
+			t.Fatalf("intento %d: %v", i+1, err)
+		}
+	}
+
+	tipos := f.eventos.tipos()
+	if len(tipos) != 1 || tipos[0] != entity.EventoSuscripcionPagoConfirmado {
+		t.Errorf("eventos = %v, want [%s]", tipos, entity.EventoSuscripcionPagoConfirmado)
+	}
+	if got := f.eventosPago.eventos["evt_1"].Estado; got != entity.EstadoEventoPagoProcesado {
+		t.Errorf("estado del evento = %q, want %q", got, entity.EstadoEventoPagoProcesado)
+	}
+}
+
+func TestProcesarWebhookPago_ReservaVencidaSeRetoma(t *testing.T) {
+	suscripcion, factura := nuevaAlta(time.Now())
+	f := newPagoFixture(t, suscripcion, factura)
+	cobroID := f.cobrar(t, factura, payment.EstadoCobroPagado)
+
+	payload, firma := webhook(t, "evt_1", payment.EventoCobroPagado, cobroID)
+	reservado, err := f.eventosPago.Reservar(context.Background(), &entity.EventoPago{ID: "evt_1"}, time.Minute)
+	if err != nil || !reservado {
+		t.Fatalf("Reservar() = %v, %v", reservado, err)
+	}
+
+	// Mientras otro proceso tiene la reserva el reintento no hace nada
+	if err := f.service.ProcesarWebhookPago(context.Background(), payload, firma); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.suscripcion(t, suscripcion.ID).Estado; got != entity.EstadoSuscripcionPendientePago {
+		t.Fatalf("estado con reserva vigente = %q, want %q", got, entity.EstadoSuscripcionPendientePago)
+	}
+
+	// El proceso se cayó: la reserva vence y el siguiente reintento la toma
+	f.eventosPago.eventos["evt_1"].ReservadoHasta = time.Now().Add(-time.Second)
+	if err := f.service.ProcesarWebhookPago(context.Background(), payload, firma); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.suscripcion(t, suscripcion.ID).Estado; got != entity.EstadoSuscripcionActiva {
+		t.Errorf("estado con reserva vencida = %q, want %q", got, entity.EstadoSuscripcionActiva)
+	}
+}
+
+func TestProcesarWebhookPago_Transiciones(t *testing.T) {
+	now := time.Now()
+
+	type want struct {
+		estado            string
+		motivoCancelacion string
+		autoRenovar       bool
+		fechaFin          time.Time
+		estadoFactura     string
+		eventos           int
+	}
+
+	tests := []struct {
+		name       string
+		preparar   func() (*entity.Suscripcion, *entity.Factura, []*entity.Factura)
+		cobro      string
+		tipoEvento string
+		want       func(suscripcion *entity.Suscripcion, factura *entity.Factura) want
+	}{
+		{
+			name: "alta pagada activa la suscripción",
+			preparar: func() (*entity.Suscripcion, *entity.Factura, []*entity.Factura) {
+				suscripcion, factura := nuevaAlta(now)
+				return suscripcion, factura, nil
+			},
+			cobro:      payment.EstadoCobroPagado,
+			tipoEvento: payment.EventoCobroPagado,
+			want: func(suscripcion *entity.Suscripcion, factura *entity.Factura) want {
+				return want{
+					estado:        entity.EstadoSuscripcionActiva,
+					autoRenovar:   true,
+					fechaFin:      suscripcion.FechaFin,
+					estadoFactura: entity.EstadoFacturaPagada,
+					eventos:       1,
+				}
+			},
+		},
+		{
+			name: "alta rechazada cancela la suscripción",
+			preparar: func() (*entity.Suscripcion, *entity.Factura, []*entity.Factura) {
+				suscripcion, factura := nuevaAlta(now)
+				return suscripcion, factura, nil
+			},
+			cobro:      payment.EstadoCobroFallido,
+			tipoEvento: payment.EventoCobroFallido,
+			want: func(suscripcion *entity.Suscripcion, factura *entity.Factura) want {
+				return want{
+					estado:            entity.EstadoSuscripcionCancelada,
+					motivoCancelacion: entity.MotivoCancelacionPagoRechazado,
+					fechaFin:          suscripcion.FechaFin,
+					estadoFactura:     entity.EstadoFacturaAnulada,
+					eventos:           1,
+				}
+			},
+		},
+		{
+			name: "renovación rechazada con el periodo empezado vence la suscripción",
+			preparar: func() (*entity.Suscripcion, *entity.Factura, []*entity.Factura) {
+				suscripcion, anterior := nuevaAlta(now.AddDate(0, -1, -1))
+				suscripcion.Estado = entity.EstadoSuscripcionActiva
+				anterior.Estado = entity.EstadoFacturaPagada
+				renovacion := &entity.Factura{
+					ID:            primitive.NewObjectID(),
+					SuscripcionID: suscripcion.ID,
+					UsuarioID:     suscripcion.UsuarioID,
+					Motivo:        entity.MotivoFacturaRenovacion,
+					Estado:        entity.EstadoFacturaAbierta,
+					Moneda:        "USD",
+					Total:         1000,
+					PeriodoInicio: anterior.PeriodoFin,
+					PeriodoFin:    fecha(anterior.PeriodoFin.AddDate(0, 1, 0)),
+				}
+				suscripcion.InicioPeriodo = renovacion.PeriodoInicio
+				suscripcion.FechaFin = renovacion.PeriodoFin
+				return suscripcion, renovacion, []*entity.Factura{anterior}
+			},
+			cobro:      payment.EstadoCobroFallido,
+			tipoEvento: payment.EventoCobroFallido,
+			want: func(suscripcion *entity.Suscripcion, factura *entity.Factura) want {
+				return want{
+					estado:        entity.EstadoSuscripcionVencida,
+					fechaFin:      factura.PeriodoInicio,
+					estadoFactura: entity.EstadoFacturaAnulada,
+					eventos:       1,
+				}
+			},
+		},
+		{
+			name: "renovación cobrada por adelantado y rechazada sigue activa hasta el fin pagado",
+			preparar: func() (*entity.Suscripcion, *entity.Factura, []*entity.Factura) {
+				suscripcion, anterior := nuevaAlta(now.AddDate(0, -1, 2))
+				suscripcion.Estado = entity.EstadoSuscripcionActiva
+				anterior.Estado = entity.EstadoFacturaPagada
+				renovacion := &entity.Factura{
+					ID:            primitive.NewObjectID(),
+					SuscripcionID: suscripcion.ID,
+					UsuarioID:     suscripcion.UsuarioID,
+					Motivo:        entity.MotivoFacturaRenovacion,
+					Estado:        entity.EstadoFacturaAbierta,
+					Moneda:        "USD",
+					Total:         1000,
+					PeriodoInicio: anterior.PeriodoFin,
+					PeriodoFin:    fecha(anterior.PeriodoFin.AddDate(0, 1, 0)),
+				}
+				suscripcion.FechaFin = renovacion.PeriodoFin
+				return suscripcion, renovacion, []*entity.Factura{anterior}
+			},
+			cobro:      payment.EstadoCobroFallido,
+			tipoEvento: payment.EventoCobroFallido,
+			want: func(suscripcion *entity.Suscripcion, factura *entity.Factura) want {
+				return want{
+					estado:        entity.EstadoSuscripcionActiva,
+					fechaFin:      factura.PeriodoInicio,
+					estadoFactura: entity.EstadoFacturaAnulada,
+					eventos:       1,
+				}
+			},
+		},
+		{
+			name: "cargo de asientos rechazado sin cambio que deshacer desactiva la renovación",
+			preparar: func() (*entity.Suscripcion, *entity.Factura, []*entity.Factura) {
+				suscripcion, _ := nuevaAlta(now.AddDate(0, 0, -10))
+				suscripcion.Estado = entity.EstadoSuscripcionActiva
+				cargo := &entity.Factura{
+					ID:            primitive.NewObjectID(),
+					SuscripcionID: suscripcion.ID,
+					UsuarioID:     suscripcion.UsuarioID,
+					Motivo:        entity.MotivoFacturaAsientos,
+					Estado:        entity.EstadoFacturaAbierta,
+					Moneda:        "USD",
+					Total:         300,
+					PeriodoInicio: fecha(now),
+					PeriodoFin:    suscripcion.FechaFin,
+				}
+				return suscripcion, cargo, nil
+			},
+			cobro:      payment.EstadoCobroFallido,
+			tipoEvento: payment.EventoCobroFallido,
+			want: func(suscripcion *entity.Suscripcion, factura *entity.Factura) want {
+				return want{
+					estado:        entity.EstadoSuscripcionActiva,
+					fechaFin:      suscripcion.FechaFin,
+					estadoFactura: entity.EstadoFacturaAnulada,
+					eventos:       1,
+				}
+			},
+		},
+		{
+			name: "cobro todavía pendiente no cambia nada",
+			preparar: func() (*entity.Suscripcion, *entity.Factura, []*entity.Factura) {
+				suscripcion, factura := nuevaAlta(now)
+				return suscripcion, factura, nil
+			},
+			cobro:      payment.EstadoCobroPendiente,
+			tipoEvento: payment.EventoCobroPagado,
+			want: func(suscripcion *entity.Suscripcion, factura *entity.Factura) want {
+				return want{
+					estado:        entity.EstadoSuscripcionPendientePago,
+					autoRenovar:   true,
+					fechaFin:      suscripcion.FechaFin,
+					estadoFactura: entity.EstadoFacturaAbierta,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suscripcion, factura, otras := tt.preparar()
+			f := newPagoFixture(t, suscripcion, append(otras, factura)...)
+			cobroID := f.cobrar(t, factura, tt.cobro)
+			w := tt.want(suscripcion, factura)
+
+			payload, firma := webhook(t, "evt_1", tt.tipoEvento, cobroID)
+			if err := f.service.ProcesarWebhookPago(context.Background(), payload, firma); err != nil {
+				t.Fatalf("ProcesarWebhookPago: %v", err)
+			}
+
+			got := f.suscripcion(t, suscripcion.ID)
+			if got.Estado != w.estado {
+				t.Errorf("estado = %q, want %q", got.Estado, w.estado)
+			}
+			if got.MotivoCancelacion != w.motivoCancelacion {
+				t.Errorf("motivo_cancelacion = %q, want %q", got.MotivoCancelacion, w.motivoCancelacion)
+			}
+			if got.AutoRenovar != w.autoRenovar {
+				t.Errorf("auto_renovar = %v, want %v", got.AutoRenovar, w.autoRenovar)
+			}
+			if !got.FechaFin.Equal(w.fechaFin) {
+				t.Errorf("fecha_fin = %v, want %v", got.FechaFin, w.fechaFin)
+			}
+			if estado := f.factura(t, factura.ID).Estado; estado != w.estadoFactura {
+				t.Errorf("estado de la factura = %q, want %q", estado, w.estadoFactura)
+			}
+			if eventos := f.eventos.tipos(); len(eventos) != w.eventos {
+				t.Errorf("eventos = %v, want %d", eventos, w.eventos)
+			}
+			if n := len(got.EventosPendientes); n != 0 {
+				t.Errorf("eventos pendientes = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestProcesarWebhookPago_CargoRechazadoDeshaceElCambio(t *testing.T) {
+	now := time.Now()
+
+	nuevoCargo := func(suscripcion *entity.Suscripcion, motivo string, total int64) *entity.Factura {
+		return &entity.Factura{
+			ID:            primitive.NewObjectID(),
+			SuscripcionID: suscripcion.ID,
+			UsuarioID:     suscripcion.UsuarioID,
+			Motivo:        motivo,
+			Estado:        entity.EstadoFacturaAbierta,
+			Moneda:        "USD",
+			Total:         total,
+			PeriodoInicio: fecha(now),
+			PeriodoFin:    suscripcion.FechaFin,
+		}
+	}
+
+	t.Run("asientos", func(t *testing.T) {
+		suscripcion, _ := nuevaAlta(now.AddDate(0, 0, -10))
+		suscripcion.Estado = entity.EstadoSuscripcionActiva
+		suscripcion.Cantidad = 5
+		suscripcion.Precio = 5000
+		cargo := nuevoCargo(suscripcion, entity.MotivoFacturaAsientos, 2000)
+
+		f := newPagoFixture(t, suscripcion, cargo)
+		f.cambiosAsientos.cambios = append(f.cambiosAsientos.cambios, &entity.CambioAsientos{
+			SuscripcionID:    suscripcion.ID,
+			CantidadAnterior: 3,
+			CantidadNueva:    5,
+			PrecioUnitario:   1000,
+			FacturaID:        &cargo.ID,
+		})
+		cobroID := f.cobrar(t, cargo, payment.EstadoCobroFallido)
+
+		payload, firma := webhook(t, "evt_1", payment.EventoCobroFallido, cobroID)
+		if err := f.service.ProcesarWebhookPago(context.Background(), payload, firma); err != nil {
+			t.Fatalf("ProcesarWebhookPago: %v", err)
+		}
+
+		got := f.suscripcion(t, suscripcion.ID)
+		if got.Cantidad != 3 || got.Precio != 3000 {
+			t.Errorf("cantidad, precio = %d, %d, want 3, 3000", got.Cantidad, got.Precio)
+		}
+		if !got.AutoRenovar {
+			t.Error("auto_renovar = false, want true")
+		}
+		if estado := f.factura(t, cargo.ID).Estado; estado != entity.EstadoFacturaAnulada {
+			t.Errorf("estado de la factura = %q, want %q", estado, entity.EstadoFacturaAnulada)
+		}
+	})
+
+	t.Run("cambio de plan", func(t *testing.T) {
+		suscripcion, _ := nuevaAlta(now.AddDate(0, 0, -10))
+		suscripcion.Estado = entity.EstadoSuscripcionActiva
+		planAnterior := primitive.NewObjectID()
+		suscripcion.PlanID = primitive.NewObjectID()
+		suscripcion.PlanVersion = 2
+		suscripcion.Precio = 3000
+		cargo := nuevoCargo(suscripcion, entity.MotivoFacturaCambioPlan, 1500)
+
+		f := newPagoFixture(t, suscripcion, cargo)
+		f.cambiosPlan.cambios = append(f.cambiosPlan.cambios, &entity.CambioPlan{
+			SuscripcionID:     suscripcion.ID,
+			PlanAnteriorID:    planAnterior,
+			PlanVersionPrevia: 1,
+			PrecioPrevio:      1000,
+			PlanNuevoID:       suscripcion.PlanID,
+			PlanVersionNueva:  2,
+			PrecioNuevo:       3000,
+			Modo:              entity.ModoCambioInmediato,
+			FacturaID:         &cargo.ID,
+		})
+		cobroID := f.cobrar(t, cargo, payment.EstadoCobroFallido)
+
+		payload, firma := webhook(t, "evt_1", payment.EventoCobroFallido, cobroID)
+		if err := f.service.ProcesarWebhookPago(context.Background(), payload, firma); err != nil {
+			t.Fatalf("ProcesarWebhookPago: %v", err)
+		}
+
+		got := f.suscripcion(t, suscripcion.ID)
+		if got.PlanID != planAnterior || got.PlanVersion != 1 || got.Precio != 1000 {
+			t.Errorf("plan, versión, precio = %s, %d, %d, want %s, 1, 1000", got.PlanID.Hex(), got.PlanVersion, got.Precio, planAnterior.Hex())
+		}
+		if !got.AutoRenovar {
+			t.Error("auto_renovar = false, want true")
+		}
+	})
+}
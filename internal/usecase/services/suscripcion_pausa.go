@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrPausaExcedida = errors.New("la pausa supera los días permitidos")
+
+// PausarSuscripcion congela la suscripción hasta por el máximo de días que
+// permite su plan, sumando todas las pausas de los últimos 12 meses. Mientras
+// está pausada no da acceso ni se renueva ni vence.
+func (s *suscripcionService) PausarSuscripcion(ctx context.Context, id, userID string, req *dto.PausarSuscripcionRequest) error {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if suscripcion.Estado != entity.EstadoSuscripcionActiva || !suscripcion.IsActive() {
+		return errors.New("solo se pueden pausar suscripciones activas")
+	}
+	if suscripcion.CancelarAlFinal {
+		return errors.New("la suscripción ya tiene una cancelación programada")
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, suscripcion.PlanID)
+	if err != nil {
+		return err
+	}
+
+	if plan.MaxDiasPausa == 0 {
+		return errors.New("el plan no permite pausar la suscripción")
+	}
+	if req.Dias > plan.MaxDiasPausa {
+		return fmt.Errorf("%w: el plan permite hasta %d días", ErrPausaExcedida, plan.MaxDiasPausa)
+	}
+
+	now := time.Now()
+
+	restantes := plan.MaxDiasPausa - suscripcion.DiasPausados(now.AddDate(-1, 0, 0), now)
+	if req.Dias > restantes {
+		return fmt.Errorf("%w: le quedan %d días de pausa en los últimos 12 meses", ErrPausaExcedida, max(restantes, 0))
+	}
+
+	expected := map[string]interface{}{
+		"fecha_fin": suscripcion.FechaFin,
+	}
+	updates := map[string]interface{}{
+		"pausada_desde": now,
+		"pausada_hasta": now.AddDate(0, 0, req.Dias),
+	}
+
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionPausada, func(evento *entity.EventoSuscripcion) error {
+		return s.transicionar(ctx, suscripcion, entity.EstadoSuscripcionPausada, expected, updates, evento)
+	}, updates)
+}
+
+func (s *suscripcionService) ReanudarSuscripcion(ctx context.Context, id, userID string) error {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !suscripcion.IsPaused() {
+		return errors.New("la suscripción no está pausada")
+	}
+
+	return s.resumeSuscripcion(ctx, suscripcion, time.Now())
+}
+
+// ResumePausedSuscripciones reanuda las suscripciones cuya pausa llegó al
+// límite solicitado, extendiéndolas solo por los días efectivamente pausados.
+func (s *suscripcionService) ResumePausedSuscripciones(ctx context.Context) (int64, error) {
+	now := time.Now()
+	batchSize := s.cfg.Scheduler.RenewalBatchSize
+
+	var (
+		total   int64
+		errs    []error
+		afterID primitive.ObjectID
+	)
+	for {
+		suscripciones, err := s.suscripcionRepo.GetPausesEnded(ctx, now, afterID, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, suscripcion := range suscripciones {
+			afterID = suscripcion.ID
+
+			if err := s.resumeSuscripcion(ctx, suscripcion, suscripcion.PausadaHasta); err != nil {
+				if !errors.Is(err, repositories.ErrSuscripcionModificada) {
+					errs = append(errs, fmt.Errorf("suscripción %s: %w", suscripcion.ID.Hex(), err))
+				}
+				continue
+			}
+			total++
+		}
+
+		if len(suscripciones) < batchSize {
+			return total, errors.Join(errs...)
+		}
+	}
+}
+
+func (s *suscripcionService) resumeSuscripcion(ctx context.Context, suscripcion *entity.Suscripcion, fin time.Time) error {
+	if fin.After(suscripcion.PausadaHasta) {
+		fin = suscripcion.PausadaHasta
+	}
+
+	pausado := fin.Sub(suscripcion.PausadaDesde)
+	if pausado < 0 {
+		pausado = 0
+	}
+
+	pausas := append(suscripcion.Pausas, entity.PeriodoPausa{
+		Inicio: suscripcion.PausadaDesde,
+		Fin:    fin,
+	})
+
+	expected := map[string]interface{}{
+		"pausada_desde": suscripcion.PausadaDesde,
+	}
+	// Mover el ancla al nuevo fin evita que la renovación vuelva al día
+	// original y se coma los días pausados
+	fechaFin := suscripcion.FechaFin.Add(pausado)
+	updates := map[string]interface{}{
+		"fecha_fin":     fechaFin,
+		"dia_ancla":     fechaFin.Day(),
+		"pausada_desde": time.Time{},
+		"pausada_hasta": time.Time{},
+		"pausas":        pausas,
+	}
+
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionReanudada, func(evento *entity.EventoSuscripcion) error {
+		return s.transicionar(ctx, suscripcion, entity.EstadoSuscripcionActiva, expected, updates, evento)
+	}, updates)
+}
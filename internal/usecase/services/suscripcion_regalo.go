@@ -0,0 +1,545 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"sw2p2go/internal/actor"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrPlanNoRegalable    = errors.New("el plan exige más de un asiento y no se puede regalar")
+	ErrRegaloNoDisponible = errors.New("el código de regalo no está disponible")
+	ErrRegaloExpirado     = errors.New("el código de regalo expiró")
+	ErrRegaloCanjeado     = errors.New("el código de regalo ya fue canjeado")
+	ErrRegaloRevocado     = errors.New("el código de regalo fue revocado")
+	ErrRegaloOtroPlan     = errors.New("el regalo es de un plan distinto al de la suscripción vigente")
+	ErrRegaloNoAplicable  = errors.New("la suscripción vigente no se puede extender con un regalo")
+	ErrRegaloYaCanjeado   = errors.New("solo se pueden modificar regalos sin canjear")
+)
+
+// Sin 0/O ni 1/I para que el código se pueda dictar sin ambigüedad
+const alfabetoCodigoRegalo = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// ComprarRegalo crea el regalo y factura al comprador el precio del plan por
+// los periodos regalados. El código se puede canjear cuando se confirma el
+// pago.
+func (s *suscripcionService) ComprarRegalo(ctx context.Context, userID string, req *dto.ComprarRegaloRequest) (*dto.RegaloDTO, error) {
+	compradorID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	planID, err := primitive.ObjectIDFromHex(req.PlanID)
+	if err != nil {
+		return nil, errors.New("ID de plan inválido")
+	}
+
+	comprador, err := s.userRepo.GetByID(ctx, compradorID)
+	if err != nil {
+		return nil, errors.New("usuario no encontrado")
+	}
+	if !comprador.Estado {
+		return nil, errors.New("usuario inactivo")
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return nil, errors.New("plan no encontrado")
+	}
+	if !plan.Activo {
+		return nil, errors.New("plan inactivo")
+	}
+	if !plan.AdmiteAsientos(1) {
+		return nil, ErrPlanNoRegalable
+	}
+
+	moneda, err := normalizarMoneda(req.Moneda, s.cfg.Suscripciones.MonedaDefault)
+	if err != nil {
+		return nil, err
+	}
+	precio, ok := plan.PrecioEn(moneda)
+	if !ok {
+		return nil, ErrMonedaNoDisponible
+	}
+
+	codigo, err := s.generarCodigoRegalo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	total := precio * int64(req.Periodos)
+
+	impuesto, err := calcularImpuesto(ctx, s.tasaRepo, comprador, total, plan.ImpuestoIncluido)
+	if err != nil {
+		return nil, err
+	}
+
+	regalo := &entity.Regalo{
+		ID:          primitive.NewObjectID(),
+		Codigo:      codigo,
+		CompradorID: compradorID,
+		PlanID:      planID,
+		PlanVersion: plan.VersionActual(),
+		Periodos:    req.Periodos,
+		Moneda:      moneda,
+		Precio:      precio,
+		Estado:      entity.EstadoRegaloPendientePago,
+		ExpiraEn:    now.Add(s.cfg.Suscripciones.RegaloVigencia),
+		CreadoEn:    now,
+	}
+
+	// La compra de un regalo no tiene suscripción ni periodo de servicio
+	factura := &entity.Factura{
+		ID:        primitive.NewObjectID(),
+		UsuarioID: compradorID,
+		RegaloID:  &regalo.ID,
+		Motivo:    entity.MotivoFacturaRegalo,
+		Estado:    entity.EstadoFacturaBorrador,
+		Moneda:    moneda,
+		Lineas: []entity.LineaFactura{{
+			Tipo:           entity.LineaFacturaPlan,
+			Descripcion:    fmt.Sprintf("Regalo del plan %s (%d periodos)", plan.Nombre, req.Periodos),
+			Cantidad:       req.Periodos,
+			PrecioUnitario: precio,
+			Monto:          total,
+		}},
+		Subtotal: total,
+		Total:    total,
+		CreadoEn: now,
+	}
+	agregarImpuesto(factura, impuesto)
+	regalo.FacturaID = &factura.ID
+
+	if err := s.regaloRepo.Create(ctx, regalo); err != nil {
+		return nil, err
+	}
+
+	factura, cobro, err := s.emitirYCobrar(ctx, compradorID, factura)
+	if err != nil {
+		return nil, errors.Join(err, s.rechazarPagoRegalo(ctx, regalo.ID, factura))
+	}
+
+	if err := s.confirmarPagoRegalo(ctx, regalo.ID, factura); err != nil {
+		return nil, err
+	}
+
+	if regalo, err = s.regaloRepo.GetByID(ctx, regalo.ID); err != nil {
+		return nil, err
+	}
+
+	result := regaloToDTO(regalo, time.Now())
+	result.Pago = &dto.PagoDTO{
+		FacturaID: factura.ID.Hex(),
+		Estado:    factura.Estado,
+		Monto:     factura.Total,
+		Moneda:    factura.Moneda,
+	}
+	if cobro != nil {
+		result.Pago.CobroID = cobro.ID
+		result.Pago.URLPago = cobro.URLPago
+	}
+
+	return result, nil
+}
+
+// CanjearRegalo le da al usuario los periodos del regalo. Si ya tiene una
+// suscripción activa o pausada del mismo plan y de un solo asiento la extiende;
+// si no tiene ninguna vigente crea una activa sin renovación automática, que
+// puede activar después para seguir pagando él.
+func (s *suscripcionService) CanjearRegalo(ctx context.Context, userID string, req *dto.CanjearRegaloRequest) (*dto.CanjeRegaloDTO, error) {
+	usuarioID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	usuario, err := s.userRepo.GetByID(ctx, usuarioID)
+	if err != nil {
+		return nil, errors.New("usuario no encontrado")
+	}
+	if !usuario.Estado {
+		return nil, errors.New("usuario inactivo")
+	}
+
+	regalo, err := s.regaloRepo.GetByCodigo(ctx, normalizarCodigo(req.Codigo))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch regalo.EstadoEn(now) {
+	case entity.EstadoRegaloDisponible:
+	case entity.EstadoRegaloExpirado:
+		return nil, ErrRegaloExpirado
+	case entity.EstadoRegaloCanjeado:
+		return nil, ErrRegaloCanjeado
+	case entity.EstadoRegaloRevocado:
+		return nil, ErrRegaloRevocado
+	default:
+		return nil, ErrRegaloNoDisponible
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, regalo.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := s.suscripcionRepo.GetActiveSuscripcionByUserID(ctx, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	if actual != nil {
+		if actual.PlanID != regalo.PlanID {
+			return nil, ErrRegaloOtroPlan
+		}
+		// El regalo paga un solo asiento y una prueba no tiene periodo pagado
+		// que extender
+		estado := actual.Estado
+		if (estado != entity.EstadoSuscripcionActiva && estado != entity.EstadoSuscripcionPausada) || actual.GetCantidad() != 1 {
+			return nil, ErrRegaloNoAplicable
+		}
+	}
+
+	suscripcionID := primitive.NewObjectID()
+	if actual != nil {
+		suscripcionID = actual.ID
+	}
+
+	// Reservar el código antes de tocar la suscripción es lo que impide
+	// canjearlo dos veces
+	reservado := map[string]interface{}{
+		"estado":         entity.EstadoRegaloCanjeado,
+		"canjeado_por":   usuarioID,
+		"canjeado_en":    now,
+		"suscripcion_id": suscripcionID,
+	}
+	disponible := map[string]interface{}{
+		"estado":    entity.EstadoRegaloDisponible,
+		"expira_en": map[string]interface{}{"$gt": now},
+	}
+	if err := s.regaloRepo.UpdateIfMatch(ctx, regalo.ID, disponible, reservado); err != nil {
+		if errors.Is(err, repositories.ErrRegaloModificado) {
+			return nil, ErrRegaloNoDisponible
+		}
+		return nil, err
+	}
+
+	var suscripcion *entity.Suscripcion
+	if actual != nil {
+		suscripcion, err = s.extenderConRegalo(ctx, actual, plan, regalo)
+	} else {
+		suscripcion, err = s.crearDesdeRegalo(ctx, suscripcionID, usuarioID, plan, regalo, now)
+	}
+	if err != nil {
+		return nil, errors.Join(err, s.liberarRegalo(ctx, regalo.ID, usuarioID))
+	}
+
+	regalo.Estado = entity.EstadoRegaloCanjeado
+	regalo.CanjeadoPor = &usuarioID
+	regalo.CanjeadoEn = now
+	regalo.SuscripcionID = &suscripcionID
+
+	return &dto.CanjeRegaloDTO{
+		Regalo:      *regaloToDTO(regalo, now),
+		Extendida:   actual != nil,
+		Suscripcion: *suscripcionToDTO(suscripcion),
+	}, nil
+}
+
+// extenderConRegalo suma los periodos del regalo al final de la suscripción.
+func (s *suscripcionService) extenderConRegalo(ctx context.Context, suscripcion *entity.Suscripcion, plan *entity.PlanSuscripcion, regalo *entity.Regalo) (*entity.Suscripcion, error) {
+	fechaFin := suscripcion.FechaFin
+	for i := 0; i < regalo.Periodos; i++ {
+		fechaFin = plan.CalcularFechaFin(fechaFin, suscripcion.DiaAncla())
+	}
+
+	expected := map[string]interface{}{
+		"estado":    suscripcion.Estado,
+		"plan_id":   suscripcion.PlanID,
+		"fecha_fin": suscripcion.FechaFin,
+	}
+	updates := map[string]interface{}{"fecha_fin": fechaFin}
+	despues := map[string]interface{}{
+		"fecha_fin": fechaFin,
+		"regalo_id": regalo.ID,
+	}
+
+	// Solo falla si la suscripción no se extendió; el evento va en la misma
+	// escritura
+	evento := nuevoEvento(ctx, suscripcion, entity.EventoSuscripcionRegaloCanjeado, valoresAnteriores(suscripcion, updates), despues)
+	err := s.aplicarEvento(ctx, suscripcion, evento, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	suscripcion.FechaFin = fechaFin
+	return suscripcion, nil
+}
+
+// crearDesdeRegalo crea la suscripción ya activa y pagada, en la versión del
+// plan que se compró.
+func (s *suscripcionService) crearDesdeRegalo(ctx context.Context, id, usuarioID primitive.ObjectID, plan *entity.PlanSuscripcion, regalo *entity.Regalo, now time.Time) (*entity.Suscripcion, error) {
+	fechaFin := now
+	for i := 0; i < regalo.Periodos; i++ {
+		fechaFin = plan.CalcularFechaFin(fechaFin, now.Day())
+	}
+
+	suscripcion := &entity.Suscripcion{
+		ID:            id,
+		UsuarioID:     usuarioID,
+		PlanID:        regalo.PlanID,
+		PlanVersion:   regalo.PlanVersion,
+		FechaInicio:   now,
+		FechaFin:      fechaFin,
+		InicioPeriodo: now,
+		Estado:        entity.EstadoSuscripcionActiva,
+		Moneda:        regalo.Moneda,
+		Precio:        regalo.Precio,
+		Cantidad:      1,
+		AutoRenovar:   false,
+		Transiciones: []entity.TransicionEstado{{
+			Hasta: entity.EstadoSuscripcionActiva,
+			Fecha: now,
+			Actor: actor.FromContext(ctx),
+		}},
+		CreadoEn: now,
+	}
+
+	despues := map[string]interface{}{
+		"estado":       suscripcion.Estado,
+		"plan_id":      suscripcion.PlanID,
+		"plan_version": suscripcion.PlanVersion,
+		"fecha_inicio": suscripcion.FechaInicio,
+		"fecha_fin":    suscripcion.FechaFin,
+		"moneda":       suscripcion.Moneda,
+		"precio":       suscripcion.Precio,
+		"auto_renovar": suscripcion.AutoRenovar,
+		"regalo_id":    regalo.ID,
+	}
+	if err := s.crearConEvento(ctx, suscripcion, despues); err != nil {
+		return nil, err
+	}
+
+	return suscripcion, nil
+}
+
+// liberarRegalo deshace la reserva del código cuando el canje no se pudo
+// completar, para que se pueda volver a intentar.
+func (s *suscripcionService) liberarRegalo(ctx context.Context, id, usuarioID primitive.ObjectID) error {
+	expected := map[string]interface{}{
+		"estado":       entity.EstadoRegaloCanjeado,
+		"canjeado_por": usuarioID,
+	}
+	updates := map[string]interface{}{
+		"estado":         entity.EstadoRegaloDisponible,
+		"canjeado_por":   nil,
+		"canjeado_en":    time.Time{},
+		"suscripcion_id": nil,
+	}
+	return s.regaloRepo.UpdateIfMatch(ctx, id, expected, updates)
+}
+
+// confirmarPagoRegalo deja el código disponible una vez pagada la compra.
+// Repetirlo no tiene efecto.
+func (s *suscripcionService) confirmarPagoRegalo(ctx context.Context, regaloID primitive.ObjectID, factura *entity.Factura) error {
+	if factura.Estado == entity.EstadoFacturaAbierta {
+		if err := s.marcarFacturaPagada(ctx, factura); err != nil {
+			return err
+		}
+	}
+	if factura.Estado != entity.EstadoFacturaPagada {
+		return nil
+	}
+
+	expected := map[string]interface{}{"estado": entity.EstadoRegaloPendientePago}
+	updates := map[string]interface{}{"estado": entity.EstadoRegaloDisponible}
+	if err := s.regaloRepo.UpdateIfMatch(ctx, regaloID, expected, updates); err != nil && !errors.Is(err, repositories.ErrRegaloModificado) {
+		return err
+	}
+	return nil
+}
+
+// rechazarPagoRegalo anula la compra y su factura si el pago fue rechazado.
+func (s *suscripcionService) rechazarPagoRegalo(ctx context.Context, regaloID primitive.ObjectID, factura *entity.Factura) error {
+	if err := s.anularFacturaAbierta(ctx, factura, time.Now()); err != nil {
+		return err
+	}
+
+	expected := map[string]interface{}{"estado": entity.EstadoRegaloPendientePago}
+	updates := map[string]interface{}{"estado": entity.EstadoRegaloAnulado}
+	if err := s.regaloRepo.UpdateIfMatch(ctx, regaloID, expected, updates); err != nil && !errors.Is(err, repositories.ErrRegaloModificado) {
+		return err
+	}
+	return nil
+}
+
+func (s *suscripcionService) GetMisRegalos(ctx context.Context, userID string, limit, offset int) ([]*dto.RegaloDTO, int64, error) {
+	compradorID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, errors.New("ID de usuario inválido")
+	}
+
+	return s.listarRegalos(ctx, map[string]interface{}{"comprador_id": compradorID}, limit, offset)
+}
+
+// GetAllRegalos lista los regalos para administración, opcionalmente por el
+// estado guardado; los expirados figuran como disponibles en el filtro.
+func (s *suscripcionService) GetAllRegalos(ctx context.Context, estado string, limit, offset int) ([]*dto.RegaloDTO, int64, error) {
+	filters := make(map[string]interface{})
+	if estado != "" {
+		filters["estado"] = estado
+	}
+
+	return s.listarRegalos(ctx, filters, limit, offset)
+}
+
+func (s *suscripcionService) listarRegalos(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]*dto.RegaloDTO, int64, error) {
+	regalos, err := s.regaloRepo.GetAll(ctx, filters, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.regaloRepo.Count(ctx, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	dtos := make([]*dto.RegaloDTO, 0, len(regalos))
+	for _, regalo := range regalos {
+		dtos = append(dtos, regaloToDTO(regalo, now))
+	}
+
+	return dtos, total, nil
+}
+
+// RevocarRegalo invalida un código que todavía no se canjeó. La compra no se
+// reembolsa sola.
+func (s *suscripcionService) RevocarRegalo(ctx context.Context, id string) error {
+	regalo, err := s.getRegaloSinCanjear(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	expected := map[string]interface{}{"estado": regalo.Estado}
+	updates := map[string]interface{}{
+		"estado":      entity.EstadoRegaloRevocado,
+		"revocado_en": time.Now(),
+	}
+	return s.regaloRepo.UpdateIfMatch(ctx, regalo.ID, expected, updates)
+}
+
+// ExpirarRegalo cambia la fecha hasta la que se puede canjear el código; sin
+// fecha lo expira de inmediato.
+func (s *suscripcionService) ExpirarRegalo(ctx context.Context, id string, req *dto.ExpirarRegaloRequest) error {
+	regalo, err := s.getRegaloSinCanjear(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	expiraEn := time.Now()
+	if req.ExpiraEn != "" {
+		if expiraEn, err = parseFecha(req.ExpiraEn); err != nil {
+			return err
+		}
+	}
+
+	expected := map[string]interface{}{"estado": regalo.Estado}
+	updates := map[string]interface{}{"expira_en": expiraEn}
+	return s.regaloRepo.UpdateIfMatch(ctx, regalo.ID, expected, updates)
+}
+
+func (s *suscripcionService) getRegaloSinCanjear(ctx context.Context, id string) (*entity.Regalo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de regalo inválido")
+	}
+
+	regalo, err := s.regaloRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if regalo.Estado != entity.EstadoRegaloPendientePago && regalo.Estado != entity.EstadoRegaloDisponible {
+		return nil, ErrRegaloYaCanjeado
+	}
+
+	return regalo, nil
+}
+
+// generarCodigoRegalo devuelve un código aleatorio como ABCD-EFGH-JKLM-NPQR
+// que no esté en uso.
+func (s *suscripcionService) generarCodigoRegalo(ctx context.Context) (string, error) {
+	for intento := 0; intento < 3; intento++ {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+
+		var sb strings.Builder
+		for i, c := range b {
+			if i > 0 && i%4 == 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(alfabetoCodigoRegalo[int(c)%len(alfabetoCodigoRegalo)])
+		}
+
+		codigo := sb.String()
+		exists, err := s.regaloRepo.CodigoExists(ctx, codigo)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return codigo, nil
+		}
+	}
+
+	return "", errors.New("no se pudo generar un código de regalo único")
+}
+
+func regaloToDTO(regalo *entity.Regalo, now time.Time) *dto.RegaloDTO {
+	result := &dto.RegaloDTO{
+		ID:          regalo.ID.Hex(),
+		Codigo:      regalo.Codigo,
+		CompradorID: regalo.CompradorID.Hex(),
+		PlanID:      regalo.PlanID.Hex(),
+		PlanVersion: regalo.PlanVersion,
+		Periodos:    regalo.Periodos,
+		Moneda:      regalo.Moneda,
+		Precio:      regalo.Precio,
+		Estado:      regalo.EstadoEn(now),
+		ExpiraEn:    regalo.ExpiraEn,
+		CreadoEn:    regalo.CreadoEn,
+	}
+
+	if regalo.FacturaID != nil {
+		result.FacturaID = regalo.FacturaID.Hex()
+	}
+	if regalo.CanjeadoPor != nil {
+		result.CanjeadoPor = regalo.CanjeadoPor.Hex()
+	}
+	if !regalo.CanjeadoEn.IsZero() {
+		canjeadoEn := regalo.CanjeadoEn
+		result.CanjeadoEn = &canjeadoEn
+	}
+	if regalo.SuscripcionID != nil {
+		result.SuscripcionID = regalo.SuscripcionID.Hex()
+	}
+	if !regalo.RevocadoEn.IsZero() {
+		revocadoEn := regalo.RevocadoEn
+		result.RevocadoEn = &revocadoEn
+	}
+
+	return result
+}
@@ -3,8 +3,13 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sw2p2go/config"
+	"sw2p2go/internal/actor"
 	"sw2p2go/internal/dto"
 	"sw2p2go/internal/entity"
+	"sw2p2go/internal/payment"
 	"sw2p2go/internal/usecase/repositories"
 	"time"
 
@@ -12,20 +17,77 @@ import (
 )
 
 type suscripcionService struct {
-	suscripcionRepo repositories.SuscripcionRepository
-	userRepo        repositories.UsuarioRepository
-	planRepo        repositories.PlanRepository
+	suscripcionRepo      repositories.SuscripcionRepository
+	userRepo             repositories.UsuarioRepository
+	planRepo             repositories.PlanRepository
+	versionRepo          repositories.VersionPlanRepository
+	renovacionRepo       repositories.RenovacionRepository
+	cambioPlanRepo       repositories.CambioPlanRepository
+	cambioAsientosRepo   repositories.CambioAsientosRepository
+	asientoRepo          repositories.AsientoRepository
+	complementoRepo      repositories.ComplementoRepository
+	regaloRepo           repositories.RegaloRepository
+	cambioProgramadoRepo repositories.CambioProgramadoRepository
+	creditoRepo          repositories.CreditoRepository
+	eventoRepo           repositories.EventoSuscripcionRepository
+	cuponRepo            repositories.CuponRepository
+	canjeRepo            repositories.CanjeCuponRepository
+	facturaRepo          repositories.FacturaRepository
+	contadorRepo         repositories.ContadorRepository
+	eventoPagoRepo       repositories.EventoPagoRepository
+	tasaRepo             repositories.TasaImpuestoRepository
+	gateway              payment.Gateway
+	entitlements         *EntitlementsCache
+	cfg                  *config.Config
 }
 
 func NewSuscripcionService(
 	suscripcionRepo repositories.SuscripcionRepository,
 	userRepo repositories.UsuarioRepository,
 	planRepo repositories.PlanRepository,
+	versionRepo repositories.VersionPlanRepository,
+	renovacionRepo repositories.RenovacionRepository,
+	cambioPlanRepo repositories.CambioPlanRepository,
+	cambioAsientosRepo repositories.CambioAsientosRepository,
+	asientoRepo repositories.AsientoRepository,
+	complementoRepo repositories.ComplementoRepository,
+	regaloRepo repositories.RegaloRepository,
+	cambioProgramadoRepo repositories.CambioProgramadoRepository,
+	creditoRepo repositories.CreditoRepository,
+	eventoRepo repositories.EventoSuscripcionRepository,
+	cuponRepo repositories.CuponRepository,
+	canjeRepo repositories.CanjeCuponRepository,
+	facturaRepo repositories.FacturaRepository,
+	contadorRepo repositories.ContadorRepository,
+	eventoPagoRepo repositories.EventoPagoRepository,
+	tasaRepo repositories.TasaImpuestoRepository,
+	gateway payment.Gateway,
+	entitlements *EntitlementsCache,
+	cfg *config.Config,
 ) SuscripcionService {
 	return &suscripcionService{
-		suscripcionRepo: suscripcionRepo,
-		userRepo:        userRepo,
-		planRepo:        planRepo,
+		suscripcionRepo:      suscripcionRepo,
+		userRepo:             userRepo,
+		planRepo:             planRepo,
+		versionRepo:          versionRepo,
+		renovacionRepo:       renovacionRepo,
+		cambioPlanRepo:       cambioPlanRepo,
+		cambioAsientosRepo:   cambioAsientosRepo,
+		asientoRepo:          asientoRepo,
+		complementoRepo:      complementoRepo,
+		regaloRepo:           regaloRepo,
+		cambioProgramadoRepo: cambioProgramadoRepo,
+		creditoRepo:          creditoRepo,
+		eventoRepo:           eventoRepo,
+		cuponRepo:            cuponRepo,
+		canjeRepo:            canjeRepo,
+		facturaRepo:          facturaRepo,
+		contadorRepo:         contadorRepo,
+		eventoPagoRepo:       eventoPagoRepo,
+		tasaRepo:             tasaRepo,
+		gateway:              gateway,
+		entitlements:         entitlements,
+		cfg:                  cfg,
 	}
 }
 
@@ -56,42 +118,164 @@ func (s *suscripcionService) CreateSuscripcion(ctx context.Context, req *dto.Cre
 		return nil, errors.New("plan inactivo")
 	}
 
+	moneda, err := normalizarMoneda(req.Moneda, s.cfg.Suscripciones.MonedaDefault)
+	if err != nil {
+		return nil, err
+	}
+	precio, ok := plan.PrecioEn(moneda)
+	if !ok {
+		return nil, ErrMonedaNoDisponible
+	}
+
+	cantidad := req.Cantidad
+	if cantidad == 0 {
+		cantidad, _ = plan.LimitesAsientos()
+	}
+	if !plan.AdmiteAsientos(cantidad) {
+		return nil, ErrCantidadAsientosInvalida
+	}
+	precio *= int64(cantidad)
+
 	activeSuscripcion, err := s.suscripcionRepo.GetActiveSuscripcionByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	if activeSuscripcion != nil {
-		return nil, errors.New("el usuario ya tiene una suscripción activa")
+		return nil, ErrSuscripcionActivaExistente
 	}
 
 	fechaInicio := time.Now()
 	if req.FechaInicio != "" {
-		if parsedTime, err := time.Parse("2006-01-02", req.FechaInicio); err == nil {
-			fechaInicio = parsedTime
+		if fechaInicio, err = parseFecha(req.FechaInicio); err != nil {
+			return nil, err
 		}
 	}
 
-	fechaFin := fechaInicio.AddDate(0, 1, 0)
+	fechaFin := plan.CalcularFechaFin(fechaInicio, fechaInicio.Day())
 	if req.FechaFin != "" {
-		if parsedTime, err := time.Parse("2006-01-02", req.FechaFin); err == nil {
-			fechaFin = parsedTime
+		if fechaFin, err = parseFecha(req.FechaFin); err != nil {
+			return nil, err
 		}
 	}
 
+	if !fechaFin.After(fechaInicio) {
+		return nil, ErrRangoFechas
+	}
+
+	autoRenovar := true
+	if req.AutoRenovar != nil {
+		autoRenovar = *req.AutoRenovar
+	}
+
 	suscripcion := &entity.Suscripcion{
-		UsuarioID:   userID,
-		PlanID:      planID,
-		FechaInicio: fechaInicio,
-		FechaFin:    fechaFin,
-		Estado:      entity.EstadoSuscripcionActiva,
-		CreadoEn:    time.Now(),
+		UsuarioID:     userID,
+		PlanID:        planID,
+		PlanVersion:   plan.VersionActual(),
+		FechaInicio:   fechaInicio,
+		FechaFin:      fechaFin,
+		InicioPeriodo: fechaInicio,
+		Estado:        entity.EstadoSuscripcionPendientePago,
+		Moneda:        moneda,
+		Precio:        precio,
+		Cantidad:      cantidad,
+		AutoRenovar:   autoRenovar,
+		CreadoEn:      time.Now(),
 	}
 
-	if err := s.suscripcionRepo.Create(ctx, suscripcion); err != nil {
+	if plan.HasTrial() {
+		eligible, err := s.isTrialEligible(ctx, userID, planID)
+		if err != nil {
+			return nil, err
+		}
+		// Con prueba el primer periodo es la prueba y el pagado se calcula al
+		// terminarla, así que una fecha_fin explícita no tendría a qué aplicarse
+		if eligible && req.FechaFin != "" {
+			return nil, ErrFechaFinConPrueba
+		}
+		if eligible {
+			suscripcion.Estado = entity.EstadoSuscripcionEnPrueba
+			suscripcion.UsoPrueba = true
+			suscripcion.FinPrueba = fechaInicio.AddDate(0, 0, plan.DiasPrueba)
+			suscripcion.FechaFin = suscripcion.FinPrueba
+		}
+	}
+
+	suscripcion.Transiciones = []entity.TransicionEstado{{
+		Hasta: suscripcion.Estado,
+		Fecha: suscripcion.CreadoEn,
+		Actor: actor.FromContext(ctx),
+	}}
+
+	var canje *entity.CanjeCupon
+	if req.Cupon != "" {
+		cupon, err := comprobarCupon(ctx, s.cuponRepo, s.canjeRepo, req.Cupon, userID, planID, moneda, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		suscripcion.ID = primitive.NewObjectID()
+		if canje, err = s.canjearCupon(ctx, cupon, suscripcion); err != nil {
+			return nil, err
+		}
+	}
+
+	if !suscripcion.IsTrial() {
+		suscripcion.Impuesto, err = calcularImpuesto(ctx, s.tasaRepo, usuario, montoNeto(precio, suscripcion.Descuento), plan.ImpuestoIncluido)
+		if err != nil {
+			if canje != nil {
+				return nil, errors.Join(err, s.revertirCanje(ctx, canje))
+			}
+			return nil, err
+		}
+	}
+
+	despues := map[string]interface{}{
+		"estado":       suscripcion.Estado,
+		"plan_id":      suscripcion.PlanID,
+		"plan_version": suscripcion.PlanVersion,
+		"fecha_inicio": suscripcion.FechaInicio,
+		"fecha_fin":    suscripcion.FechaFin,
+		"moneda":       suscripcion.Moneda,
+		"precio":       suscripcion.Precio,
+		"cantidad":     suscripcion.Cantidad,
+		"auto_renovar": suscripcion.AutoRenovar,
+	}
+	if suscripcion.Descuento != nil {
+		despues["cupon"] = suscripcion.Descuento.Codigo
+	}
+	if err := s.crearConEvento(ctx, suscripcion, despues); err != nil {
+		if canje != nil {
+			return nil, errors.Join(err, s.revertirCanje(ctx, canje))
+		}
 		return nil, err
 	}
 
-	dtoResult := s.entityToDTO(suscripcion)
+	// Durante la prueba no se cobra; la primera factura se genera al terminarla.
+	// Fuera de ella la suscripción queda pendiente hasta que se confirme el pago.
+	var (
+		factura *entity.Factura
+		cobro   *payment.Cobro
+	)
+	if !suscripcion.IsTrial() {
+		factura, cobro, err = s.facturarPeriodo(ctx, suscripcion, plan, suscripcion.Precio, nil, suscripcion.Descuento, suscripcion.Impuesto, entity.MotivoFacturaAlta, suscripcion.FechaInicio, suscripcion.FechaFin)
+		if err != nil {
+			return nil, errors.Join(err, s.rechazarPago(ctx, suscripcion, factura))
+		}
+
+		// Un cobro que la pasarela no aprobó en el momento se confirma por webhook;
+		// mientras tanto el usuario paga en URLPago
+		if cobro == nil || cobro.Estado == payment.EstadoCobroPagado {
+			if err := s.confirmarPago(ctx, suscripcion, factura); err != nil {
+				return nil, err
+			}
+
+			if suscripcion, err = s.suscripcionRepo.GetByID(ctx, suscripcion.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	dtoResult := suscripcionToDTO(suscripcion)
 	dtoResult.Usuario = &dto.UsuarioDTO{
 		ID:       usuario.ID.Hex(),
 		Nombre:   usuario.Nombre,
@@ -100,13 +284,19 @@ func (s *suscripcionService) CreateSuscripcion(ctx context.Context, req *dto.Cre
 		Estado:   usuario.Estado,
 		CreadoEn: usuario.CreadoEn,
 	}
-	dtoResult.Plan = &dto.PlanSuscripcionDTO{
-		ID:          plan.ID.Hex(),
-		Nombre:      plan.Nombre,
-		Descripcion: plan.Descripcion,
-		Precio:      plan.Precio,
-		Activo:      plan.Activo,
-		CreadoEn:    plan.CreadoEn,
+	dtoResult.Plan = planToDTO(plan)
+
+	if factura != nil {
+		dtoResult.Pago = &dto.PagoDTO{
+			FacturaID: factura.ID.Hex(),
+			Estado:    factura.Estado,
+			Monto:     factura.Total,
+			Moneda:    factura.Moneda,
+		}
+		if cobro != nil {
+			dtoResult.Pago.CobroID = cobro.ID
+			dtoResult.Pago.URLPago = cobro.URLPago
+		}
 	}
 
 	return dtoResult, nil
@@ -125,7 +315,7 @@ func (s *suscripcionService) GetAllSuscripciones(ctx context.Context, limit, off
 
 	var dtos []*dto.SuscripcionDTO
 	for _, suscripcion := range suscripciones {
-		dtos = append(dtos, s.entityToDTO(suscripcion))
+		dtos = append(dtos, suscripcionToDTO(suscripcion))
 	}
 
 	return dtos, total, nil
@@ -142,7 +332,7 @@ func (s *suscripcionService) GetSuscripcionByID(ctx context.Context, id string)
 		return nil, err
 	}
 
-	return s.entityToDTO(suscripcion), nil
+	return suscripcionToDTO(suscripcion), nil
 }
 
 func (s *suscripcionService) GetSuscripcionesByUser(ctx context.Context, userID string, limit, offset int) ([]*dto.SuscripcionDTO, error) {
@@ -158,7 +348,7 @@ func (s *suscripcionService) GetSuscripcionesByUser(ctx context.Context, userID
 
 	var dtos []*dto.SuscripcionDTO
 	for _, suscripcion := range suscripciones {
-		dtos = append(dtos, s.entityToDTO(suscripcion))
+		dtos = append(dtos, suscripcionToDTO(suscripcion))
 	}
 
 	return dtos, nil
@@ -168,50 +358,250 @@ func (s *suscripcionService) GetMySuscripciones(ctx context.Context, userID stri
 	return s.GetSuscripcionesByUser(ctx, userID, limit, offset)
 }
 
+// UpdateSuscripcion es la edición manual de fecha_fin o del estado, reservada
+// a los administradores.
 func (s *suscripcionService) UpdateSuscripcion(ctx context.Context, id string, req *dto.UpdateSuscripcionRequest) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.New("ID de suscripción inválido")
 	}
 
+	suscripcion, err := s.suscripcionRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
 	updates := make(map[string]interface{})
 
+	fechaFin := suscripcion.FechaFin
 	if req.FechaFin != nil {
-		if parsedTime, err := time.Parse("2006-01-02", *req.FechaFin); err == nil {
-			updates["fecha_fin"] = parsedTime
-		} else {
-			return errors.New("formato de fecha inválido (use YYYY-MM-DD)")
+		if fechaFin, err = parseFecha(*req.FechaFin); err != nil {
+			return err
 		}
+		if !fechaFin.After(suscripcion.FechaInicio) {
+			return ErrRangoFechas
+		}
+		updates["fecha_fin"] = fechaFin
 	}
 
-	if req.Estado != nil {
-		if *req.Estado != entity.EstadoSuscripcionActiva &&
-			*req.Estado != entity.EstadoSuscripcionVencida &&
-			*req.Estado != entity.EstadoSuscripcionCancelada {
-			return errors.New("estado inválido")
+	if req.Estado == nil || *req.Estado == suscripcion.Estado {
+		if len(updates) == 0 {
+			return errors.New("no hay campos para actualizar")
 		}
-		updates["estado"] = *req.Estado
+		expected := map[string]interface{}{"estado": suscripcion.Estado}
+		return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionEdicionManual, func(evento *entity.EventoSuscripcion) error {
+			return s.suscripcionRepo.UpdateIfMatch(ctx, objectID, expected, updates, evento)
+		}, updates)
 	}
 
-	if len(updates) == 0 {
-		return errors.New("no hay campos para actualizar")
+	hasta := *req.Estado
+	switch hasta {
+	case entity.EstadoSuscripcionActiva:
+		if !fechaFin.After(time.Now()) {
+			return ErrFechaFinPasada
+		}
+
+		// Reactivar no puede dejar al usuario con dos suscripciones vigentes
+		activeSuscripcion, err := s.suscripcionRepo.GetActiveSuscripcionByUserID(ctx, suscripcion.UsuarioID)
+		if err != nil {
+			return err
+		}
+		if activeSuscripcion != nil && activeSuscripcion.ID != suscripcion.ID {
+			return ErrSuscripcionActivaExistente
+		}
+	case entity.EstadoSuscripcionCancelada:
+		updates["cancelada_en"] = time.Now()
 	}
 
-	return s.suscripcionRepo.Update(ctx, objectID, updates)
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionEdicionManual, func(evento *entity.EventoSuscripcion) error {
+		return s.transicionar(ctx, suscripcion, hasta, nil, updates, evento)
+	}, updates)
 }
 
-func (s *suscripcionService) CancelSuscripcion(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+// CancelSuscripcion cancela de inmediato (fin del acceso ahora) o programa la
+// cancelación para fecha_fin, dejando al usuario usar el periodo ya pagado. La
+// cancelación inmediata deja como crédito a favor la parte no usada. Solo el
+// dueño o un administrador pueden cancelar.
+func (s *suscripcionService) CancelSuscripcion(ctx context.Context, id, userID string, esAdmin bool, req *dto.CancelarSuscripcionRequest) error {
+	suscripcion, err := s.getSuscripcionAutorizada(ctx, id, userID, esAdmin)
 	if err != nil {
-		return errors.New("ID de suscripción inválido")
+		return err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"cancelada_en":           now,
+		"motivo_cancelacion":     strings.TrimSpace(req.Motivo),
+		"comentario_cancelacion": strings.TrimSpace(req.Comentario),
+	}
+
+	if req.Modo != entity.ModoCancelacionFinPeriodo {
+		credito, err := s.creditoCancelacion(ctx, suscripcion, now)
+		if err != nil {
+			return err
+		}
+
+		updates["fecha_fin"] = now
+		updates["cancelar_al_final"] = false
+		updates["auto_renovar"] = false
+		err = s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionCancelada, func(evento *entity.EventoSuscripcion) error {
+			return s.transicionar(ctx, suscripcion, entity.EstadoSuscripcionCancelada, nil, updates, evento)
+		}, updates)
+		if err != nil || credito == nil {
+			return err
+		}
+
+		return registrarCredito(ctx, s.creditoRepo, credito)
+	}
+
+	if !suscripcion.IsActive() {
+		return errors.New("la suscripción no está activa")
+	}
+	if suscripcion.CancelarAlFinal {
+		return errors.New("la suscripción ya tiene una cancelación programada")
+	}
+
+	expected := map[string]interface{}{
+		"estado":    suscripcion.Estado,
+		"fecha_fin": suscripcion.FechaFin,
+	}
+	updates["cancelar_al_final"] = true
+	updates["auto_renovar"] = false
+	updates["auto_renovar_previo"] = suscripcion.AutoRenovar
+	updates["plan_pendiente_id"] = nil
+
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionCancelacionProgramada, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	}, updates)
+}
+
+// creditoCancelacion calcula lo que se le debe al usuario al cancelar de
+// inmediato: la parte no usada de la factura pagada del periodo en curso y, si
+// ya se renovó por adelantado, la del siguiente, cada una prorrateada por su
+// propio periodo. Los cargos sueltos de asientos o complementos no se devuelven.
+func (s *suscripcionService) creditoCancelacion(ctx context.Context, suscripcion *entity.Suscripcion, now time.Time) (*entity.MovimientoCredito, error) {
+	if suscripcion.Estado != entity.EstadoSuscripcionActiva || !suscripcion.FechaFin.After(now) {
+		return nil, nil
+	}
+
+	filters := map[string]interface{}{
+		"suscripcion_id": suscripcion.ID,
+		"estado":         entity.EstadoFacturaPagada,
+		"motivo":         map[string]interface{}{"$in": []string{entity.MotivoFacturaAlta, entity.MotivoFacturaRenovacion}},
+		"periodo_fin":    map[string]interface{}{"$gt": now},
 	}
+	facturas, err := s.facturaRepo.GetAll(ctx, filters, 2, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var monto int64
+	for _, factura := range facturas {
+		desde := factura.PeriodoInicio
+		if now.After(desde) {
+			desde = now
+		}
+		pagado := factura.Total + factura.CreditoAplicado
+		monto += prorratear(pagado, fraccion(factura.PeriodoFin.Sub(desde), factura.PeriodoFin.Sub(factura.PeriodoInicio)))
+	}
+	if monto <= 0 {
+		return nil, nil
+	}
+
+	return &entity.MovimientoCredito{
+		UsuarioID:     suscripcion.UsuarioID,
+		Moneda:        facturas[0].Moneda,
+		Monto:         monto,
+		Motivo:        entity.MotivoCreditoCancelacion,
+		SuscripcionID: &suscripcion.ID,
+	}, nil
+}
 
+// DeshacerCancelacion revierte una cancelación programada mientras el periodo
+// pagado no haya terminado. La renovación automática vuelve a como estaba
+// antes de programarla.
+func (s *suscripcionService) DeshacerCancelacion(ctx context.Context, id, userID string) error {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !suscripcion.IsCancellationScheduled() {
+		return errors.New("la suscripción no tiene una cancelación programada")
+	}
+	if suscripcion.AutoRenovarPrevio == nil {
+		return errors.New("la cancelación programada no guardó la renovación previa")
+	}
+
+	expected := map[string]interface{}{
+		"estado":              suscripcion.Estado,
+		"fecha_fin":           suscripcion.FechaFin,
+		"cancelar_al_final":   true,
+		"auto_renovar_previo": *suscripcion.AutoRenovarPrevio,
+	}
 	updates := map[string]interface{}{
-		"estado":    entity.EstadoSuscripcionCancelada,
-		"fecha_fin": time.Now(),
+		"cancelar_al_final":      false,
+		"auto_renovar":           *suscripcion.AutoRenovarPrevio,
+		"auto_renovar_previo":    nil,
+		"cancelada_en":           time.Time{},
+		"motivo_cancelacion":     "",
+		"comentario_cancelacion": "",
 	}
 
-	return s.suscripcionRepo.Update(ctx, objectID, updates)
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionCancelacionDeshecha, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	}, updates)
+}
+
+func (s *suscripcionService) SetAutoRenovar(ctx context.Context, id, userID string, autoRenovar bool) error {
+	suscripcion, err := s.getOwnedSuscripcion(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !suscripcion.IsActive() {
+		return errors.New("la suscripción no está activa")
+	}
+	if autoRenovar && suscripcion.CancelarAlFinal {
+		return errors.New("la suscripción ya tiene una cancelación programada")
+	}
+
+	updates := map[string]interface{}{
+		"auto_renovar": autoRenovar,
+	}
+
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionAutoRenovacion, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.Update(ctx, suscripcion.ID, updates, evento)
+	}, updates)
+}
+
+// GetRenovaciones devuelve las renovaciones de la suscripción. Solo el dueño o
+// un administrador pueden verlas.
+func (s *suscripcionService) GetRenovaciones(ctx context.Context, id, userID string, esAdmin bool, limit, offset int) ([]*dto.RenovacionDTO, error) {
+	suscripcion, err := s.getSuscripcionAutorizada(ctx, id, userID, esAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	renovaciones, err := s.renovacionRepo.GetBySuscripcionID(ctx, suscripcion.ID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var dtos []*dto.RenovacionDTO
+	for _, renovacion := range renovaciones {
+		dtos = append(dtos, &dto.RenovacionDTO{
+			ID:               renovacion.ID.Hex(),
+			SuscripcionID:    renovacion.SuscripcionID.Hex(),
+			PlanAnteriorID:   renovacion.PlanAnteriorID.Hex(),
+			PlanID:           renovacion.PlanID.Hex(),
+			FechaFinAnterior: renovacion.FechaFinAnterior,
+			FechaFinNueva:    renovacion.FechaFinNueva,
+			CreadoEn:         renovacion.CreadoEn,
+		})
+	}
+
+	return dtos, nil
 }
 
 func (s *suscripcionService) GetSuscripcionesWithDetails(ctx context.Context, limit, offset int) ([]map[string]interface{}, int64, error) {
@@ -228,14 +618,474 @@ func (s *suscripcionService) GetSuscripcionesWithDetails(ctx context.Context, li
 	return results, total, nil
 }
 
-func (s *suscripcionService) entityToDTO(suscripcion *entity.Suscripcion) *dto.SuscripcionDTO {
-	return &dto.SuscripcionDTO{
-		ID:          suscripcion.ID.Hex(),
-		UsuarioID:   suscripcion.UsuarioID.Hex(),
-		PlanID:      suscripcion.PlanID.Hex(),
-		FechaInicio: suscripcion.FechaInicio,
-		FechaFin:    suscripcion.FechaFin,
-		Estado:      suscripcion.Estado,
-		CreadoEn:    suscripcion.CreadoEn,
+// ExpireSuscripciones marca como vencidas, por lotes, las suscripciones activas
+// cuya fecha_fin ya pasó; las que tenían cancelación programada quedan
+// canceladas. Es idempotente, por lo que puede ejecutarse desde cualquier
+// réplica o manualmente. Un error en una suscripción no detiene al resto.
+func (s *suscripcionService) ExpireSuscripciones(ctx context.Context) (int64, error) {
+	now := time.Now()
+	batchSize := s.cfg.Scheduler.ExpiryBatchSize
+
+	var (
+		total   int64
+		errs    []error
+		afterID primitive.ObjectID
+	)
+	for {
+		suscripciones, err := s.suscripcionRepo.GetExpired(ctx, now, afterID, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, suscripcion := range suscripciones {
+			afterID = suscripcion.ID
+
+			if err := s.vencerSuscripcion(ctx, suscripcion); err != nil {
+				if !errors.Is(err, repositories.ErrSuscripcionModificada) {
+					errs = append(errs, fmt.Errorf("suscripción %s: %w", suscripcion.ID.Hex(), err))
+				}
+				continue
+			}
+			total++
+		}
+
+		if len(suscripciones) < batchSize {
+			return total, errors.Join(errs...)
+		}
+	}
+}
+
+// vencerSuscripcion cierra una suscripción cuyo periodo terminó. Si entre la
+// búsqueda y la actualización cambió su fecha_fin o su cancelación programada,
+// no se toca.
+func (s *suscripcionService) vencerSuscripcion(ctx context.Context, suscripcion *entity.Suscripcion) error {
+	hasta, tipo := entity.EstadoSuscripcionVencida, entity.EventoSuscripcionVencida
+	if suscripcion.CancelarAlFinal {
+		hasta, tipo = entity.EstadoSuscripcionCancelada, entity.EventoSuscripcionCancelada
+	}
+
+	expected := map[string]interface{}{
+		"fecha_fin":         suscripcion.FechaFin,
+		"cancelar_al_final": suscripcion.CancelarAlFinal,
+	}
+	updates := map[string]interface{}{}
+	return s.aplicarConEvento(ctx, suscripcion, tipo, func(evento *entity.EventoSuscripcion) error {
+		return s.transicionar(ctx, suscripcion, hasta, expected, updates, evento)
+	}, updates)
+}
+
+// RenewSuscripciones extiende las suscripciones con renovación automática que
+// vencen dentro de la ventana configurada. Un error en una suscripción no
+// detiene al resto; todos se devuelven juntos al final.
+func (s *suscripcionService) RenewSuscripciones(ctx context.Context) (int64, error) {
+	until := time.Now().Add(s.cfg.Scheduler.RenewalLeadTime)
+	batchSize := s.cfg.Scheduler.RenewalBatchSize
+
+	var (
+		total   int64
+		errs    []error
+		afterID primitive.ObjectID
+	)
+	for {
+		suscripciones, err := s.suscripcionRepo.GetRenewable(ctx, until, afterID, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, suscripcion := range suscripciones {
+			afterID = suscripcion.ID
+
+			renewed, err := s.renewSuscripcion(ctx, suscripcion)
+			if err != nil {
+				if !errors.Is(err, repositories.ErrSuscripcionModificada) {
+					errs = append(errs, fmt.Errorf("suscripción %s: %w", suscripcion.ID.Hex(), err))
+				}
+				continue
+			}
+			if renewed {
+				total++
+			}
+		}
+
+		if len(suscripciones) < batchSize {
+			return total, errors.Join(errs...)
+		}
+	}
+}
+
+func (s *suscripcionService) renewSuscripcion(ctx context.Context, suscripcion *entity.Suscripcion) (bool, error) {
+	expected := map[string]interface{}{
+		"estado":       entity.EstadoSuscripcionActiva,
+		"auto_renovar": true,
+		"fecha_fin":    suscripcion.FechaFin,
 	}
+
+	plan, precio, err := s.getRenewalPlan(ctx, suscripcion)
+	if err != nil {
+		return false, err
+	}
+
+	// Sin plan de respaldo la suscripción se deja vencer al final del periodo
+	if plan == nil {
+		updates := map[string]interface{}{"auto_renovar": false}
+		return false, s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionAutoRenovacion, func(evento *entity.EventoSuscripcion) error {
+			return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+		}, updates)
+	}
+
+	fechaFinNueva := plan.CalcularFechaFin(suscripcion.FechaFin, suscripcion.DiaAncla())
+
+	updates := map[string]interface{}{
+		"plan_id":           plan.ID,
+		"plan_version":      plan.VersionActual(),
+		"moneda":            suscripcion.GetMoneda(),
+		"precio":            precio,
+		"fecha_fin":         fechaFinNueva,
+		"inicio_periodo":    suscripcion.FechaFin,
+		"plan_pendiente_id": nil,
+	}
+	descuento := suscripcion.Descuento
+	if descuento != nil {
+		descuento = avanzarDescuento(descuento)
+		updates["descuento"] = descuento
+	}
+
+	complementos, err := s.complementosRenovados(ctx, suscripcion, plan)
+	if err != nil {
+		return false, err
+	}
+	if len(suscripcion.Complementos) > 0 {
+		updates["complementos"] = complementos
+	}
+
+	impuesto, err := s.calcularImpuestoPeriodo(ctx, suscripcion, plan, precio, complementos, descuento)
+	if err != nil {
+		return false, err
+	}
+	updates["impuesto"] = impuesto
+
+	err = s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionRenovada, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	}, updates)
+	if err != nil {
+		return false, err
+	}
+
+	renovacion := &entity.Renovacion{
+		SuscripcionID:    suscripcion.ID,
+		UsuarioID:        suscripcion.UsuarioID,
+		PlanAnteriorID:   suscripcion.PlanID,
+		PlanID:           plan.ID,
+		FechaFinAnterior: suscripcion.FechaFin,
+		FechaFinNueva:    fechaFinNueva,
+	}
+
+	if err := s.renovacionRepo.Create(ctx, renovacion); err != nil {
+		return true, err
+	}
+
+	_, _, err = s.facturarPeriodo(ctx, suscripcion, plan, precio, complementos, descuento, impuesto, entity.MotivoFacturaRenovacion, suscripcion.FechaFin, fechaFinNueva)
+	return true, err
+}
+
+// ProcessTrials resuelve las pruebas gratuitas terminadas: pasan a activa con
+// su primer periodo pagado si tienen renovación automática, o vencen si no.
+func (s *suscripcionService) ProcessTrials(ctx context.Context) (int64, error) {
+	now := time.Now()
+	batchSize := s.cfg.Scheduler.RenewalBatchSize
+
+	var (
+		total   int64
+		errs    []error
+		afterID primitive.ObjectID
+	)
+	for {
+		suscripciones, err := s.suscripcionRepo.GetEndedTrials(ctx, now, afterID, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, suscripcion := range suscripciones {
+			afterID = suscripcion.ID
+
+			if err := s.endTrial(ctx, suscripcion); err != nil {
+				if !errors.Is(err, repositories.ErrSuscripcionModificada) {
+					errs = append(errs, fmt.Errorf("suscripción %s: %w", suscripcion.ID.Hex(), err))
+				}
+				continue
+			}
+			total++
+		}
+
+		if len(suscripciones) < batchSize {
+			return total, errors.Join(errs...)
+		}
+	}
+}
+
+func (s *suscripcionService) endTrial(ctx context.Context, suscripcion *entity.Suscripcion) error {
+	expected := map[string]interface{}{
+		"fin_prueba": suscripcion.FinPrueba,
+	}
+
+	estadoFinal := entity.EstadoSuscripcionVencida
+	if suscripcion.CancelarAlFinal {
+		estadoFinal = entity.EstadoSuscripcionCancelada
+	}
+
+	vencer := func() error {
+		updates := map[string]interface{}{"estado": estadoFinal}
+		return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionPruebaFinalizada, func(evento *entity.EventoSuscripcion) error {
+			return s.transicionar(ctx, suscripcion, estadoFinal, expected, updates, evento)
+		}, updates)
+	}
+
+	if !suscripcion.AutoRenovar {
+		return vencer()
+	}
+
+	plan, precio, err := s.getRenewalPlan(ctx, suscripcion)
+	if err != nil {
+		return err
+	}
+	if plan == nil {
+		return vencer()
+	}
+
+	fechaFin := plan.CalcularFechaFin(suscripcion.FinPrueba, suscripcion.DiaAncla())
+
+	complementos, err := s.complementosRenovados(ctx, suscripcion, plan)
+	if err != nil {
+		return err
+	}
+
+	impuesto, err := s.calcularImpuestoPeriodo(ctx, suscripcion, plan, precio, complementos, suscripcion.Descuento)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"plan_id":           plan.ID,
+		"plan_version":      plan.VersionActual(),
+		"moneda":            suscripcion.GetMoneda(),
+		"precio":            precio,
+		"impuesto":          impuesto,
+		"fecha_fin":         fechaFin,
+		"inicio_periodo":    suscripcion.FinPrueba,
+		"plan_pendiente_id": nil,
+	}
+	if len(suscripcion.Complementos) > 0 {
+		updates["complementos"] = complementos
+	}
+
+	err = s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionPruebaFinalizada, func(evento *entity.EventoSuscripcion) error {
+		return s.transicionar(ctx, suscripcion, entity.EstadoSuscripcionActiva, expected, updates, evento)
+	}, updates)
+	if err != nil {
+		return err
+	}
+
+	renovacion := &entity.Renovacion{
+		SuscripcionID:    suscripcion.ID,
+		UsuarioID:        suscripcion.UsuarioID,
+		PlanAnteriorID:   suscripcion.PlanID,
+		PlanID:           plan.ID,
+		FechaFinAnterior: suscripcion.FechaFin,
+		FechaFinNueva:    fechaFin,
+	}
+
+	if err := s.renovacionRepo.Create(ctx, renovacion); err != nil {
+		return err
+	}
+
+	// El primer periodo pagado se factura como un alta
+	_, _, err = s.facturarPeriodo(ctx, suscripcion, plan, precio, complementos, suscripcion.Descuento, impuesto, entity.MotivoFacturaAlta, suscripcion.FinPrueba, fechaFin)
+	return err
+}
+
+func (s *suscripcionService) isTrialEligible(ctx context.Context, userID, planID primitive.ObjectID) (bool, error) {
+	var scope *primitive.ObjectID
+	if s.cfg.Suscripciones.TrialScope != config.TrialScopeGlobal {
+		scope = &planID
+	}
+
+	used, err := s.suscripcionRepo.HasUsedTrial(ctx, userID, scope)
+	if err != nil {
+		return false, err
+	}
+
+	return !used, nil
+}
+
+// getRenewalPlan devuelve el plan con el que se debe renovar la suscripción y
+// su precio en la moneda de la suscripción por todos sus asientos: el cambio
+// de plan pendiente o el suyo si siguen activos y tienen precio en esa moneda,
+// el de respaldo si no, o nil si ninguno aplica. Un plan pendiente o de
+// respaldo además tiene que admitir la cantidad de asientos; el mismo plan la
+// conserva aunque sus límites hayan cambiado.
+func (s *suscripcionService) getRenewalPlan(ctx context.Context, suscripcion *entity.Suscripcion) (*entity.PlanSuscripcion, int64, error) {
+	plan, precio, err := s.getRenewalPlanUnitario(ctx, suscripcion)
+	return plan, precio * int64(suscripcion.GetCantidad()), err
+}
+
+// getRenewalPlanUnitario es getRenewalPlan con el precio de un solo asiento.
+func (s *suscripcionService) getRenewalPlanUnitario(ctx context.Context, suscripcion *entity.Suscripcion) (*entity.PlanSuscripcion, int64, error) {
+	moneda := suscripcion.GetMoneda()
+
+	if suscripcion.PlanPendienteID != nil {
+		pendiente, err := s.planRepo.GetByID(ctx, *suscripcion.PlanPendienteID)
+		if err == nil && pendiente.Activo && pendiente.AdmiteAsientos(suscripcion.GetCantidad()) {
+			if precio, ok := pendiente.PrecioEn(moneda); ok {
+				return pendiente, precio, nil
+			}
+		}
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, suscripcion.PlanID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if plan.Activo {
+		// Con grandfathering permanente se renueva con los términos de la
+		// versión en la que quedó la suscripción, no con los vigentes
+		if s.cfg.Suscripciones.Grandfathering == config.GrandfatheringPermanente {
+			anterior, err := planEnVersion(ctx, s.versionRepo, plan, suscripcion.GetPlanVersion())
+			if err != nil && !errors.Is(err, ErrVersionPlanInexistente) {
+				return nil, 0, err
+			}
+			if err == nil {
+				if precio, ok := anterior.PrecioEn(moneda); ok {
+					return anterior, precio, nil
+				}
+			}
+		}
+
+		if precio, ok := plan.PrecioEn(moneda); ok {
+			return plan, precio, nil
+		}
+	}
+
+	return s.getFallbackPlan(ctx, moneda, suscripcion.GetCantidad())
+}
+
+func (s *suscripcionService) getFallbackPlan(ctx context.Context, moneda string, cantidad int) (*entity.PlanSuscripcion, int64, error) {
+	if s.cfg.Suscripciones.FallbackPlanID == "" {
+		return nil, 0, nil
+	}
+
+	planID, err := primitive.ObjectIDFromHex(s.cfg.Suscripciones.FallbackPlanID)
+	if err != nil {
+		return nil, 0, errors.New("FALLBACK_PLAN_ID inválido")
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !plan.Activo || !plan.AdmiteAsientos(cantidad) {
+		return nil, 0, nil
+	}
+
+	precio, ok := plan.PrecioEn(moneda)
+	if !ok {
+		return nil, 0, nil
+	}
+
+	return plan, precio, nil
+}
+
+func (s *suscripcionService) getOwnedSuscripcion(ctx context.Context, id, userID string) (*entity.Suscripcion, error) {
+	suscripcion, err := s.getSuscripcion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if suscripcion.UsuarioID.Hex() != userID {
+		return nil, errors.New("no autorizado para modificar esta suscripción")
+	}
+
+	return suscripcion, nil
+}
+
+// getSuscripcionAutorizada es getOwnedSuscripcion, salvo que un administrador
+// puede actuar sobre cualquier suscripción.
+func (s *suscripcionService) getSuscripcionAutorizada(ctx context.Context, id, userID string, esAdmin bool) (*entity.Suscripcion, error) {
+	if esAdmin {
+		return s.getSuscripcion(ctx, id)
+	}
+	return s.getOwnedSuscripcion(ctx, id, userID)
+}
+
+func (s *suscripcionService) getSuscripcion(ctx context.Context, id string) (*entity.Suscripcion, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de suscripción inválido")
+	}
+
+	return s.suscripcionRepo.GetByID(ctx, objectID)
+}
+
+func suscripcionToDTO(suscripcion *entity.Suscripcion) *dto.SuscripcionDTO {
+	result := &dto.SuscripcionDTO{
+		ID:                suscripcion.ID.Hex(),
+		UsuarioID:         suscripcion.UsuarioID.Hex(),
+		PlanID:            suscripcion.PlanID.Hex(),
+		PlanVersion:       suscripcion.GetPlanVersion(),
+		FechaInicio:       suscripcion.FechaInicio,
+		FechaFin:          suscripcion.FechaFin,
+		Estado:            suscripcion.Estado,
+		Moneda:            suscripcion.GetMoneda(),
+		Precio:            suscripcion.Precio,
+		Cantidad:          suscripcion.GetCantidad(),
+		Complementos:      complementosToDTO(suscripcion.Complementos),
+		Impuesto:          desgloseImpuestoToDTO(suscripcion.Impuesto),
+		AutoRenovar:       suscripcion.AutoRenovar,
+		CancelarAlFinal:   suscripcion.CancelarAlFinal,
+		MotivoCancelacion: suscripcion.MotivoCancelacion,
+		CreadoEn:          suscripcion.CreadoEn,
+	}
+
+	for _, transicion := range suscripcion.Transiciones {
+		result.Transiciones = append(result.Transiciones, dto.TransicionEstadoDTO{
+			Desde: transicion.Desde,
+			Hasta: transicion.Hasta,
+			Fecha: transicion.Fecha,
+			Actor: transicion.Actor,
+		})
+	}
+
+	if suscripcion.PlanPendienteID != nil {
+		result.PlanPendienteID = suscripcion.PlanPendienteID.Hex()
+	}
+
+	if descuento := suscripcion.Descuento; descuento != nil {
+		result.Descuento = &dto.DescuentoDTO{
+			CuponID:           descuento.CuponID.Hex(),
+			Codigo:            descuento.Codigo,
+			Tipo:              descuento.Tipo,
+			Valor:             descuento.Valor,
+			Moneda:            descuento.Moneda,
+			Duracion:          descuento.Duracion,
+			PeriodosRestantes: descuento.PeriodosRestantes,
+		}
+	}
+
+	if !suscripcion.CanceladaEn.IsZero() {
+		canceladaEn := suscripcion.CanceladaEn
+		result.CanceladaEn = &canceladaEn
+	}
+
+	if !suscripcion.PausadaDesde.IsZero() {
+		pausadaDesde, pausadaHasta := suscripcion.PausadaDesde, suscripcion.PausadaHasta
+		result.PausadaDesde = &pausadaDesde
+		result.PausadaHasta = &pausadaHasta
+	}
+
+	if !suscripcion.FinPrueba.IsZero() {
+		finPrueba := suscripcion.FinPrueba
+		result.FinPrueba = &finPrueba
+	}
+
+	return result
 }
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/config"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/payment"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type altaFixture struct {
+	service       *suscripcionService
+	suscripciones *fakeSuscripcionRepo
+	facturas      *fakeFacturaRepo
+	usuario       *entity.Usuario
+	plan          *entity.PlanSuscripcion
+}
+
+func newAltaFixture(diasPrueba int) *altaFixture {
+	f := &altaFixture{
+		suscripciones: newFakeSuscripcionRepo(),
+		facturas:      newFakeFacturaRepo(),
+		usuario:       &entity.Usuario{ID: primitive.NewObjectID(), Nombre: "Ana", Estado: true},
+		plan: &entity.PlanSuscripcion{
+			ID:         primitive.NewObjectID(),
+			Nombre:     "Pro",
+			Precios:    []entity.PrecioPlan{{Moneda: "USD", Monto: 1000}},
+			Intervalo:  entity.IntervaloMes,
+			DiasPrueba: diasPrueba,
+			Activo:     true,
+		},
+	}
+
+	cfg := &config.Config{}
+	cfg.Suscripciones.MonedaDefault = "USD"
+
+	f.service = &suscripcionService{
+		suscripcionRepo: f.suscripciones,
+		userRepo:        newFakeUsuarioRepo(f.usuario),
+		planRepo:        newFakePlanRepo(f.plan),
+		creditoRepo:     &fakeCreditoRepo{},
+		eventoRepo:      &fakeEventoSuscripcionRepo{},
+		facturaRepo:     f.facturas,
+		contadorRepo:    newFakeContadorRepo(),
+		gateway:         payment.NewFakeGateway(false),
+		entitlements:    NewEntitlementsCache(time.Minute),
+		cfg:             cfg,
+	}
+	return f
+}
+
+func TestCreateSuscripcion_CobroPendienteQuedaSinActivar(t *testing.T) {
+	f := newAltaFixture(0)
+
+	result, err := f.service.CreateSuscripcion(context.Background(), &dto.CreateSuscripcionRequest{
+		UsuarioID: f.usuario.ID.Hex(),
+		PlanID:    f.plan.ID.Hex(),
+	})
+	if err != nil {
+		t.Fatalf("CreateSuscripcion: %v", err)
+	}
+
+	if result.Estado != entity.EstadoSuscripcionPendientePago {
+		t.Errorf("estado devuelto = %q, want %q", result.Estado, entity.EstadoSuscripcionPendientePago)
+	}
+	if result.Pago == nil || result.Pago.URLPago == "" {
+		t.Fatalf("pago = %+v, want una URL de pago", result.Pago)
+	}
+
+	id, _ := primitive.ObjectIDFromHex(result.ID)
+	suscripcion, err := f.suscripciones.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suscripcion.Estado != entity.EstadoSuscripcionPendientePago {
+		t.Errorf("estado guardado = %q, want %q", suscripcion.Estado, entity.EstadoSuscripcionPendientePago)
+	}
+
+	facturaID, _ := primitive.ObjectIDFromHex(result.Pago.FacturaID)
+	factura, err := f.facturas.GetByID(context.Background(), facturaID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if factura.Estado != entity.EstadoFacturaAbierta {
+		t.Errorf("estado de la factura = %q, want %q", factura.Estado, entity.EstadoFacturaAbierta)
+	}
+}
+
+func TestCreateSuscripcion_FechaFinConPrueba(t *testing.T) {
+	fechaFin := time.Now().AddDate(0, 2, 0).Format("2006-01-02")
+
+	tests := []struct {
+		name       string
+		diasPrueba int
+		fechaFin   string
+		wantErr    error
+		wantEstado string
+	}{
+		{"con prueba y sin fecha_fin", 14, "", nil, entity.EstadoSuscripcionEnPrueba},
+		{"con prueba y fecha_fin", 14, fechaFin, ErrFechaFinConPrueba, ""},
+		{"sin prueba y fecha_fin", 0, fechaFin, nil, entity.EstadoSuscripcionPendientePago},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAltaFixture(tt.diasPrueba)
+
+			result, err := f.service.CreateSuscripcion(context.Background(), &dto.CreateSuscripcionRequest{
+				UsuarioID: f.usuario.ID.Hex(),
+				PlanID:    f.plan.ID.Hex(),
+				FechaFin:  tt.fechaFin,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateSuscripcion() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.Estado != tt.wantEstado {
+				t.Errorf("estado = %q, want %q", result.Estado, tt.wantEstado)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type claveResumenMigracion struct {
+	version int
+	moneda  string
+}
+
+// MigrarVersionPlan mueve suscripciones del plan a otra versión. Los derechos
+// cambian de inmediato; el precio del periodo en curso ya se cobró, así que el
+// de la versión nueva se aplica desde la próxima renovación. Con grandfathering
+// "renovacion" toda suscripción pasa a la versión vigente al renovarse, de modo
+// que migrar a una versión anterior solo dura hasta entonces.
+func (s *suscripcionService) MigrarVersionPlan(ctx context.Context, planID string, req *dto.MigrarVersionPlanRequest) (*dto.MigracionVersionPlanDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(planID)
+	if err != nil {
+		return nil, errors.New("ID de plan inválido")
+	}
+
+	plan, err := s.planRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	destino := req.VersionDestino
+	if destino == 0 {
+		destino = plan.VersionActual()
+	}
+	if destino > plan.VersionActual() {
+		return nil, ErrVersionPlanInexistente
+	}
+
+	terminos, err := planEnVersion(ctx, s.versionRepo, plan, destino)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &dto.MigracionVersionPlanDTO{
+		PlanID:         plan.ID.Hex(),
+		VersionDestino: destino,
+		DryRun:         req.DryRun,
+		Resumen:        []*dto.ResumenMigracionDTO{},
+		Omitidas:       []*dto.OmisionMigracionDTO{},
+	}
+	resumen := make(map[claveResumenMigracion]*dto.ResumenMigracionDTO)
+	versiones := map[int]*entity.PlanSuscripcion{destino: terminos}
+
+	omitir := func(suscripcion *entity.Suscripcion, motivo string) {
+		reporte.Omitidas = append(reporte.Omitidas, &dto.OmisionMigracionDTO{
+			SuscripcionID: suscripcion.ID.Hex(),
+			Motivo:        motivo,
+		})
+	}
+
+	batchSize := s.cfg.Scheduler.RenewalBatchSize
+	var afterID primitive.ObjectID
+	for {
+		suscripciones, err := s.suscripcionRepo.GetVigentesByPlan(ctx, objectID, afterID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, suscripcion := range suscripciones {
+			afterID = suscripcion.ID
+
+			origen := suscripcion.GetPlanVersion()
+			if origen == destino || (req.VersionOrigen != nil && origen != *req.VersionOrigen) {
+				continue
+			}
+			reporte.Evaluadas++
+
+			moneda := suscripcion.GetMoneda()
+			precioNuevo, ok := terminos.PrecioEn(moneda)
+			if !ok {
+				omitir(suscripcion, fmt.Sprintf("%s: %s", ErrMonedaNoDisponible.Error(), moneda))
+				continue
+			}
+
+			anterior, ok := versiones[origen]
+			if !ok {
+				if anterior, err = planEnVersion(ctx, s.versionRepo, plan, origen); err != nil {
+					omitir(suscripcion, err.Error())
+					continue
+				}
+				versiones[origen] = anterior
+			}
+
+			if !req.DryRun {
+				if err := s.migrarVersion(ctx, suscripcion, destino); err != nil {
+					omitir(suscripcion, err.Error())
+					continue
+				}
+			}
+			reporte.Migradas++
+
+			clave := claveResumenMigracion{version: origen, moneda: moneda}
+			item, ok := resumen[clave]
+			if !ok {
+				precioActual, _ := anterior.PrecioEn(moneda)
+				item = &dto.ResumenMigracionDTO{
+					VersionOrigen: origen,
+					Moneda:        moneda,
+					PrecioActual:  precioActual,
+					PrecioNuevo:   precioNuevo,
+				}
+				resumen[clave] = item
+				reporte.Resumen = append(reporte.Resumen, item)
+			}
+			item.Suscripciones++
+		}
+
+		if len(suscripciones) < batchSize {
+			return reporte, nil
+		}
+	}
+}
+
+// migrarVersion exige el mismo plan y periodo que se leyó: si la suscripción se
+// renovó o cambió de plan mientras tanto, ya tomó una versión por su cuenta.
+func (s *suscripcionService) migrarVersion(ctx context.Context, suscripcion *entity.Suscripcion, destino int) error {
+	expected := map[string]interface{}{
+		"estado":    suscripcion.Estado,
+		"plan_id":   suscripcion.PlanID,
+		"fecha_fin": suscripcion.FechaFin,
+	}
+	updates := map[string]interface{}{"plan_version": destino}
+
+	return s.aplicarConEvento(ctx, suscripcion, entity.EventoSuscripcionVersionMigrada, func(evento *entity.EventoSuscripcion) error {
+		return s.suscripcionRepo.UpdateIfMatch(ctx, suscripcion.ID, expected, updates, evento)
+	}, updates)
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/config"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrCuotaExcedida                = repositories.ErrCuotaExcedida
+	ErrMetricaNoDisponible          = errors.New("el plan no incluye un límite para esa métrica")
+	ErrSuscripcionNoVigente         = errors.New("la suscripción no está vigente")
+	ErrClaveIdempotenciaRequerida   = errors.New("clave de idempotencia requerida")
+	ErrClaveIdempotenciaReutilizada = errors.New("la clave de idempotencia ya se usó con otros datos")
+	ErrEventoUsoEnProceso           = errors.New("el evento de uso todavía se está procesando")
+	ErrUsoFueraDePeriodo            = errors.New("el evento de uso no pertenece al periodo actual")
+)
+
+type usoService struct {
+	suscripcionRepo repositories.SuscripcionRepository
+	planRepo        repositories.PlanRepository
+	versionRepo     repositories.VersionPlanRepository
+	eventoUsoRepo   repositories.EventoUsoRepository
+	usoPeriodoRepo  repositories.UsoPeriodoRepository
+	cfg             *config.Config
+}
+
+func NewUsoService(
+	suscripcionRepo repositories.SuscripcionRepository,
+	planRepo repositories.PlanRepository,
+	versionRepo repositories.VersionPlanRepository,
+	eventoUsoRepo repositories.EventoUsoRepository,
+	usoPeriodoRepo repositories.UsoPeriodoRepository,
+	cfg *config.Config,
+) UsoService {
+	return &usoService{
+		suscripcionRepo: suscripcionRepo,
+		planRepo:        planRepo,
+		versionRepo:     versionRepo,
+		eventoUsoRepo:   eventoUsoRepo,
+		usoPeriodoRepo:  usoPeriodoRepo,
+		cfg:             cfg,
+	}
+}
+
+// RegistrarUso suma el consumo al periodo actual de la suscripción si entra en
+// la cuota del plan. Repetir la misma clave de idempotencia devuelve el evento
+// original sin volver a contarlo.
+func (s *usoService) RegistrarUso(ctx context.Context, userID string, esAdmin bool, req *dto.RegistrarUsoRequest) (*dto.EventoUsoDTO, error) {
+	if req.ClaveIdempotencia == "" {
+		return nil, ErrClaveIdempotenciaRequerida
+	}
+
+	suscripcion, plan, err := s.getSuscripcionVigente(ctx, req.SuscripcionID, userID, esAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	eventoID := entity.EventoUsoID(suscripcion.ID, req.ClaveIdempotencia)
+	if existente, err := s.eventoUsoRepo.GetByID(ctx, eventoID); err == nil {
+		// Una reserva vencida es de un proceso que se cayó antes de terminar:
+		// el mismo evento se vuelve a intentar
+		mismoEvento := existente.Metrica == req.Metrica && existente.Cantidad == req.Cantidad
+		if !mismoEvento || !existente.ReservaVencida(now) {
+			return s.repetirEvento(ctx, suscripcion, plan, existente, req)
+		}
+	}
+
+	limite, ok := plan.LimiteDe(req.Metrica)
+	if !ok {
+		return nil, ErrMetricaNoDisponible
+	}
+
+	inicio := suscripcion.InicioPeriodoActual()
+	ocurridoEn := now
+	if req.OcurridoEn != nil {
+		ocurridoEn = *req.OcurridoEn
+		if ocurridoEn.Before(inicio) || !ocurridoEn.Before(suscripcion.FechaFin) || ocurridoEn.After(now) {
+			return nil, ErrUsoFueraDePeriodo
+		}
+	}
+
+	evento := &entity.EventoUso{
+		ID:                eventoID,
+		SuscripcionID:     suscripcion.ID,
+		UsuarioID:         suscripcion.UsuarioID,
+		Metrica:           req.Metrica,
+		Cantidad:          req.Cantidad,
+		ClaveIdempotencia: req.ClaveIdempotencia,
+		PeriodoInicio:     inicio,
+		OcurridoEn:        ocurridoEn,
+		CreadoEn:          now,
+	}
+
+	reservado, err := s.eventoUsoRepo.Reservar(ctx, evento, s.cfg.Suscripciones.UsoReserva)
+	if err != nil {
+		return nil, err
+	}
+	if !reservado {
+		// Otra petición con la misma clave se adelantó entre la lectura y la reserva
+		existente, err := s.eventoUsoRepo.GetByID(ctx, eventoID)
+		if err != nil {
+			return nil, err
+		}
+		return s.repetirEvento(ctx, suscripcion, plan, existente, req)
+	}
+
+	uso, err := s.usoPeriodoRepo.Consumir(ctx, usoPeriodoDe(suscripcion, req.Metrica), req.Cantidad, limite)
+	if err != nil {
+		return nil, errors.Join(err, s.eventoUsoRepo.Liberar(ctx, eventoID))
+	}
+
+	if err := s.eventoUsoRepo.MarcarRegistrado(ctx, eventoID); err != nil {
+		return nil, errors.Join(err,
+			s.usoPeriodoRepo.Revertir(ctx, uso.ID, req.Cantidad),
+			s.eventoUsoRepo.Liberar(ctx, eventoID))
+	}
+
+	result := eventoUsoToDTO(evento)
+	result.Consumo = consumoToDTO(req.Metrica, uso.Consumido, limite, suscripcion)
+	return result, nil
+}
+
+// VerificarUso responde si la suscripción puede consumir cantidad unidades de
+// la métrica sin registrar nada. Es una foto: entre la verificación y el
+// registro otro consumo puede agotar la cuota.
+func (s *usoService) VerificarUso(ctx context.Context, userID string, esAdmin bool, req *dto.VerificarUsoRequest) (*dto.VerificacionUsoDTO, error) {
+	suscripcion, plan, err := s.getSuscripcionVigente(ctx, req.SuscripcionID, userID, esAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	limite, ok := plan.LimiteDe(req.Metrica)
+	if !ok {
+		return nil, ErrMetricaNoDisponible
+	}
+
+	consumido, err := s.consumido(ctx, suscripcion, req.Metrica)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.VerificacionUsoDTO{
+		Permitido:         limite == entity.LimiteIlimitado || consumido+req.Cantidad <= limite,
+		ConsumoMetricaDTO: *consumoToDTO(req.Metrica, consumido, limite, suscripcion),
+	}, nil
+}
+
+// GetUsoSuscripcion devuelve el consumo del periodo actual de cada límite del
+// plan, incluidos los que todavía no tienen consumo.
+func (s *usoService) GetUsoSuscripcion(ctx context.Context, id, userID string, esAdmin bool) (*dto.UsoSuscripcionDTO, error) {
+	suscripcion, err := s.getSuscripcion(ctx, id, userID, esAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := planDeSuscripcion(ctx, s.planRepo, s.versionRepo, suscripcion)
+	if err != nil {
+		return nil, err
+	}
+	plan = plan.ConComplementos(suscripcion.Complementos)
+
+	result := &dto.UsoSuscripcionDTO{
+		SuscripcionID: suscripcion.ID.Hex(),
+		PeriodoInicio: suscripcion.InicioPeriodoActual(),
+		PeriodoFin:    suscripcion.FechaFin,
+		Metricas:      []*dto.ConsumoMetricaDTO{},
+	}
+
+	for _, derecho := range plan.Derechos {
+		if derecho.Tipo != entity.TipoDerechoLimite {
+			continue
+		}
+
+		consumido, err := s.consumido(ctx, suscripcion, derecho.Clave)
+		if err != nil {
+			return nil, err
+		}
+		result.Metricas = append(result.Metricas, consumoToDTO(derecho.Clave, consumido, derecho.Limite, suscripcion))
+	}
+
+	return result, nil
+}
+
+// repetirEvento responde a un reintento. El evento se devuelve tal como quedó
+// aunque el plan o el periodo hayan cambiado desde entonces.
+func (s *usoService) repetirEvento(ctx context.Context, suscripcion *entity.Suscripcion, plan *entity.PlanSuscripcion, evento *entity.EventoUso, req *dto.RegistrarUsoRequest) (*dto.EventoUsoDTO, error) {
+	if evento.Metrica != req.Metrica || evento.Cantidad != req.Cantidad {
+		return nil, ErrClaveIdempotenciaReutilizada
+	}
+	if evento.Estado != entity.EstadoEventoUsoRegistrado {
+		return nil, ErrEventoUsoEnProceso
+	}
+
+	result := eventoUsoToDTO(evento)
+	result.Duplicado = true
+
+	if limite, ok := plan.LimiteDe(evento.Metrica); ok {
+		consumido, err := s.consumido(ctx, suscripcion, evento.Metrica)
+		if err != nil {
+			return nil, err
+		}
+		result.Consumo = consumoToDTO(evento.Metrica, consumido, limite, suscripcion)
+	}
+
+	return result, nil
+}
+
+func (s *usoService) consumido(ctx context.Context, suscripcion *entity.Suscripcion, metrica string) (int64, error) {
+	uso, err := s.usoPeriodoRepo.GetByID(ctx, usoPeriodoDe(suscripcion, metrica).ID)
+	if err != nil || uso == nil {
+		return 0, err
+	}
+	return uso.Consumido, nil
+}
+
+func (s *usoService) getSuscripcionVigente(ctx context.Context, id, userID string, esAdmin bool) (*entity.Suscripcion, *entity.PlanSuscripcion, error) {
+	suscripcion, err := s.getSuscripcion(ctx, id, userID, esAdmin)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !suscripcion.IsActive() {
+		return nil, nil, ErrSuscripcionNoVigente
+	}
+
+	plan, err := planDeSuscripcion(ctx, s.planRepo, s.versionRepo, suscripcion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Los límites de los complementos se suman a los del plan
+	return suscripcion, plan.ConComplementos(suscripcion.Complementos), nil
+}
+
+func (s *usoService) getSuscripcion(ctx context.Context, id, userID string, esAdmin bool) (*entity.Suscripcion, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de suscripción inválido")
+	}
+
+	suscripcion, err := s.suscripcionRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !esAdmin && suscripcion.UsuarioID.Hex() != userID {
+		return nil, errors.New("no autorizado para consultar esta suscripción")
+	}
+
+	return suscripcion, nil
+}
+
+// usoPeriodoDe arma el acumulado del periodo actual. El inicio del periodo es
+// parte del _id, así que al renovar la suscripción el consumo arranca de cero
+// sin tener que reiniciar nada.
+func usoPeriodoDe(suscripcion *entity.Suscripcion, metrica string) *entity.UsoPeriodo {
+	inicio := suscripcion.InicioPeriodoActual()
+	return &entity.UsoPeriodo{
+		ID:            entity.UsoPeriodoID(suscripcion.ID, metrica, inicio),
+		SuscripcionID: suscripcion.ID,
+		UsuarioID:     suscripcion.UsuarioID,
+		Metrica:       metrica,
+		PeriodoInicio: inicio,
+		PeriodoFin:    suscripcion.FechaFin,
+	}
+}
+
+func consumoToDTO(metrica string, consumido, limite int64, suscripcion *entity.Suscripcion) *dto.ConsumoMetricaDTO {
+	restante := int64(entity.LimiteIlimitado)
+	if limite != entity.LimiteIlimitado {
+		restante = max(limite-consumido, 0)
+	}
+
+	return &dto.ConsumoMetricaDTO{
+		Metrica:       metrica,
+		Consumido:     consumido,
+		Limite:        limite,
+		Restante:      restante,
+		PeriodoInicio: suscripcion.InicioPeriodoActual(),
+		PeriodoFin:    suscripcion.FechaFin,
+	}
+}
+
+func eventoUsoToDTO(evento *entity.EventoUso) *dto.EventoUsoDTO {
+	return &dto.EventoUsoDTO{
+		ID:                evento.ID,
+		SuscripcionID:     evento.SuscripcionID.Hex(),
+		Metrica:           evento.Metrica,
+		Cantidad:          evento.Cantidad,
+		ClaveIdempotencia: evento.ClaveIdempotencia,
+		PeriodoInicio:     evento.PeriodoInicio,
+		OcurridoEn:        evento.OcurridoEn,
+		CreadoEn:          evento.CreadoEn,
+	}
+}
@@ -16,14 +16,23 @@ import (
 )
 
 type usuarioService struct {
-	userRepo repositories.UsuarioRepository
-	cfg      *config.Config
+	userRepo        repositories.UsuarioRepository
+	suscripcionRepo repositories.SuscripcionRepository
+	eventoRepo      repositories.EventoSuscripcionRepository
+	cfg             *config.Config
 }
 
-func NewUsuarioService(userRepo repositories.UsuarioRepository, cfg *config.Config) UsuarioService {
+func NewUsuarioService(
+	userRepo repositories.UsuarioRepository,
+	suscripcionRepo repositories.SuscripcionRepository,
+	eventoRepo repositories.EventoSuscripcionRepository,
+	cfg *config.Config,
+) UsuarioService {
 	return &usuarioService{
-		userRepo: userRepo,
-		cfg:      cfg,
+		userRepo:        userRepo,
+		suscripcionRepo: suscripcionRepo,
+		eventoRepo:      eventoRepo,
+		cfg:             cfg,
 	}
 }
 
@@ -45,12 +54,13 @@ func (s *usuarioService) Register(ctx context.Context, req *dto.CreateUsuarioReq
 	}
 
 	usuario := &entity.Usuario{
-		Nombre:   req.Nombre,
-		Email:    req.Email,
-		Telefono: req.Telefono,
-		Password: string(hashedPassword),
-		Estado:   true,
-		EsAdmin:  req.EsAdmin, // Usar el valor del request
+		Nombre:            req.Nombre,
+		Email:             req.Email,
+		Telefono:          req.Telefono,
+		Password:          string(hashedPassword),
+		Estado:            true,
+		PaisFacturacion:   strings.ToUpper(req.PaisFacturacion),
+		RegionFacturacion: normalizarRegion(req.RegionFacturacion),
 	}
 
 	if err := s.userRepo.Create(ctx, usuario); err != nil {
@@ -101,6 +111,46 @@ func (s *usuarioService) GetProfile(ctx context.Context, userID string) (*dto.Us
 	return s.entityToDTO(usuario), nil
 }
 
+// ExportarDatos reúne todo lo que se guarda del usuario: su perfil, sus
+// suscripciones y el historial completo de eventos de cada una.
+func (s *usuarioService) ExportarDatos(ctx context.Context, userID string) (*dto.ExportacionUsuarioDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID de usuario inválido")
+	}
+
+	usuario, err := s.userRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limite 0 devuelve todos los documentos
+	suscripciones, err := s.suscripcionRepo.GetByUserID(ctx, objectID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	eventos, err := s.eventoRepo.GetByUsuarioID(ctx, objectID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	exportacion := &dto.ExportacionUsuarioDTO{
+		Usuario:       *s.entityToDTO(usuario),
+		Suscripciones: []*dto.SuscripcionDTO{},
+		Eventos:       []*dto.EventoSuscripcionDTO{},
+		GeneradoEn:    time.Now(),
+	}
+	for _, suscripcion := range suscripciones {
+		exportacion.Suscripciones = append(exportacion.Suscripciones, suscripcionToDTO(suscripcion))
+	}
+	for _, evento := range eventos {
+		exportacion.Eventos = append(exportacion.Eventos, eventoSuscripcionToDTO(evento))
+	}
+
+	return exportacion, nil
+}
+
 func (s *usuarioService) GetAllUsers(ctx context.Context, limit, offset int) ([]*dto.UsuarioDTO, int64, error) {
 	usuarios, err := s.userRepo.GetAll(ctx, nil, limit, offset)
 	if err != nil {
@@ -152,6 +202,16 @@ func (s *usuarioService) UpdateUser(ctx context.Context, id string, req *dto.Upd
 		updates["telefono"] = *req.Telefono
 	}
 
+	// El cambio de país aplica desde el próximo cobro; las facturas emitidas
+	// conservan el impuesto con que se generaron
+	if req.PaisFacturacion != nil {
+		updates["pais_facturacion"] = strings.ToUpper(*req.PaisFacturacion)
+	}
+
+	if req.RegionFacturacion != nil {
+		updates["region_facturacion"] = normalizarRegion(*req.RegionFacturacion)
+	}
+
 	if req.Password != nil {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
 		if err != nil {
@@ -238,12 +298,14 @@ func (s *usuarioService) generateJWT(usuario *entity.Usuario) (string, error) {
 
 func (s *usuarioService) entityToDTO(usuario *entity.Usuario) *dto.UsuarioDTO {
 	return &dto.UsuarioDTO{
-		ID:       usuario.ID.Hex(),
-		Nombre:   usuario.Nombre,
-		Email:    usuario.Email,
-		Telefono: usuario.Telefono,
-		Estado:   usuario.Estado,
-		EsAdmin:  usuario.EsAdmin,
-		CreadoEn: usuario.CreadoEn,
+		ID:                usuario.ID.Hex(),
+		Nombre:            usuario.Nombre,
+		Email:             usuario.Email,
+		Telefono:          usuario.Telefono,
+		Estado:            usuario.Estado,
+		EsAdmin:           usuario.EsAdmin,
+		PaisFacturacion:   usuario.PaisFacturacion,
+		RegionFacturacion: usuario.RegionFacturacion,
+		CreadoEn:          usuario.CreadoEn,
 	}
 }
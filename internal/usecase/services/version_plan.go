@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sw2p2go/internal/dto"
+	"sw2p2go/internal/entity"
+	"sw2p2go/internal/usecase/repositories"
+)
+
+var ErrVersionPlanInexistente = errors.New("el plan no tiene esa versión")
+
+// planDeSuscripcion devuelve el plan con los términos de la versión en la que
+// quedó la suscripción, que pueden no ser los vigentes del plan.
+func planDeSuscripcion(ctx context.Context, planRepo repositories.PlanRepository, versionRepo repositories.VersionPlanRepository, suscripcion *entity.Suscripcion) (*entity.PlanSuscripcion, error) {
+	plan, err := planRepo.GetByID(ctx, suscripcion.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	return planEnVersion(ctx, versionRepo, plan, suscripcion.GetPlanVersion())
+}
+
+func planEnVersion(ctx context.Context, versionRepo repositories.VersionPlanRepository, plan *entity.PlanSuscripcion, numero int) (*entity.PlanSuscripcion, error) {
+	if numero == plan.VersionActual() {
+		return plan, nil
+	}
+
+	version, err := versionRepo.GetByNumero(ctx, plan.ID, numero)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, ErrVersionPlanInexistente
+	}
+
+	return plan.ConVersion(version), nil
+}
+
+func versionPlanToDTO(version *entity.VersionPlan, vigente bool) *dto.VersionPlanDTO {
+	return &dto.VersionPlanDTO{
+		Numero:           version.Numero,
+		Precios:          preciosToDTO(version.Precios),
+		ImpuestoIncluido: version.ImpuestoIncluido,
+		Derechos:         derechosToDTO(version.Derechos),
+		Vigente:          vigente,
+		CreadoEn:         version.CreadoEn,
+	}
+}